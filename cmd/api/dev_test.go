@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"ledger/config"
+	"ledger/internal/domain"
+)
+
+// TestDevMode runs the whole API on in-memory storage and queue
+func TestDevMode(t *testing.T) {
+	testAPI(t)
+}
+
+// TestSQLiteStorage runs the whole API on a SQLite database file
+func TestSQLiteStorage(t *testing.T) {
+	t.Setenv("STORAGE", "sqlite")
+	t.Setenv("SQLITE_PATH", filepath.Join(t.TempDir(), "ledger.db"))
+	testAPI(t)
+}
+
+// testAPI starts the API in -dev mode and walks a customer and its accounts
+// through their lifecycle, moving money between them
+func testAPI(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := lis.Addr().String()
+	lis.Close()
+	t.Setenv("HTTP_PORT", addr)
+	t.Setenv("SHUTDOWN_DELAY", "0s")
+	cfg, err := config.LoadDev()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stop, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- run(stop, cfg) }()
+	defer func() {
+		cancel()
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Errorf("api did not shut down cleanly: %v", err)
+			}
+		case <-time.After(10 * time.Second):
+			t.Error("api did not shut down")
+		}
+	}()
+
+	base := "http://" + addr
+	call := func(method, path string, body any, wantStatus int, out any) {
+		t.Helper()
+		var payload bytes.Buffer
+		if body != nil {
+			json.NewEncoder(&payload).Encode(body)
+		}
+		req, _ := http.NewRequest(method, base+path, &payload)
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s: %v", method, path, err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != wantStatus {
+			t.Fatalf("%s %s: status %d, want %d", method, path, resp.StatusCode, wantStatus)
+		}
+		if out != nil {
+			if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+				t.Fatalf("%s %s: decode response: %v", method, path, err)
+			}
+		}
+	}
+
+	// Wait for the server to listen
+	for start := time.Now(); ; time.Sleep(20 * time.Millisecond) {
+		if resp, err := http.Get(base + "/healthz"); err == nil {
+			resp.Body.Close()
+			break
+		}
+		if time.Since(start) > 5*time.Second {
+			t.Fatal("api never started listening")
+		}
+	}
+	call("GET", "/readyz", nil, http.StatusOK, nil)
+
+	var customer domain.Customer
+	call("POST", "/api/v1/customers", map[string]string{"name": "Acme", "email": "ops@acme.test"}, http.StatusCreated, &customer)
+	var account domain.Account
+	call("POST", fmt.Sprintf("/api/v1/customers/%s/accounts", customer.ID), map[string]any{"currency": "USD", "initial_balance": 100}, http.StatusCreated, &account)
+
+	var fetched domain.Account
+	call("GET", "/api/v1/accounts/"+account.ID, nil, http.StatusOK, &fetched)
+	if fetched.Balance != 100 || fetched.CustomerID != customer.ID {
+		t.Errorf("account = %+v, want a balance of 100 owned by %s", fetched, customer.ID)
+	}
+
+	// Transfers address accounts by number
+	var savings domain.Account
+	call("POST", fmt.Sprintf("/api/v1/customers/%s/accounts", customer.ID), map[string]any{"currency": "USD"}, http.StatusCreated, &savings)
+	from, err := strconv.ParseInt(account.ID, 10, 64)
+	if err != nil {
+		t.Fatalf("account id %q is not a number", account.ID)
+	}
+	to, err := strconv.ParseInt(savings.ID, 10, 64)
+	if err != nil {
+		t.Fatalf("account id %q is not a number", savings.ID)
+	}
+	transfer := domain.Transaction{FromAccountID: from, ToAccountID: to, Amount: 30, Currency: "USD"}
+	call("POST", "/api/v1/transactions", transfer, http.StatusCreated, nil)
+	call("GET", "/api/v1/accounts/"+account.ID, nil, http.StatusOK, &fetched)
+	if fetched.Balance != 70 {
+		t.Errorf("sender balance = %v after the transfer, want 70", fetched.Balance)
+	}
+	call("GET", "/api/v1/accounts/"+savings.ID, nil, http.StatusOK, &fetched)
+	if fetched.Balance != 30 {
+		t.Errorf("receiver balance = %v after the transfer, want 30", fetched.Balance)
+	}
+	for _, id := range []string{account.ID, savings.ID} {
+		var history []domain.Transaction
+		call("GET", "/api/v1/accounts/"+id+"/transactions", nil, http.StatusOK, &history)
+		if len(history) != 1 || history[0].FromAccountID != from || history[0].ToAccountID != to || history[0].Amount != 30 {
+			t.Errorf("history of account %s = %+v, want the transfer", id, history)
+		}
+	}
+	call("POST", "/api/v1/accounts/"+account.ID+"/freeze", nil, http.StatusNoContent, nil)
+	call("GET", "/api/v1/accounts/"+account.ID, nil, http.StatusOK, &fetched)
+	if fetched.Status != domain.AccountStatusFrozen {
+		t.Errorf("status = %s after freezing", fetched.Status)
+	}
+
+	var balances domain.CustomerBalances
+	call("GET", fmt.Sprintf("/api/v1/customers/%s/balances", customer.ID), nil, http.StatusOK, &balances)
+	if balances.Accounts != 2 || len(balances.Balances) != 1 || balances.Balances[0].Total != 100 {
+		t.Errorf("balances = %+v", balances)
+	}
+
+	// Customers owning accounts cannot be deleted
+	call("DELETE", "/api/v1/customers/"+customer.ID, nil, http.StatusConflict, nil)
+	call("DELETE", "/api/v1/accounts/"+account.ID, nil, http.StatusNoContent, nil)
+	call("DELETE", "/api/v1/accounts/"+savings.ID, nil, http.StatusNoContent, nil)
+	call("DELETE", "/api/v1/customers/"+customer.ID, nil, http.StatusNoContent, nil)
+	call("GET", "/api/v1/customers/"+customer.ID, nil, http.StatusNotFound, nil)
+}
@@ -3,94 +3,333 @@ package main
 import (
 	"context"
 	"errors"
-	"ledger/internal/repository/mongo"
-	"ledger/internal/repository/postgres"
+	"flag"
+	"fmt"
 	"ledger/internal/service"
-	"log"
+	"log/slog"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
 
 	"ledger/config"
+	"ledger/internal/auth"
+	"ledger/internal/domain"
+	"ledger/internal/events"
+	"ledger/internal/grpcapi"
 	"ledger/internal/handler"
+	"ledger/internal/health"
+	"ledger/internal/logging"
+	"ledger/internal/metrics"
+	"ledger/internal/openapi"
 	"ledger/internal/queue"
+	"ledger/internal/ratelimit"
+	"ledger/internal/tracing"
+	"ledger/internal/webhook"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(os.Args[2:], os.Stdout); err != nil {
+			fatal("migration failed", err)
+		}
+		return
+	}
+	dev := flag.Bool("dev", false, "keep data and queued transfers in memory, so no Postgres, MongoDB or broker is needed")
+	flag.Parse()
+
 	// Load config/env vars
-	cfg, err := config.Load()
+	load := config.Load
+	if *dev {
+		load = config.LoadDev
+	}
+	cfg, err := load()
+	if err != nil {
+		fatal("failed to load config", err)
+	}
+
+	// Structured logging; every line logged with a request context carries its correlation ID
+	logger, err := logging.NewLogger(os.Stdout, cfg.LogFormat, cfg.LogLevel)
+	if err != nil {
+		fatal("failed to configure logging", err)
+	}
+	slog.SetDefault(logger)
+
+	ctx, stopSignals := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stopSignals()
+	if err := run(ctx, cfg); err != nil {
+		fatal("api failed", err)
+	}
+}
+
+// run serves the API until stop is done, then shuts it down gracefully
+func run(stop context.Context, cfg *config.Config) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Prometheus metrics, served on /metrics
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+	appMetrics := metrics.New(registry)
+
+	// OpenTelemetry tracing; spans are pretty-printed to stderr with TRACE_EXPORTER=stdout
+	shutdownTracing, err := tracing.Setup(ctx, cfg.TraceExporter, "ledger-api", os.Stderr)
+	if err != nil {
+		return fmt.Errorf("configure tracing: %w", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			slog.Error("failed to flush traces", "error", err)
+		}
+	}()
+
+	// JWTs are verified with local keys
+	jwtVerifier, err := auth.NewJWTVerifier(auth.JWTConfig{
+		HMACSecret:     cfg.JWTHMACSecret,
+		PublicKeyFiles: cfg.JWTPublicKeyFiles,
+		Issuer:         cfg.JWTIssuer,
+		Audience:       cfg.JWTAudience,
+	})
 	if err != nil {
-		log.Fatalf("failed to load config: %v", err)
+		return fmt.Errorf("load JWT keys: %w", err)
 	}
 
-	// PostgreSQL setup
-	pgDB, err := config.SetupPostgres(cfg.PostgresDSN)
+	// Load the OpenAPI spec that every /api/v1 request and response is checked against
+	spec, err := openapi.Load()
+	if err != nil {
+		return fmt.Errorf("load OpenAPI spec: %w", err)
+	}
+	validator, err := openapi.NewValidator(spec)
 	if err != nil {
-		log.Fatalf("failed to connect to Postgres: %v", err)
+		return fmt.Errorf("create OpenAPI validator: %w", err)
 	}
+	validator.StrictResponses = cfg.StrictResponseValidation
 
-	// MongoDB setup
-	mongoClient, err := config.SetupMongo(cfg.MongoURI)
+	// Repositories live in Postgres and MongoDB, or in memory with STORAGE=memory
+	stores, err := openStorage(ctx, cfg)
 	if err != nil {
-		log.Fatalf("failed to connect to MongoDB: %v", err)
+		return err
 	}
 
 	// Initialize publisher
-	transactionPublisher, err := queue.NewTransactionPublisher(cfg.RabbitMQURL, cfg.QueueName)
+	queues, err := queue.NewTransport(cfg.QueueSettings(), appMetrics)
 	if err != nil {
-		log.Fatalf("failed to create transaction publisher: %v", err)
+		stores.close(ctx)
+		return fmt.Errorf("configure the queue: %w", err)
 	}
-	// Initialize account handler
-	accountRepo := postgres.NewAccountRepository(pgDB)
-	accountService := service.NewAccountService(accountRepo)
-	accountHandler := handler.NewAccountHandler(accountService)
-	// Initialize ledger Repository
-	ledgerRepo := mongo.NewLedgerRepository(mongoClient, cfg.MongoDBName, cfg.MongoCollection)
+	transactionPublisher, err := queues.Publisher()
+	if err != nil {
+		stores.close(ctx)
+		return fmt.Errorf("create transaction publisher: %w", err)
+	}
+	// Initialize webhook delivery, which receives every account and transaction event
+	webhookService := service.NewWebhookService(stores.webhooks, webhook.NewSender(cfg.WebhookMaxAttempts))
+	webhookService.Start(ctx, cfg.WebhookWorkers)
+	webhookHandler := handler.NewWebhookHandler(auth.NewScopedWebhookService(webhookService))
+
+	// Events go to webhooks and to the in-process bus feeding streaming clients
+	eventBus := events.NewBus(cfg.EventHistorySize)
+	publisher := events.Fanout{webhookService, eventBus}
+
+	// Initialize authentication: stored API keys and JWTs
+	authenticator := &auth.Authenticator{Keys: stores.apiKeys, JWT: jwtVerifier, BootstrapKey: cfg.AuthBootstrapKey}
+	if cfg.AuthDisabled {
+		slog.Warn("authentication is disabled, every request is served as admin")
+		authenticator.Anonymous = &auth.Principal{Subject: "anonymous", Role: domain.RoleAdmin}
+	}
+
+	// Initialize account handler. Handlers get services scoped to the caller's customer.
+	accountRepo := metrics.NewAccountRepository(tracing.NewAccountRepository(stores.accounts, stores.accountSystem), appMetrics)
+	accountService := service.NewAccountService(accountRepo, publisher)
+	scopedAccounts := auth.NewScopedAccountService(tracing.NewAccountService(accountService))
+	accountHandler := handler.NewAccountHandler(scopedAccounts)
+	streamHandler := handler.NewStreamHandler(eventBus, scopedAccounts)
+	// Initialize customer handler
+	customerRepo := tracing.NewCustomerRepository(stores.customers, stores.accountSystem)
+	customerService := service.NewCustomerService(customerRepo, accountRepo)
+	customerHandler := handler.NewCustomerHandler(auth.NewScopedCustomerService(tracing.NewCustomerService(customerService)))
+	apiKeyHandler := handler.NewAPIKeyHandler(auth.NewScopedAPIKeyService(service.NewAPIKeyService(stores.apiKeys, customerRepo)))
+	ledgerRepo := metrics.NewLedgerRepository(tracing.NewLedgerRepository(stores.ledger, stores.ledgerSystem), appMetrics)
 
 	// Initialize transaction service
-	transactionService := service.NewTransactionService(accountRepo, ledgerRepo, *transactionPublisher)
-	transactionHandler := handler.NewTransactionHandler(transactionService)
+	transactionService := service.NewTransactionService(accountRepo, ledgerRepo, transactionPublisher, publisher)
+	transactionService.LowBalanceThreshold = cfg.LowBalanceThreshold
+	transactionService.Transactor = stores.transactor
+	if stores.locker != nil {
+		transactionService.Locker = stores.locker
+	}
+	instrumentedTransactions := metrics.NewTransactionService(tracing.NewTransactionService(transactionService), appMetrics)
+	scopedTransactions := auth.NewScopedTransactionService(instrumentedTransactions, scopedAccounts)
+	transactionHandler := handler.NewTransactionHandler(scopedTransactions)
 
-	// Start consumer in background
-	go func() {
-		err := queue.StartTransactionConsumer(cfg.RabbitMQURL, cfg.QueueName, transactionService)
+	// Consume queued transfers in-process unless cmd/processor does it
+	var consumer queue.Consumer
+	if cfg.ConsumerEnabled {
+		consumer, err = queues.StartConsumer(instrumentedTransactions)
 		if err != nil {
-			log.Fatalf("failed to start transaction consumer: %v", err)
+			transactionPublisher.Close()
+			stores.close(ctx)
+			return fmt.Errorf("start transaction consumer: %w", err)
 		}
-	}()
+	}
+
+	// Readiness pings every dependency; liveness only shows the process is serving
+	readiness := &health.Checker{Timeout: cfg.HealthCheckTimeout}
+	stores.addReadiness(readiness)
+	readiness.Add(cfg.QueueBroker, transactionPublisher.Ping)
+	if consumer != nil {
+		readiness.Add("consumer", consumer.Ping)
+	}
+
+	// Throttle each client per route class, sharing buckets through Postgres when configured
+	limitStore := stores.rateLimitStore(cfg)
+	addressLimiter := ratelimit.ByAddress(limitStore, cfg.RateLimits)
+	limiter := &ratelimit.Limiter{Store: limitStore, Limits: cfg.RateLimits, TrustedProxies: cfg.TrustedProxies}
+	addressLimiter.TrustedProxies = cfg.TrustedProxies
 
 	// Setup HTTP router
 	router := mux.NewRouter()
+	router.Use(tracing.Middleware, logging.Middleware, appMetrics.Middleware)
+	router.HandleFunc("/healthz", health.Liveness).Methods("GET")
+	router.HandleFunc("/readyz", readiness.Readiness).Methods("GET")
+	router.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{})).Methods("GET")
 	api := router.PathPrefix("/api/v1").Subrouter()
-	api.HandleFunc("/accounts", accountHandler.CreateAccount).Methods("POST")
-	api.HandleFunc("/accounts/{id}", accountHandler.GetAccount).Methods("GET")
-	api.HandleFunc("/accounts", accountHandler.GetAllAccounts).Methods("GET")
-	api.HandleFunc("/accounts/{id}/balance", accountHandler.UpdateBalance).Methods("PUT")
-	api.HandleFunc("/accounts/{id}", accountHandler.DeleteAccount).Methods("DELETE")
-	api.HandleFunc("/transactions", transactionHandler.ProcessTransaction).Methods("POST")
-	api.HandleFunc("/transactions/{id}", transactionHandler.GetTransactionHistory).Methods("GET")
+	api.Use(addressLimiter.Middleware, authenticator.Middleware, limiter.Middleware, validator.Middleware)
+	api.HandleFunc("/openapi.yaml", openapi.SpecHandler(spec)).Methods("GET")
+	api.HandleFunc("/openapi.json", openapi.SpecHandler(spec)).Methods("GET")
+	api.HandleFunc("/accounts", auth.Require(domain.RoleOperator, accountHandler.CreateAccount)).Methods("POST")
+	api.HandleFunc("/accounts/{id}", auth.Require(domain.RoleViewer, accountHandler.GetAccount)).Methods("GET")
+	api.HandleFunc("/accounts", auth.Require(domain.RoleViewer, accountHandler.GetAllAccounts)).Methods("GET")
+	api.HandleFunc("/accounts/{id}/balance", auth.Require(domain.RoleAdmin, accountHandler.UpdateBalance)).Methods("PUT")
+	api.HandleFunc("/accounts/{id}", auth.Require(domain.RoleAdmin, accountHandler.DeleteAccount)).Methods("DELETE")
+	api.HandleFunc("/accounts/{id}/freeze", auth.Require(domain.RoleOperator, accountHandler.FreezeAccount)).Methods("POST")
+	api.HandleFunc("/accounts/{id}/unfreeze", auth.Require(domain.RoleOperator, accountHandler.UnfreezeAccount)).Methods("POST")
+	api.HandleFunc("/accounts/{id}/events", auth.Require(domain.RoleViewer, streamHandler.Events)).Methods("GET")
+	api.HandleFunc("/accounts/{id}/events/ws", auth.Require(domain.RoleViewer, streamHandler.EventsWS)).Methods("GET")
+	api.HandleFunc("/customers", auth.Require(domain.RoleOperator, customerHandler.CreateCustomer)).Methods("POST")
+	api.HandleFunc("/customers", auth.Require(domain.RoleViewer, customerHandler.GetAllCustomers)).Methods("GET")
+	api.HandleFunc("/customers/{id}", auth.Require(domain.RoleViewer, customerHandler.GetCustomer)).Methods("GET")
+	api.HandleFunc("/customers/{id}", auth.Require(domain.RoleOperator, customerHandler.UpdateCustomer)).Methods("PUT")
+	api.HandleFunc("/customers/{id}", auth.Require(domain.RoleAdmin, customerHandler.DeleteCustomer)).Methods("DELETE")
+	api.HandleFunc("/customers/{id}/accounts", auth.Require(domain.RoleOperator, customerHandler.OpenAccount)).Methods("POST")
+	api.HandleFunc("/customers/{id}/accounts", auth.Require(domain.RoleViewer, customerHandler.GetCustomerAccounts)).Methods("GET")
+	api.HandleFunc("/customers/{id}/balances", auth.Require(domain.RoleViewer, customerHandler.GetCustomerBalances)).Methods("GET")
+	api.HandleFunc("/transactions", auth.Require(domain.RoleOperator, transactionHandler.ProcessTransaction)).Methods("POST")
+	api.HandleFunc("/webhooks", auth.Require(domain.RoleAdmin, webhookHandler.CreateSubscription)).Methods("POST")
+	api.HandleFunc("/webhooks", auth.Require(domain.RoleAdmin, webhookHandler.ListSubscriptions)).Methods("GET")
+	api.HandleFunc("/webhooks/{id}", auth.Require(domain.RoleAdmin, webhookHandler.GetSubscription)).Methods("GET")
+	api.HandleFunc("/webhooks/{id}", auth.Require(domain.RoleAdmin, webhookHandler.DeleteSubscription)).Methods("DELETE")
+	api.HandleFunc("/webhooks/{id}/deliveries", auth.Require(domain.RoleAdmin, webhookHandler.ListDeliveries)).Methods("GET")
+	api.HandleFunc("/webhooks/deliveries/{id}/replay", auth.Require(domain.RoleAdmin, webhookHandler.ReplayDelivery)).Methods("POST")
+	api.HandleFunc("/api-keys", auth.Require(domain.RoleAdmin, apiKeyHandler.CreateKey)).Methods("POST")
+	api.HandleFunc("/api-keys", auth.Require(domain.RoleAdmin, apiKeyHandler.ListKeys)).Methods("GET")
+	api.HandleFunc("/api-keys/{id}", auth.Require(domain.RoleAdmin, apiKeyHandler.RevokeKey)).Methods("DELETE")
+	api.HandleFunc("/accounts/{id}/transactions", auth.Require(domain.RoleViewer, transactionHandler.GetTransactionHistory)).Methods("GET")
+
+	// Either server failing to serve shuts the API down
+	serveErr := make(chan error, 2)
+
+	// Start gRPC server on its own port
+	var grpcServer *grpc.Server
+	if cfg.GRPCPort != "" {
+		grpcServer = grpcapi.NewServer(scopedAccounts, scopedTransactions,
+			grpc.ChainUnaryInterceptor(logging.UnaryServerInterceptor, auth.UnaryServerInterceptor(authenticator, grpcapi.MethodRoles)),
+			grpc.ChainStreamInterceptor(logging.StreamServerInterceptor, auth.StreamServerInterceptor(authenticator, grpcapi.MethodRoles)),
+		)
+		go func() {
+			slog.Info("starting gRPC server", "addr", cfg.GRPCPort)
+			lis, err := net.Listen("tcp", cfg.GRPCPort)
+			if err == nil {
+				err = grpcServer.Serve(lis)
+			}
+			if err != nil {
+				serveErr <- fmt.Errorf("serve gRPC: %w", err)
+			}
+		}()
+	}
 
 	// Start HTTP server
 	server := &http.Server{
 		Addr:    cfg.HTTPPort,
 		Handler: router,
 	}
-	log.Println("Starting server on", cfg.HTTPPort)
-	if err := server.ListenAndServe(); err != nil && !errors.Is(http.ErrServerClosed, err) {
-		log.Fatalf("failed to start server: %v", err)
+	// Streaming clients would hold Shutdown until its deadline; ending their
+	// subscriptions makes them reconnect and resume elsewhere
+	server.RegisterOnShutdown(eventBus.Close)
+	go func() {
+		slog.Info("starting HTTP server", "addr", cfg.HTTPPort)
+		if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+			serveErr <- fmt.Errorf("serve HTTP: %w", err)
+		}
+	}()
+
+	var failed error
+	select {
+	case failed = <-serveErr:
+	case <-stop.Done():
 	}
-	log.Println("Server stopped gracefully")
-	log.Println("Exiting application")
-	err = mongoClient.Disconnect(context.Background())
-	if err != nil {
-		log.Printf("failed to disconnect from MongoDB: %v", err)
-		return
 
+	// Report not ready and keep serving for ShutdownDelay so the orchestrator
+	// stops routing traffic before the listeners close
+	slog.Info("shutting down", "delay", cfg.ShutdownDelay, "timeout", cfg.ShutdownTimeout)
+	readiness.Shutdown()
+	time.Sleep(cfg.ShutdownDelay)
+
+	// Everything below shares one deadline
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancelShutdown()
+
+	// Stop accepting requests and wait for in-flight ones
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		slog.Error("HTTP requests did not finish in time, closing connections", "error", err)
+		server.Close()
 	}
-	err = pgDB.Close()
-	if err != nil {
-		log.Printf("failed to close PostgreSQL connection: %v", err)
-		return
+	if grpcServer != nil {
+		stopGRPC(shutdownCtx, grpcServer)
+	}
+
+	// Finish the transfers the consumer already received, then stop webhook delivery
+	if consumer != nil {
+		if err := consumer.Shutdown(shutdownCtx); err != nil {
+			slog.Error("failed to drain transaction consumer", "error", err)
+		}
 	}
+	cancel()
+	transactionPublisher.Close()
+	stores.close(shutdownCtx)
+
+	slog.Info("shutdown complete")
+	return failed
+}
+
+// stopGRPC waits for in-flight RPCs, cutting them off when ctx expires
+func stopGRPC(ctx context.Context, s *grpc.Server) {
+	done := make(chan struct{})
+	go func() {
+		s.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		slog.Error("gRPC calls did not finish in time, closing connections")
+		s.Stop()
+		<-done
+	}
+}
 
+// fatal logs err and exits
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"ledger/config"
+	"ledger/internal/migration"
+)
+
+const migrateUsage = "usage: api migrate [up | down [steps] | status]"
+
+// runMigrate implements "api migrate". It only needs POSTGRES_DSN.
+func runMigrate(args []string, out io.Writer) error {
+	cmd := "up"
+	if len(args) > 0 {
+		cmd = args[0]
+	}
+	steps := 1
+	if cmd == "down" && len(args) > 1 {
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n < 1 {
+			return fmt.Errorf("invalid number of steps %q\n%s", args[1], migrateUsage)
+		}
+		steps = n
+	}
+
+	db, err := config.SetupPostgres(config.PostgresDSN())
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	migrator, err := migration.New(db)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	switch cmd {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, m := range applied {
+			fmt.Fprintf(out, "applied %d_%s\n", m.Version, m.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Fprintln(out, "schema is up to date")
+		}
+		return err
+	case "down":
+		reverted, err := migrator.Down(ctx, steps)
+		for _, m := range reverted {
+			fmt.Fprintf(out, "reverted %d_%s\n", m.Version, m.Name)
+		}
+		return err
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		tw := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+		for _, s := range statuses {
+			status, at := "pending", "-"
+			if s.AppliedAt != nil {
+				status, at = "applied", s.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(tw, "%d\t%s\t%s\t%s\n", s.Version, s.Name, status, at)
+		}
+		return tw.Flush()
+	default:
+		return fmt.Errorf("unknown migrate command %q\n%s", cmd, migrateUsage)
+	}
+}
+
+// migrateOnStart brings the schema up to date before the API starts serving
+func migrateOnStart(ctx context.Context, db *sql.DB) error {
+	migrator, err := migration.New(db)
+	if err != nil {
+		return err
+	}
+	applied, err := migrator.Up(ctx)
+	for _, m := range applied {
+		slog.Info("applied migration", "version", m.Version, "name", m.Name)
+	}
+	return err
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+
+	mongodriver "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+
+	"ledger/config"
+	"ledger/internal/domain"
+	"ledger/internal/health"
+	"ledger/internal/ratelimit"
+	"ledger/internal/repository/memory"
+	"ledger/internal/repository/mongo"
+	"ledger/internal/repository/postgres"
+	"ledger/internal/repository/sqlite"
+	"ledger/internal/tracing"
+)
+
+// storage holds the repositories selected by STORAGE, before instrumentation,
+// and the connections behind them
+type storage struct {
+	accounts  domain.AccountRepository
+	ledger    domain.LedgerRepository
+	customers domain.CustomerRepository
+	apiKeys   domain.APIKeyRepository
+	webhooks  domain.WebhookRepository
+	// accountSystem and ledgerSystem are reported as db.system on repository spans
+	accountSystem string
+	ledgerSystem  string
+	// transactor makes transfers atomic when accounts and the ledger share a database
+	transactor domain.Transactor
+	// locker, when set, serializes transfers with the same ID across processes
+	locker domain.Locker
+
+	pgDB        *sql.DB
+	mongoClient *mongodriver.Client
+	sqliteDB    *sql.DB
+}
+
+// openStorage connects to the backends of cfg.Storage and prepares their schema
+func openStorage(ctx context.Context, cfg *config.Config) (*storage, error) {
+	switch cfg.Storage {
+	case "memory":
+		slog.Warn("storing data in memory, everything is lost on restart")
+		db := memory.NewDB()
+		return &storage{
+			accounts:      memory.NewAccountRepository(db),
+			ledger:        memory.NewLedgerRepository(),
+			customers:     memory.NewCustomerRepository(db),
+			apiKeys:       memory.NewAPIKeyRepository(db),
+			webhooks:      memory.NewWebhookRepository(db),
+			accountSystem: tracing.SystemMemory,
+			ledgerSystem:  tracing.SystemMemory,
+		}, nil
+	case "sqlite":
+		db, err := sqlite.Open(ctx, cfg.SQLitePath)
+		if err != nil {
+			return nil, fmt.Errorf("open SQLite database: %w", err)
+		}
+		return &storage{
+			accounts:      sqlite.NewAccountRepository(db),
+			ledger:        sqlite.NewLedgerRepository(db),
+			customers:     sqlite.NewCustomerRepository(db),
+			apiKeys:       sqlite.NewAPIKeyRepository(db),
+			webhooks:      sqlite.NewWebhookRepository(db),
+			accountSystem: tracing.SystemSQLite,
+			ledgerSystem:  tracing.SystemSQLite,
+			transactor:    sqlite.NewTransactor(db),
+			sqliteDB:      db,
+		}, nil
+	}
+
+	pgDB, err := config.SetupPostgres(cfg.PostgresDSN)
+	if err != nil {
+		return nil, fmt.Errorf("connect to Postgres: %w", err)
+	}
+	if cfg.MigrateOnStart {
+		if err := migrateOnStart(ctx, pgDB); err != nil {
+			pgDB.Close()
+			return nil, fmt.Errorf("migrate the database: %w", err)
+		}
+	}
+	mongoClient, err := config.SetupMongo(cfg.MongoURI)
+	if err != nil {
+		pgDB.Close()
+		return nil, fmt.Errorf("connect to MongoDB: %w", err)
+	}
+	s := &storage{
+		accounts:      postgres.NewAccountRepository(pgDB),
+		customers:     postgres.NewCustomerRepository(pgDB),
+		apiKeys:       postgres.NewAPIKeyRepository(pgDB),
+		webhooks:      postgres.NewWebhookRepository(pgDB),
+		locker:        postgres.NewLocker(pgDB),
+		accountSystem: tracing.SystemPostgres,
+		ledgerSystem:  tracing.SystemMongo,
+		pgDB:          pgDB,
+		mongoClient:   mongoClient,
+	}
+	// Make sure the ledger collection's indexes and validator exist
+	mongoLedger := mongo.NewLedgerRepository(mongoClient, cfg.MongoDBName, cfg.MongoCollection)
+	if cfg.MongoBootstrap {
+		if err := mongoLedger.Bootstrap(ctx); err != nil {
+			s.close(ctx)
+			return nil, fmt.Errorf("bootstrap the ledger collection: %w", err)
+		}
+	}
+	s.ledger = mongoLedger
+	return s, nil
+}
+
+// rateLimitStore shares rate limit buckets through Postgres when configured
+func (s *storage) rateLimitStore(cfg *config.Config) ratelimit.Store {
+	if cfg.RateLimitStore == "postgres" && s.pgDB != nil {
+		return ratelimit.NewPostgresStore(s.pgDB)
+	}
+	return ratelimit.NewMemoryStore()
+}
+
+// addReadiness registers a readiness check for every database connection
+func (s *storage) addReadiness(c *health.Checker) {
+	if s.pgDB != nil {
+		c.Add("postgres", s.pgDB.PingContext)
+	}
+	if s.mongoClient != nil {
+		c.Add("mongo", func(ctx context.Context) error { return s.mongoClient.Ping(ctx, readpref.Primary()) })
+	}
+	if s.sqliteDB != nil {
+		c.Add("sqlite", s.sqliteDB.PingContext)
+	}
+}
+
+func (s *storage) close(ctx context.Context) {
+	if s.mongoClient != nil {
+		if err := s.mongoClient.Disconnect(ctx); err != nil {
+			slog.Error("failed to disconnect from MongoDB", "error", err)
+		}
+	}
+	if s.pgDB != nil {
+		if err := s.pgDB.Close(); err != nil {
+			slog.Error("failed to close PostgreSQL connection", "error", err)
+		}
+	}
+	if s.sqliteDB != nil {
+		if err := s.sqliteDB.Close(); err != nil {
+			slog.Error("failed to close SQLite database", "error", err)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+// Command processor consumes queued transfers from RabbitMQ or Kafka and applies them
+// through the same transaction service as the API, so the two can be scaled
+// independently. It serves only health probes and metrics over HTTP.
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+
+	"ledger/config"
+	"ledger/internal/health"
+	"ledger/internal/logging"
+	"ledger/internal/metrics"
+	"ledger/internal/queue"
+	"ledger/internal/repository/mongo"
+	"ledger/internal/repository/postgres"
+	"ledger/internal/service"
+	"ledger/internal/tracing"
+	"ledger/internal/webhook"
+)
+
+func main() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cfg, err := config.Load()
+	if err != nil {
+		fatal("failed to load config", err)
+	}
+
+	logger, err := logging.NewLogger(os.Stdout, cfg.LogFormat, cfg.LogLevel)
+	if err != nil {
+		fatal("failed to configure logging", err)
+	}
+	slog.SetDefault(logger)
+	if cfg.QueueBroker == queue.BrokerMemory {
+		// An in-process queue only ever holds what the same process publishes
+		slog.Error("the processor needs a shared broker, set QUEUE_BROKER to rabbitmq or kafka")
+		os.Exit(1)
+	}
+	if cfg.Storage != "postgres" {
+		slog.Error("the processor applies transfers to the API's Postgres and MongoDB databases, set STORAGE to postgres")
+		os.Exit(1)
+	}
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+	appMetrics := metrics.New(registry)
+
+	shutdownTracing, err := tracing.Setup(ctx, cfg.TraceExporter, "ledger-processor", os.Stderr)
+	if err != nil {
+		fatal("failed to configure tracing", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			slog.Error("failed to flush traces", "error", err)
+		}
+	}()
+
+	pgDB, err := config.SetupPostgres(cfg.PostgresDSN)
+	if err != nil {
+		fatal("failed to connect to Postgres", err)
+	}
+	mongoClient, err := config.SetupMongo(cfg.MongoURI)
+	if err != nil {
+		fatal("failed to connect to MongoDB", err)
+	}
+
+	// Transfer events reach webhook subscribers; streaming clients of the API
+	// only see events for transfers the API applies itself
+	webhookService := service.NewWebhookService(postgres.NewWebhookRepository(pgDB), webhook.NewSender(cfg.WebhookMaxAttempts))
+	webhookService.Start(ctx, cfg.WebhookWorkers)
+
+	accountRepo := metrics.NewAccountRepository(tracing.NewAccountRepository(postgres.NewAccountRepository(pgDB), tracing.SystemPostgres), appMetrics)
+	mongoLedger := mongo.NewLedgerRepository(mongoClient, cfg.MongoDBName, cfg.MongoCollection)
+	if cfg.MongoBootstrap {
+		if err := mongoLedger.Bootstrap(ctx); err != nil {
+			fatal("failed to bootstrap the ledger collection", err)
+		}
+	}
+	ledgerRepo := metrics.NewLedgerRepository(tracing.NewLedgerRepository(mongoLedger, tracing.SystemMongo), appMetrics)
+	// The processor never queues transfers itself, so it has no publisher
+	transactionService := service.NewTransactionService(accountRepo, ledgerRepo, nil, webhookService)
+	transactionService.LowBalanceThreshold = cfg.LowBalanceThreshold
+	// The API and other processors apply the same transfers
+	transactionService.Locker = postgres.NewLocker(pgDB)
+	instrumentedTransactions := metrics.NewTransactionService(tracing.NewTransactionService(transactionService), appMetrics)
+
+	queues, err := queue.NewTransport(cfg.QueueSettings(), appMetrics)
+	if err != nil {
+		fatal("failed to configure the queue", err)
+	}
+	consumer, err := queues.StartConsumer(instrumentedTransactions)
+	if err != nil {
+		fatal("failed to start transaction consumer", err)
+	}
+
+	readiness := &health.Checker{Timeout: cfg.HealthCheckTimeout}
+	readiness.Add("postgres", pgDB.PingContext)
+	readiness.Add("mongo", func(ctx context.Context) error { return mongoClient.Ping(ctx, readpref.Primary()) })
+	readiness.Add("consumer", consumer.Ping)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/healthz", health.Liveness).Methods("GET")
+	router.HandleFunc("/readyz", readiness.Readiness).Methods("GET")
+	router.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{})).Methods("GET")
+	server := &http.Server{
+		Addr:    cfg.HTTPPort,
+		Handler: router,
+	}
+	serveErr := make(chan error, 1)
+	go func() {
+		slog.Info("starting health and metrics server", "addr", cfg.HTTPPort)
+		serveErr <- server.ListenAndServe()
+	}()
+
+	stop, stopSignals := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stopSignals()
+	select {
+	case err := <-serveErr:
+		fatal("failed to start server", err)
+	case <-stop.Done():
+	}
+
+	slog.Info("shutting down", "timeout", cfg.ShutdownTimeout)
+	readiness.Shutdown()
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancelShutdown()
+
+	// Finish the transfers already received before anything they depend on goes away
+	if err := consumer.Shutdown(shutdownCtx); err != nil {
+		slog.Error("failed to drain transaction consumer", "error", err)
+	}
+	cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		slog.Error("failed to shut down health server", "error", err)
+	}
+	if err := mongoClient.Disconnect(shutdownCtx); err != nil {
+		slog.Error("failed to disconnect from MongoDB", "error", err)
+	}
+	if err := pgDB.Close(); err != nil {
+		slog.Error("failed to close PostgreSQL connection", "error", err)
+	}
+	slog.Info("shutdown complete")
+}
+
+// fatal logs err and exits
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
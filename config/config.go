@@ -4,16 +4,27 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
-	"log"
+	"log/slog"
+	"net/netip"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	_ "github.com/lib/pq" // PostgreSQL driver
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"ledger/internal/queue"
+	"ledger/internal/ratelimit"
 )
 
 type Config struct {
+	// Storage is "postgres" for accounts in Postgres and the ledger in MongoDB,
+	// "sqlite" to keep all data in the single file at SQLitePath, or "memory" to
+	// keep it in process memory, where it is lost on restart
+	Storage         string
+	SQLitePath      string
 	PostgresDSN     string
 	MongoURI        string
 	MongoDBName     string
@@ -21,28 +32,357 @@ type Config struct {
 	RabbitMQURL     string
 	QueueName       string
 	HTTPPort        string
+	// QueueBroker is "rabbitmq", "kafka" or "memory"; the in-process broker loses queued transfers on restart
+	QueueBroker string
+	// KafkaBrokers are the seed brokers when QueueBroker is "kafka"; QueueName is then the topic
+	KafkaBrokers []string
+	// KafkaConsumerGroup is the group consumers commit their offsets under
+	KafkaConsumerGroup string
+	// GRPCPort enables the gRPC API on its own listener when set, e.g. ":9090"
+	GRPCPort string
+	// WebhookWorkers is the number of goroutines delivering webhooks
+	WebhookWorkers int
+	// WebhookMaxAttempts bounds how often a failing delivery is retried
+	WebhookMaxAttempts int
+	// LowBalanceThreshold emits balance.low when a transfer leaves an account below it; 0 disables it
+	LowBalanceThreshold float64
+	// EventHistorySize is how many recent events streaming clients can resume from
+	EventHistorySize int
+	// AuthDisabled serves every request as an anonymous admin; only meant for local development
+	AuthDisabled bool
+	// AuthBootstrapKey is accepted as an admin API key so the first stored keys can be created
+	AuthBootstrapKey string
+	// JWT bearer tokens are verified with these locally configured keys; JWTs are rejected when none is set
+	JWTHMACSecret     string
+	JWTPublicKeyFiles []string
+	JWTIssuer         string
+	JWTAudience       string
+	// StrictResponseValidation turns responses that violate the OpenAPI spec into 500s instead of logging them
+	StrictResponseValidation bool
+	// RateLimits maps route classes (read, write, transfer) to their per-client
+	// quota, and the address class to the quota of each client address
+	RateLimits map[string]ratelimit.Limit
+	// TrustedProxies are the load balancers whose X-Forwarded-For header names the client address to rate limit
+	TrustedProxies []netip.Prefix
+	// LogFormat is "json" or "text"; LogLevel is debug, info, warn or error
+	LogFormat string
+	LogLevel  string
+	// RateLimitStore is "memory" for per-replica buckets or "postgres" to share one quota across replicas
+	RateLimitStore string
+	// MigrateOnStart applies pending schema migrations before the API starts serving
+	MigrateOnStart bool
+	// MongoBootstrap creates the ledger collection's indexes and validator on startup
+	MongoBootstrap bool
+	// PublishConfirmTimeout bounds how long queueing a transfer waits for RabbitMQ to confirm it
+	PublishConfirmTimeout time.Duration
+	// ConsumerEnabled runs the transaction consumer inside the API; disable it when cmd/processor consumes the queue
+	ConsumerEnabled bool
+	// ConsumerMaxAttempts is how often a transfer failing with a transient error is tried before it is dead-lettered
+	ConsumerMaxAttempts int
+	// ConsumerRetryBaseDelay is the delay before the first retry; it doubles per attempt up to ConsumerRetryMaxDelay
+	ConsumerRetryBaseDelay time.Duration
+	ConsumerRetryMaxDelay  time.Duration
+	// ConsumerWorkers processes transfers from different source accounts in parallel
+	ConsumerWorkers int
+	// ConsumerPrefetch caps unacknowledged deliveries; 0 uses four per worker
+	ConsumerPrefetch int
+	// HealthCheckTimeout bounds each dependency ping made by /readyz
+	HealthCheckTimeout time.Duration
+	// ShutdownDelay is how long the API keeps serving, while reporting not ready, after a shutdown signal
+	ShutdownDelay time.Duration
+	// ShutdownTimeout bounds draining requests, the consumer and closing connections after a shutdown signal
+	ShutdownTimeout time.Duration
+	// TraceExporter is "none", "stdout" or "otlp"; the OTLP endpoint comes from OTEL_EXPORTER_OTLP_ENDPOINT
+	TraceExporter string
 }
 
 // Load reads environment variables into a config struct
 func Load() (*Config, error) {
+	return load(false)
+}
+
+// LoadDev is Load with the defaults of the API's -dev mode: data and queue in
+// memory, authentication disabled and HTTP on :8080. Environment variables
+// still override them.
+func LoadDev() (*Config, error) {
+	return load(true)
+}
+
+func load(dev bool) (*Config, error) {
+	storage, broker, httpPort := "postgres", "rabbitmq", ""
+	if dev {
+		storage, broker, httpPort = "memory", "memory", ":8080"
+	}
 
 	cfg := &Config{
+		Storage:         envString("STORAGE", storage),
+		SQLitePath:      envString("SQLITE_PATH", "ledger.db"),
 		PostgresDSN:     os.Getenv("POSTGRES_DSN"),
 		MongoURI:        os.Getenv("MONGO_URI"),
 		MongoDBName:     os.Getenv("MONGO_DB_NAME"),
 		MongoCollection: os.Getenv("MONGO_COLLECTION"),
 		RabbitMQURL:     os.Getenv("RABBITMQ_URL"),
 		QueueName:       os.Getenv("QUEUE_NAME"),
-		HTTPPort:        os.Getenv("HTTP_PORT"),
+		HTTPPort:        envString("HTTP_PORT", httpPort),
+		GRPCPort:        os.Getenv("GRPC_PORT"),
+
+		ConsumerEnabled: os.Getenv("CONSUMER_ENABLED") != "false",
+		MigrateOnStart:  os.Getenv("MIGRATE_ON_START") == "true",
+		MongoBootstrap:  os.Getenv("MONGO_BOOTSTRAP") != "false",
+
+		AuthDisabled:     envString("AUTH_DISABLED", strconv.FormatBool(dev)) == "true",
+		AuthBootstrapKey: os.Getenv("AUTH_BOOTSTRAP_KEY"),
+		JWTHMACSecret:    os.Getenv("JWT_HMAC_SECRET"),
+		JWTIssuer:        os.Getenv("JWT_ISSUER"),
+		JWTAudience:      os.Getenv("JWT_AUDIENCE"),
+
+		StrictResponseValidation: os.Getenv("OPENAPI_STRICT_RESPONSES") == "true",
+
+		RateLimitStore: os.Getenv("RATE_LIMIT_STORE"),
+		LogFormat:      envString("LOG_FORMAT", "json"),
+		LogLevel:       envString("LOG_LEVEL", "info"),
+		TraceExporter:  envString("TRACE_EXPORTER", "none"),
+		QueueBroker:    envString("QUEUE_BROKER", broker),
+
+		KafkaConsumerGroup: envString("KAFKA_CONSUMER_GROUP", "ledger-processor"),
+	}
+	if brokers := os.Getenv("KAFKA_BROKERS"); brokers != "" {
+		cfg.KafkaBrokers = strings.Split(brokers, ",")
+	}
+	if files := os.Getenv("JWT_PUBLIC_KEY_FILES"); files != "" {
+		cfg.JWTPublicKeyFiles = strings.Split(files, ",")
 	}
 
-	if cfg.PostgresDSN == "" || cfg.MongoURI == "" || cfg.MongoDBName == "" || cfg.RabbitMQURL == "" || cfg.HTTPPort == "" {
+	var err error
+	if cfg.WebhookWorkers, err = envInt("WEBHOOK_WORKERS", 4); err != nil {
+		return nil, err
+	}
+	if cfg.WebhookMaxAttempts, err = envInt("WEBHOOK_MAX_ATTEMPTS", 8); err != nil {
+		return nil, err
+	}
+	if cfg.EventHistorySize, err = envInt("EVENT_HISTORY_SIZE", 1000); err != nil {
+		return nil, err
+	}
+	if cfg.LowBalanceThreshold, err = envFloat("LOW_BALANCE_THRESHOLD", 0); err != nil {
+		return nil, err
+	}
+	if cfg.PublishConfirmTimeout, err = envDuration("PUBLISH_CONFIRM_TIMEOUT", 5*time.Second); err != nil {
+		return nil, err
+	}
+	if cfg.ConsumerMaxAttempts, err = envInt("CONSUMER_MAX_ATTEMPTS", 5); err != nil {
+		return nil, err
+	}
+	if cfg.ConsumerWorkers, err = envInt("CONSUMER_WORKERS", 4); err != nil {
+		return nil, err
+	}
+	if cfg.ConsumerPrefetch, err = envInt("CONSUMER_PREFETCH", 0); err != nil {
+		return nil, err
+	}
+	if cfg.ConsumerRetryBaseDelay, err = envDuration("CONSUMER_RETRY_BASE_DELAY", time.Second); err != nil {
+		return nil, err
+	}
+	if cfg.ConsumerRetryMaxDelay, err = envDuration("CONSUMER_RETRY_MAX_DELAY", time.Minute); err != nil {
+		return nil, err
+	}
+	if cfg.HealthCheckTimeout, err = envDuration("HEALTH_CHECK_TIMEOUT", 2*time.Second); err != nil {
+		return nil, err
+	}
+	if cfg.ShutdownDelay, err = envDuration("SHUTDOWN_DELAY", 5*time.Second); err != nil {
+		return nil, err
+	}
+	if cfg.ShutdownTimeout, err = envDuration("SHUTDOWN_TIMEOUT", 20*time.Second); err != nil {
+		return nil, err
+	}
+	cfg.RateLimits = make(map[string]ratelimit.Limit)
+	for class, def := range map[string]string{
+		ratelimit.ClassRead:     "50:100",
+		ratelimit.ClassWrite:    "10:20",
+		ratelimit.ClassTransfer: "5:10",
+		ratelimit.ClassAddress:  "100:200",
+	} {
+		if cfg.RateLimits[class], err = envLimit("RATE_LIMIT_"+strings.ToUpper(class), def); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.TrustedProxies, err = envPrefixes("RATE_LIMIT_TRUSTED_PROXIES"); err != nil {
+		return nil, err
+	}
+	switch cfg.RateLimitStore {
+	case "":
+		cfg.RateLimitStore = "memory"
+	case "memory", "postgres":
+	default:
+		return nil, fmt.Errorf("invalid RATE_LIMIT_STORE %q, want memory or postgres", cfg.RateLimitStore)
+	}
+
+	if cfg.ConsumerMaxAttempts < 1 {
+		return nil, fmt.Errorf("invalid CONSUMER_MAX_ATTEMPTS %d, want at least 1", cfg.ConsumerMaxAttempts)
+	}
+	if cfg.ConsumerWorkers < 1 || cfg.ConsumerPrefetch < 0 {
+		return nil, fmt.Errorf("invalid CONSUMER_WORKERS %d or CONSUMER_PREFETCH %d", cfg.ConsumerWorkers, cfg.ConsumerPrefetch)
+	}
+	if cfg.ConsumerRetryBaseDelay <= 0 || cfg.ConsumerRetryMaxDelay < cfg.ConsumerRetryBaseDelay {
+		return nil, fmt.Errorf("invalid consumer retry delays %s..%s", cfg.ConsumerRetryBaseDelay, cfg.ConsumerRetryMaxDelay)
+	}
+
+	switch cfg.TraceExporter {
+	case "none", "stdout", "otlp":
+	default:
+		return nil, fmt.Errorf("invalid TRACE_EXPORTER %q, want none, stdout or otlp", cfg.TraceExporter)
+	}
+
+	switch cfg.QueueBroker {
+	case "rabbitmq":
+		if cfg.RabbitMQURL == "" {
+			return nil, fmt.Errorf("missing RABBITMQ_URL")
+		}
+	case "kafka":
+		if len(cfg.KafkaBrokers) == 0 {
+			return nil, fmt.Errorf("missing KAFKA_BROKERS")
+		}
+	case "memory":
+		// Nothing outside the process can read the queue
+		if !cfg.ConsumerEnabled {
+			return nil, fmt.Errorf("QUEUE_BROKER=memory needs the in-process consumer, unset CONSUMER_ENABLED")
+		}
+	default:
+		return nil, fmt.Errorf("invalid QUEUE_BROKER %q, want rabbitmq, kafka or memory", cfg.QueueBroker)
+	}
+
+	switch cfg.Storage {
+	case "postgres":
+		if cfg.PostgresDSN == "" || cfg.MongoURI == "" || cfg.MongoDBName == "" {
+			return nil, fmt.Errorf("missing one or more required environment variables")
+		}
+	case "sqlite":
+		if cfg.SQLitePath == "" {
+			return nil, fmt.Errorf("missing SQLITE_PATH")
+		}
+	case "memory":
+	default:
+		return nil, fmt.Errorf("invalid STORAGE %q, want postgres, sqlite or memory", cfg.Storage)
+	}
+	if cfg.RateLimitStore == "postgres" && cfg.Storage != "postgres" {
+		return nil, fmt.Errorf("RATE_LIMIT_STORE=postgres needs STORAGE=postgres")
+	}
+	if cfg.HTTPPort == "" {
 		return nil, fmt.Errorf("missing one or more required environment variables")
 	}
 
 	return cfg, nil
 }
 
+// QueueSettings configures the transport to the broker selected by QUEUE_BROKER
+func (c *Config) QueueSettings() queue.Settings {
+	return queue.Settings{
+		Broker:             c.QueueBroker,
+		Queue:              c.QueueName,
+		RabbitMQURL:        c.RabbitMQURL,
+		ConfirmTimeout:     c.PublishConfirmTimeout,
+		KafkaBrokers:       c.KafkaBrokers,
+		KafkaConsumerGroup: c.KafkaConsumerGroup,
+		Consumer: queue.ConsumerOptions{
+			Retry: queue.RetryPolicy{
+				MaxAttempts: c.ConsumerMaxAttempts,
+				BaseDelay:   c.ConsumerRetryBaseDelay,
+				MaxDelay:    c.ConsumerRetryMaxDelay,
+			},
+			Workers:  c.ConsumerWorkers,
+			Prefetch: c.ConsumerPrefetch,
+		},
+	}
+}
+
+// PostgresDSN reads only POSTGRES_DSN, for commands such as "migrate" that need nothing else
+func PostgresDSN() string {
+	return os.Getenv("POSTGRES_DSN")
+}
+
+// envString reads a string environment variable, falling back to def when unset
+func envString(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+// envInt reads an integer environment variable, falling back to def when unset
+func envInt(key string, def int) (int, error) {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return def, nil
+	}
+	v, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return v, nil
+}
+
+// envLimit reads a "<rate>:<burst>" rate limit; "off" disables limiting
+func envLimit(key, def string) (ratelimit.Limit, error) {
+	raw := os.Getenv(key)
+	if raw == "" {
+		raw = def
+	}
+	if raw == "off" {
+		return ratelimit.Limit{}, nil
+	}
+	l, err := ratelimit.ParseLimit(raw)
+	if err != nil {
+		return ratelimit.Limit{}, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return l, nil
+}
+
+// envPrefixes reads a comma-separated list of CIDRs; a bare address stands for itself
+func envPrefixes(key string) ([]netip.Prefix, error) {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return nil, nil
+	}
+	var prefixes []netip.Prefix
+	for _, s := range strings.Split(raw, ",") {
+		s = strings.TrimSpace(s)
+		p, err := netip.ParsePrefix(s)
+		if err != nil {
+			addr, addrErr := netip.ParseAddr(s)
+			if addrErr != nil {
+				return nil, fmt.Errorf("invalid %s: %w", key, err)
+			}
+			p = netip.PrefixFrom(addr, addr.BitLen())
+		}
+		prefixes = append(prefixes, p.Masked())
+	}
+	return prefixes, nil
+}
+
+// envFloat reads a float environment variable, falling back to def when unset
+func envFloat(key string, def float64) (float64, error) {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return def, nil
+	}
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return v, nil
+}
+
+// envDuration reads a duration such as "500ms" from the environment, falling back to def when unset
+func envDuration(key string, def time.Duration) (time.Duration, error) {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return def, nil
+	}
+	v, err := time.ParseDuration(raw)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return v, nil
+}
+
 // SetupPostgres connects to PostgreSQL using the standard library
 func SetupPostgres(dsn string) (*sql.DB, error) {
 	db, err := sql.Open("postgres", dsn)
@@ -58,7 +398,7 @@ func SetupPostgres(dsn string) (*sql.DB, error) {
 		return nil, fmt.Errorf("postgres ping failed: %w", err)
 	}
 
-	log.Println("Connected to PostgreSQL")
+	slog.Info("connected to PostgreSQL")
 	return db, nil
 }
 
@@ -77,6 +417,6 @@ func SetupMongo(uri string) (*mongo.Client, error) {
 		return nil, fmt.Errorf("mongo ping failed: %w", err)
 	}
 
-	log.Println("Connected to MongoDB")
+	slog.Info("connected to MongoDB")
 	return client, nil
 }
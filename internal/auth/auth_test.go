@@ -0,0 +1,229 @@
+package auth_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"ledger/internal/auth"
+	"ledger/internal/domain"
+)
+
+type fakeKeyRepo struct {
+	domain.APIKeyRepository
+	keys map[string]*domain.APIKey // by hash
+}
+
+func (f *fakeKeyRepo) GetByHash(ctx context.Context, hash string) (*domain.APIKey, error) {
+	if k, ok := f.keys[hash]; ok {
+		return k, nil
+	}
+	return nil, domain.ErrAPIKeyNotFound
+}
+
+func TestPrincipal_HasRole(t *testing.T) {
+	operator := &auth.Principal{Role: domain.RoleOperator}
+	if !operator.HasRole(domain.RoleViewer) || !operator.HasRole(domain.RoleOperator) {
+		t.Error("operator should satisfy viewer and operator")
+	}
+	if operator.HasRole(domain.RoleAdmin) {
+		t.Error("operator should not satisfy admin")
+	}
+	if (&auth.Principal{Role: "root"}).HasRole(domain.RoleViewer) {
+		t.Error("unknown roles should satisfy nothing")
+	}
+}
+
+func TestGenerateAPIKey(t *testing.T) {
+	key, prefix, hash, err := auth.GenerateAPIKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !auth.IsAPIKey(key) || len(prefix) != 11 || key[:11] != prefix {
+		t.Errorf("unexpected key %q with prefix %q", key, prefix)
+	}
+	if hash != auth.HashAPIKey(key) || hash == key {
+		t.Error("hash should be derived from the key and differ from it")
+	}
+}
+
+func signHMAC(t *testing.T, secret string, claims jwt.Claims) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func claims(role, customerID string, exp time.Time) auth.Claims {
+	return auth.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{Subject: "user-1", Issuer: "idp", ExpiresAt: jwt.NewNumericDate(exp)},
+		Role:             role,
+		CustomerID:       customerID,
+	}
+}
+
+func TestJWTVerifier_HMAC(t *testing.T) {
+	v, err := auth.NewJWTVerifier(auth.JWTConfig{HMACSecret: "secret", Issuer: "idp"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := v.Verify(signHMAC(t, "secret", claims(domain.RoleViewer, "cust1", time.Now().Add(time.Hour))))
+	if err != nil {
+		t.Fatalf("expected valid token, got %v", err)
+	}
+	if p.Subject != "user-1" || p.Role != domain.RoleViewer || p.CustomerID != "cust1" {
+		t.Errorf("unexpected principal %+v", p)
+	}
+
+	bad := map[string]string{
+		"wrong secret": signHMAC(t, "other", claims(domain.RoleViewer, "", time.Now().Add(time.Hour))),
+		"expired":      signHMAC(t, "secret", claims(domain.RoleViewer, "", time.Now().Add(-time.Hour))),
+		"unknown role": signHMAC(t, "secret", claims("root", "", time.Now().Add(time.Hour))),
+		"no expiry":    signHMAC(t, "secret", auth.Claims{RegisteredClaims: jwt.RegisteredClaims{Subject: "u", Issuer: "idp"}, Role: domain.RoleAdmin}),
+	}
+	for name, token := range bad {
+		if _, err := v.Verify(token); err == nil {
+			t.Errorf("%s: expected token to be rejected", name)
+		}
+	}
+}
+
+func TestJWTVerifier_PublicKeyFile(t *testing.T) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, _ := x509.MarshalPKIXPublicKey(&priv.PublicKey)
+	path := filepath.Join(t.TempDir(), "idp.pem")
+	os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o600)
+
+	v, err := auth.NewJWTVerifier(auth.JWTConfig{PublicKeyFiles: []string{path}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, _ := jwt.NewWithClaims(jwt.SigningMethodES256, claims(domain.RoleAdmin, "", time.Now().Add(time.Hour))).SignedString(priv)
+	if _, err := v.Verify(token); err != nil {
+		t.Errorf("expected ES256 token to verify, got %v", err)
+	}
+
+	// An HMAC token must not be accepted by a verifier configured only with a public key
+	if _, err := v.Verify(signHMAC(t, "secret", claims(domain.RoleAdmin, "", time.Now().Add(time.Hour)))); err == nil {
+		t.Error("expected HS256 token to be rejected")
+	}
+}
+
+func TestNewJWTVerifier_NoKeys(t *testing.T) {
+	v, err := auth.NewJWTVerifier(auth.JWTConfig{})
+	if err != nil || v != nil {
+		t.Errorf("expected nil verifier without keys, got %v %v", v, err)
+	}
+}
+
+func newTestAuthenticator(t *testing.T) (*auth.Authenticator, string) {
+	t.Helper()
+	key, _, hash, _ := auth.GenerateAPIKey()
+	v, _ := auth.NewJWTVerifier(auth.JWTConfig{HMACSecret: "secret"})
+	return &auth.Authenticator{
+		Keys: &fakeKeyRepo{keys: map[string]*domain.APIKey{
+			hash: {ID: "key1", Role: domain.RoleOperator, CustomerID: "cust1"},
+		}},
+		JWT:          v,
+		BootstrapKey: "bootstrap-secret",
+	}, key
+}
+
+// serve runs the authenticator and Require(role) around a handler reporting the principal
+func serve(a *auth.Authenticator, role string, r *http.Request) (*httptest.ResponseRecorder, *auth.Principal) {
+	var got *auth.Principal
+	h := a.Middleware(auth.Require(role, func(w http.ResponseWriter, r *http.Request) {
+		got, _ = auth.FromContext(r.Context())
+	}))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w, got
+}
+
+func TestMiddleware_APIKey(t *testing.T) {
+	a, key := newTestAuthenticator(t)
+
+	r := httptest.NewRequest("GET", "/accounts", nil)
+	r.Header.Set("Authorization", "Bearer "+key)
+	w, p := serve(a, domain.RoleViewer, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if p.Subject != "key1" || p.CustomerID != "cust1" {
+		t.Errorf("unexpected principal %+v", p)
+	}
+
+	r = httptest.NewRequest("GET", "/accounts", nil)
+	r.Header.Set("X-API-Key", key)
+	if w, _ := serve(a, domain.RoleAdmin, r); w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for operator on admin route, got %d", w.Code)
+	}
+}
+
+func TestMiddleware_JWTAndQueryToken(t *testing.T) {
+	a, _ := newTestAuthenticator(t)
+	token := signHMAC(t, "secret", claims(domain.RoleViewer, "", time.Now().Add(time.Hour)))
+
+	r := httptest.NewRequest("GET", "/accounts/acc1/events?access_token="+token, nil)
+	w, p := serve(a, domain.RoleViewer, r)
+
+	if w.Code != http.StatusOK || p.Subject != "user-1" {
+		t.Errorf("expected JWT from query to authenticate, got %d %+v", w.Code, p)
+	}
+}
+
+func TestMiddleware_Rejections(t *testing.T) {
+	a, _ := newTestAuthenticator(t)
+
+	r := httptest.NewRequest("GET", "/accounts", nil)
+	if w, _ := serve(a, domain.RoleViewer, r); w.Code != http.StatusUnauthorized || w.Header().Get("WWW-Authenticate") == "" {
+		t.Errorf("expected 401 with challenge without credentials, got %d", w.Code)
+	}
+
+	r = httptest.NewRequest("GET", "/accounts", nil)
+	r.Header.Set("Authorization", "Bearer lk_unknown")
+	if w, _ := serve(a, domain.RoleViewer, r); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 for unknown key, got %d", w.Code)
+	}
+
+	r = httptest.NewRequest("GET", "/accounts", nil)
+	r.Header.Set("Authorization", "Bearer not-a-jwt")
+	if w, _ := serve(a, domain.RoleViewer, r); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 for malformed token, got %d", w.Code)
+	}
+}
+
+func TestMiddleware_BootstrapAndAnonymous(t *testing.T) {
+	a, _ := newTestAuthenticator(t)
+
+	r := httptest.NewRequest("POST", "/api-keys", nil)
+	r.Header.Set("Authorization", "Bearer bootstrap-secret")
+	if w, _ := serve(a, domain.RoleAdmin, r); w.Code != http.StatusOK {
+		t.Errorf("expected bootstrap key to act as admin, got %d", w.Code)
+	}
+
+	a.Anonymous = &auth.Principal{Subject: "anonymous", Role: domain.RoleAdmin}
+	r = httptest.NewRequest("DELETE", "/accounts/acc1", nil)
+	if w, _ := serve(a, domain.RoleAdmin, r); w.Code != http.StatusOK {
+		t.Errorf("expected anonymous principal to be used, got %d", w.Code)
+	}
+}
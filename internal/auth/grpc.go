@@ -0,0 +1,78 @@
+package auth
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"ledger/internal/domain"
+	"ledger/internal/errmap"
+)
+
+// UnaryServerInterceptor authenticates the "authorization" metadata of each call and
+// checks the role listed for its full method name. Unlisted methods require admin.
+func UnaryServerInterceptor(a *Authenticator, roles map[string]string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := a.authorizeCall(ctx, info.FullMethod, roles)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor is the streaming counterpart of UnaryServerInterceptor
+func StreamServerInterceptor(a *Authenticator, roles map[string]string) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := a.authorizeCall(ss.Context(), info.FullMethod, roles)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &principalStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+func (a *Authenticator) authorizeCall(ctx context.Context, method string, roles map[string]string) (context.Context, error) {
+	var credential string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get("authorization"); len(v) > 0 {
+			scheme, token, ok := strings.Cut(v[0], " ")
+			if ok && strings.EqualFold(scheme, "Bearer") {
+				credential = strings.TrimSpace(token)
+			}
+		}
+	}
+	p := a.Anonymous
+	if credential != "" {
+		var err error
+		if p, err = a.Authenticate(ctx, credential); err != nil {
+			return nil, status.Error(errmap.GRPCCode(err), err.Error())
+		}
+	}
+	if p == nil {
+		return nil, status.Error(codes.Unauthenticated, domain.ErrUnauthenticated.Error())
+	}
+
+	role, ok := roles[method]
+	if !ok {
+		role = domain.RoleAdmin
+	}
+	if !p.HasRole(role) {
+		return nil, status.Errorf(codes.PermissionDenied, "%s role required", role)
+	}
+	return WithPrincipal(ctx, p), nil
+}
+
+// principalStream overrides the context of a server stream
+type principalStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *principalStream) Context() context.Context {
+	return s.ctx
+}
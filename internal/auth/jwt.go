@@ -0,0 +1,96 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"ledger/internal/domain"
+)
+
+// JWTConfig lists the locally configured keys and expected claims for bearer tokens
+type JWTConfig struct {
+	// HMACSecret verifies HS256/384/512 tokens
+	HMACSecret string
+	// PublicKeyFiles are PEM files with RSA, ECDSA or Ed25519 public keys
+	PublicKeyFiles []string
+	Issuer         string
+	Audience       string
+}
+
+// Claims are the token claims the API understands
+type Claims struct {
+	jwt.RegisteredClaims
+	Role       string `json:"role"`
+	CustomerID string `json:"customer_id,omitempty"`
+}
+
+// JWTVerifier checks bearer tokens against the configured keys
+type JWTVerifier struct {
+	keys    jwt.VerificationKeySet
+	methods []string
+	opts    []jwt.ParserOption
+}
+
+// NewJWTVerifier loads the configured keys. It returns nil when no key is configured.
+func NewJWTVerifier(cfg JWTConfig) (*JWTVerifier, error) {
+	v := &JWTVerifier{}
+	if cfg.HMACSecret != "" {
+		v.keys.Keys = append(v.keys.Keys, []byte(cfg.HMACSecret))
+		v.methods = append(v.methods, "HS256", "HS384", "HS512")
+	}
+	for _, path := range cfg.PublicKeyFiles {
+		pem, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read jwt public key: %w", err)
+		}
+		key, methods, err := parsePublicKey(pem)
+		if err != nil {
+			return nil, fmt.Errorf("parse jwt public key %s: %w", path, err)
+		}
+		v.keys.Keys = append(v.keys.Keys, key)
+		v.methods = append(v.methods, methods...)
+	}
+	if len(v.keys.Keys) == 0 {
+		return nil, nil
+	}
+
+	v.opts = []jwt.ParserOption{jwt.WithValidMethods(v.methods), jwt.WithExpirationRequired()}
+	if cfg.Issuer != "" {
+		v.opts = append(v.opts, jwt.WithIssuer(cfg.Issuer))
+	}
+	if cfg.Audience != "" {
+		v.opts = append(v.opts, jwt.WithAudience(cfg.Audience))
+	}
+	return v, nil
+}
+
+func parsePublicKey(pem []byte) (jwt.VerificationKey, []string, error) {
+	if key, err := jwt.ParseRSAPublicKeyFromPEM(pem); err == nil {
+		return key, []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512"}, nil
+	}
+	if key, err := jwt.ParseECPublicKeyFromPEM(pem); err == nil {
+		return key, []string{"ES256", "ES384", "ES512"}, nil
+	}
+	if key, err := jwt.ParseEdPublicKeyFromPEM(pem); err == nil {
+		return key, []string{"EdDSA"}, nil
+	}
+	return nil, nil, errors.New("not an RSA, ECDSA or Ed25519 public key")
+}
+
+// Verify validates token and returns the principal it describes
+func (v *JWTVerifier) Verify(token string) (*Principal, error) {
+	var claims Claims
+	_, err := jwt.ParseWithClaims(token, &claims, func(*jwt.Token) (any, error) {
+		return v.keys, nil
+	}, v.opts...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrUnauthenticated, err)
+	}
+	if claims.Subject == "" || domain.RoleRank(claims.Role) < 0 {
+		return nil, fmt.Errorf("%w: token needs a subject and a known role", domain.ErrUnauthenticated)
+	}
+	return &Principal{Subject: claims.Subject, Role: claims.Role, CustomerID: claims.CustomerID}, nil
+}
@@ -0,0 +1,108 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"ledger/internal/domain"
+	"ledger/internal/handler"
+)
+
+// Authenticator resolves credentials to principals
+type Authenticator struct {
+	Keys domain.APIKeyRepository
+	// JWT verifies bearer tokens that are not API keys; nil rejects them
+	JWT *JWTVerifier
+	// BootstrapKey, when set, is accepted as an admin API key so the first real keys can be issued
+	BootstrapKey string
+	// Anonymous is the principal for requests without credentials; nil leaves them
+	// unauthenticated. Setting it to an admin effectively disables authentication.
+	Anonymous *Principal
+}
+
+// Authenticate resolves an API key or JWT to a principal
+func (a *Authenticator) Authenticate(ctx context.Context, credential string) (*Principal, error) {
+	if a.BootstrapKey != "" && subtle.ConstantTimeCompare([]byte(credential), []byte(a.BootstrapKey)) == 1 {
+		return &Principal{Subject: "bootstrap", Role: domain.RoleAdmin}, nil
+	}
+	if IsAPIKey(credential) {
+		key, err := a.Keys.GetByHash(ctx, HashAPIKey(credential))
+		if err != nil {
+			if errors.Is(err, domain.ErrAPIKeyNotFound) {
+				return nil, fmt.Errorf("%w: unknown or revoked api key", domain.ErrUnauthenticated)
+			}
+			return nil, err
+		}
+		return &Principal{Subject: key.ID, Role: key.Role, CustomerID: key.CustomerID}, nil
+	}
+
+	if a.JWT == nil {
+		return nil, fmt.Errorf("%w: bearer tokens are not accepted", domain.ErrUnauthenticated)
+	}
+	return a.JWT.Verify(credential)
+}
+
+// Middleware authenticates requests that carry credentials and stores the
+// principal in the request context. Requests without credentials continue
+// anonymously; Require decides whether a route needs a principal.
+func (a *Authenticator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		credential := credentialFromRequest(r)
+		if credential == "" {
+			if a.Anonymous != nil {
+				r = r.WithContext(WithPrincipal(r.Context(), a.Anonymous))
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		p, err := a.Authenticate(r.Context(), credential)
+		if err != nil {
+			if errors.Is(err, domain.ErrUnauthenticated) {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="ledger"`)
+			}
+			handler.WriteError(w, r, err)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), p)))
+	})
+}
+
+// credentialFromRequest reads "Authorization: Bearer", X-API-Key, or the
+// access_token query parameter used by EventSource and WebSocket clients,
+// which cannot set headers.
+func credentialFromRequest(r *http.Request) string {
+	if h := r.Header.Get("Authorization"); h != "" {
+		scheme, token, ok := strings.Cut(h, " ")
+		if ok && strings.EqualFold(scheme, "Bearer") {
+			return strings.TrimSpace(token)
+		}
+		return ""
+	}
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return key
+	}
+	return r.URL.Query().Get("access_token")
+}
+
+// Require wraps h so that it only runs for principals holding at least role
+func Require(role string, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		p, ok := FromContext(r.Context())
+		if !ok {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="ledger"`)
+			handler.WriteError(w, r, domain.ErrUnauthenticated)
+			return
+		}
+		if !p.HasRole(role) {
+			handler.WriteError(w, r, fmt.Errorf("%w: %s role required", domain.ErrForbidden, role))
+			return
+		}
+		h(w, r)
+	}
+}
@@ -0,0 +1,49 @@
+// Package auth authenticates API callers with API keys or JWTs, enforces
+// role requirements per route and scopes customer principals to their own data.
+package auth
+
+import (
+	"context"
+
+	"ledger/internal/domain"
+)
+
+// Principal is an authenticated caller
+type Principal struct {
+	Subject string // API key ID or JWT subject
+	Role    string
+	// CustomerID, when set, limits the principal to that customer's accounts
+	CustomerID string
+}
+
+// HasRole reports whether p is at least as privileged as role
+func (p *Principal) HasRole(role string) bool {
+	rank := domain.RoleRank(p.Role)
+	return rank >= 0 && rank >= domain.RoleRank(role)
+}
+
+// Scoped reports whether p is restricted to a single customer
+func (p *Principal) Scoped() bool {
+	return p.CustomerID != ""
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a context carrying p
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext returns the principal stored in ctx, if any
+func FromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok
+}
+
+// scopedCustomer returns the customer the caller is limited to, or "" when unrestricted
+func scopedCustomer(ctx context.Context) string {
+	if p, ok := FromContext(ctx); ok {
+		return p.CustomerID
+	}
+	return ""
+}
@@ -0,0 +1,303 @@
+package auth
+
+import (
+	"context"
+	"strconv"
+
+	"ledger/internal/domain"
+)
+
+// The Scoped* decorators restrict services to the data of the calling
+// principal's customer. Other customers' records are reported as not found so
+// their existence is not revealed. Callers without a customer pass straight through.
+
+// ScopedAccountService limits a domain.AccountService to the caller's accounts
+type ScopedAccountService struct {
+	domain.AccountService
+}
+
+func NewScopedAccountService(next domain.AccountService) *ScopedAccountService {
+	return &ScopedAccountService{AccountService: next}
+}
+
+func (s *ScopedAccountService) CreateAccount(ctx context.Context, ownerName string, initialBalance float64) error {
+	if scopedCustomer(ctx) != "" {
+		// Customer principals open accounts through their customer
+		return domain.ErrForbidden
+	}
+	return s.AccountService.CreateAccount(ctx, ownerName, initialBalance)
+}
+
+func (s *ScopedAccountService) GetAccount(ctx context.Context, id string) (*domain.Account, error) {
+	account, err := s.AccountService.GetAccount(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if customerID := scopedCustomer(ctx); customerID != "" && account.CustomerID != customerID {
+		return nil, domain.ErrAccountNotFound
+	}
+	return account, nil
+}
+
+func (s *ScopedAccountService) GetAllAccounts(ctx context.Context) ([]*domain.Account, error) {
+	accounts, err := s.AccountService.GetAllAccounts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	customerID := scopedCustomer(ctx)
+	if customerID == "" {
+		return accounts, nil
+	}
+	owned := []*domain.Account{}
+	for _, a := range accounts {
+		if a.CustomerID == customerID {
+			owned = append(owned, a)
+		}
+	}
+	return owned, nil
+}
+
+func (s *ScopedAccountService) ListAccounts(ctx context.Context, opts domain.AccountListOptions) (*domain.AccountPage, error) {
+	if customerID := scopedCustomer(ctx); customerID != "" {
+		opts.CustomerID = customerID
+	}
+	return s.AccountService.ListAccounts(ctx, opts)
+}
+
+func (s *ScopedAccountService) UpdateAccountBalance(ctx context.Context, id string, newBalance float64) error {
+	if _, err := s.GetAccount(ctx, id); err != nil {
+		return err
+	}
+	return s.AccountService.UpdateAccountBalance(ctx, id, newBalance)
+}
+
+func (s *ScopedAccountService) FreezeAccount(ctx context.Context, id string) error {
+	if _, err := s.GetAccount(ctx, id); err != nil {
+		return err
+	}
+	return s.AccountService.FreezeAccount(ctx, id)
+}
+
+func (s *ScopedAccountService) UnfreezeAccount(ctx context.Context, id string) error {
+	if _, err := s.GetAccount(ctx, id); err != nil {
+		return err
+	}
+	return s.AccountService.UnfreezeAccount(ctx, id)
+}
+
+func (s *ScopedAccountService) DeleteAccount(ctx context.Context, id string) error {
+	if _, err := s.GetAccount(ctx, id); err != nil {
+		return err
+	}
+	return s.AccountService.DeleteAccount(ctx, id)
+}
+
+// ScopedCustomerService limits a domain.CustomerService to the caller's own customer
+type ScopedCustomerService struct {
+	domain.CustomerService
+}
+
+func NewScopedCustomerService(next domain.CustomerService) *ScopedCustomerService {
+	return &ScopedCustomerService{CustomerService: next}
+}
+
+// owns reports whether the caller may see customer id
+func owns(ctx context.Context, id string) bool {
+	customerID := scopedCustomer(ctx)
+	return customerID == "" || customerID == id
+}
+
+func (s *ScopedCustomerService) CreateCustomer(ctx context.Context, name, email string) (*domain.Customer, error) {
+	if scopedCustomer(ctx) != "" {
+		return nil, domain.ErrForbidden
+	}
+	return s.CustomerService.CreateCustomer(ctx, name, email)
+}
+
+func (s *ScopedCustomerService) GetCustomer(ctx context.Context, id string) (*domain.Customer, error) {
+	if !owns(ctx, id) {
+		return nil, domain.ErrCustomerNotFound
+	}
+	return s.CustomerService.GetCustomer(ctx, id)
+}
+
+func (s *ScopedCustomerService) GetAllCustomers(ctx context.Context) ([]*domain.Customer, error) {
+	customerID := scopedCustomer(ctx)
+	if customerID == "" {
+		return s.CustomerService.GetAllCustomers(ctx)
+	}
+	c, err := s.CustomerService.GetCustomer(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	return []*domain.Customer{c}, nil
+}
+
+func (s *ScopedCustomerService) UpdateCustomer(ctx context.Context, id, name, email string) (*domain.Customer, error) {
+	if !owns(ctx, id) {
+		return nil, domain.ErrCustomerNotFound
+	}
+	return s.CustomerService.UpdateCustomer(ctx, id, name, email)
+}
+
+func (s *ScopedCustomerService) DeleteCustomer(ctx context.Context, id string) error {
+	if !owns(ctx, id) {
+		return domain.ErrCustomerNotFound
+	}
+	return s.CustomerService.DeleteCustomer(ctx, id)
+}
+
+func (s *ScopedCustomerService) OpenAccount(ctx context.Context, customerID, currency string, initialBalance float64) (*domain.Account, error) {
+	if !owns(ctx, customerID) {
+		return nil, domain.ErrCustomerNotFound
+	}
+	return s.CustomerService.OpenAccount(ctx, customerID, currency, initialBalance)
+}
+
+func (s *ScopedCustomerService) GetCustomerAccounts(ctx context.Context, customerID string) ([]*domain.Account, error) {
+	if !owns(ctx, customerID) {
+		return nil, domain.ErrCustomerNotFound
+	}
+	return s.CustomerService.GetCustomerAccounts(ctx, customerID)
+}
+
+func (s *ScopedCustomerService) GetCustomerBalances(ctx context.Context, customerID string) (*domain.CustomerBalances, error) {
+	if !owns(ctx, customerID) {
+		return nil, domain.ErrCustomerNotFound
+	}
+	return s.CustomerService.GetCustomerBalances(ctx, customerID)
+}
+
+// ScopedTransactionService limits transfers and history to the caller's accounts.
+// Accounts must be a scoped account service so ownership is checked on lookup.
+type ScopedTransactionService struct {
+	domain.TransactionService
+	Accounts domain.AccountService
+}
+
+func NewScopedTransactionService(next domain.TransactionService, accounts domain.AccountService) *ScopedTransactionService {
+	return &ScopedTransactionService{TransactionService: next, Accounts: accounts}
+}
+
+// checkAccount verifies the caller owns the account with the numeric id used by transfers
+func (s *ScopedTransactionService) checkAccount(ctx context.Context, accountID int64) error {
+	if scopedCustomer(ctx) == "" {
+		return nil
+	}
+	_, err := s.Accounts.GetAccount(ctx, strconv.FormatInt(accountID, 10))
+	return err
+}
+
+// ProcessTransaction only lets customers move money out of their own accounts
+func (s *ScopedTransactionService) ProcessTransaction(ctx context.Context, tx *domain.Transaction) error {
+	if err := s.checkAccount(ctx, tx.FromAccountID); err != nil {
+		return err
+	}
+	return s.TransactionService.ProcessTransaction(ctx, tx)
+}
+
+func (s *ScopedTransactionService) GetTransactionHistory(ctx context.Context, accountID int64) ([]*domain.Transaction, error) {
+	if err := s.checkAccount(ctx, accountID); err != nil {
+		return nil, err
+	}
+	return s.TransactionService.GetTransactionHistory(ctx, accountID)
+}
+
+func (s *ScopedTransactionService) ListTransactionHistory(ctx context.Context, accountID int64, filter domain.HistoryFilter) (*domain.TransactionPage, error) {
+	if err := s.checkAccount(ctx, accountID); err != nil {
+		return nil, err
+	}
+	return s.TransactionService.ListTransactionHistory(ctx, accountID, filter)
+}
+
+// unscoped rejects principals limited to one customer, for services that act
+// across customers
+func unscoped(ctx context.Context) error {
+	if p, ok := FromContext(ctx); ok && p.Scoped() {
+		return domain.ErrForbidden
+	}
+	return nil
+}
+
+// ScopedWebhookService keeps customer principals away from webhooks, which
+// receive every customer's events
+type ScopedWebhookService struct {
+	domain.WebhookService
+}
+
+func NewScopedWebhookService(next domain.WebhookService) *ScopedWebhookService {
+	return &ScopedWebhookService{WebhookService: next}
+}
+
+func (s *ScopedWebhookService) Subscribe(ctx context.Context, url string, eventTypes []string) (*domain.WebhookSubscription, error) {
+	if err := unscoped(ctx); err != nil {
+		return nil, err
+	}
+	return s.WebhookService.Subscribe(ctx, url, eventTypes)
+}
+
+func (s *ScopedWebhookService) GetSubscription(ctx context.Context, id string) (*domain.WebhookSubscription, error) {
+	if err := unscoped(ctx); err != nil {
+		return nil, err
+	}
+	return s.WebhookService.GetSubscription(ctx, id)
+}
+
+func (s *ScopedWebhookService) ListSubscriptions(ctx context.Context) ([]*domain.WebhookSubscription, error) {
+	if err := unscoped(ctx); err != nil {
+		return nil, err
+	}
+	return s.WebhookService.ListSubscriptions(ctx)
+}
+
+func (s *ScopedWebhookService) Unsubscribe(ctx context.Context, id string) error {
+	if err := unscoped(ctx); err != nil {
+		return err
+	}
+	return s.WebhookService.Unsubscribe(ctx, id)
+}
+
+func (s *ScopedWebhookService) ListDeliveries(ctx context.Context, subscriptionID string) ([]*domain.WebhookDelivery, error) {
+	if err := unscoped(ctx); err != nil {
+		return nil, err
+	}
+	return s.WebhookService.ListDeliveries(ctx, subscriptionID)
+}
+
+func (s *ScopedWebhookService) ReplayDelivery(ctx context.Context, deliveryID string) (*domain.WebhookDelivery, error) {
+	if err := unscoped(ctx); err != nil {
+		return nil, err
+	}
+	return s.WebhookService.ReplayDelivery(ctx, deliveryID)
+}
+
+// ScopedAPIKeyService keeps customer principals from issuing or listing keys,
+// which would let them reach beyond their customer
+type ScopedAPIKeyService struct {
+	domain.APIKeyService
+}
+
+func NewScopedAPIKeyService(next domain.APIKeyService) *ScopedAPIKeyService {
+	return &ScopedAPIKeyService{APIKeyService: next}
+}
+
+func (s *ScopedAPIKeyService) CreateKey(ctx context.Context, name, role, customerID string) (*domain.APIKey, string, error) {
+	if err := unscoped(ctx); err != nil {
+		return nil, "", err
+	}
+	return s.APIKeyService.CreateKey(ctx, name, role, customerID)
+}
+
+func (s *ScopedAPIKeyService) ListKeys(ctx context.Context) ([]*domain.APIKey, error) {
+	if err := unscoped(ctx); err != nil {
+		return nil, err
+	}
+	return s.APIKeyService.ListKeys(ctx)
+}
+
+func (s *ScopedAPIKeyService) RevokeKey(ctx context.Context, id string) error {
+	if err := unscoped(ctx); err != nil {
+		return err
+	}
+	return s.APIKeyService.RevokeKey(ctx, id)
+}
@@ -0,0 +1,167 @@
+package auth_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"ledger/internal/auth"
+	"ledger/internal/domain"
+)
+
+type fakeAccounts struct {
+	domain.AccountService
+	accounts map[string]*domain.Account
+	listOpts domain.AccountListOptions
+	deleted  []string
+}
+
+func (f *fakeAccounts) GetAccount(ctx context.Context, id string) (*domain.Account, error) {
+	if a, ok := f.accounts[id]; ok {
+		return a, nil
+	}
+	return nil, domain.ErrAccountNotFound
+}
+
+func (f *fakeAccounts) ListAccounts(ctx context.Context, opts domain.AccountListOptions) (*domain.AccountPage, error) {
+	f.listOpts = opts
+	return &domain.AccountPage{}, nil
+}
+
+func (f *fakeAccounts) DeleteAccount(ctx context.Context, id string) error {
+	f.deleted = append(f.deleted, id)
+	return nil
+}
+
+type fakeTransactions struct {
+	domain.TransactionService
+	processed int
+}
+
+func (f *fakeTransactions) ProcessTransaction(ctx context.Context, tx *domain.Transaction) error {
+	f.processed++
+	return nil
+}
+
+func newFakeAccounts() *fakeAccounts {
+	return &fakeAccounts{accounts: map[string]*domain.Account{
+		"1": {ID: "1", CustomerID: "cust1"},
+		"2": {ID: "2", CustomerID: "cust2"},
+	}}
+}
+
+func customerCtx(customerID string) context.Context {
+	return auth.WithPrincipal(context.Background(), &auth.Principal{Subject: "u", Role: domain.RoleAdmin, CustomerID: customerID})
+}
+
+func TestScopedAccountService(t *testing.T) {
+	inner := newFakeAccounts()
+	svc := auth.NewScopedAccountService(inner)
+	ctx := customerCtx("cust1")
+
+	if _, err := svc.GetAccount(ctx, "1"); err != nil {
+		t.Errorf("expected own account to be visible, got %v", err)
+	}
+	if _, err := svc.GetAccount(ctx, "2"); !errors.Is(err, domain.ErrAccountNotFound) {
+		t.Errorf("expected other customer's account to be not found, got %v", err)
+	}
+
+	if err := svc.DeleteAccount(ctx, "2"); err == nil {
+		t.Error("expected delete of other customer's account to fail")
+	}
+	if len(inner.deleted) != 0 {
+		t.Errorf("inner service should not have been called, deleted %v", inner.deleted)
+	}
+
+	svc.ListAccounts(ctx, domain.AccountListOptions{CustomerID: "cust2"})
+	if inner.listOpts.CustomerID != "cust1" {
+		t.Errorf("expected listing to be forced to cust1, got %q", inner.listOpts.CustomerID)
+	}
+
+	if err := svc.CreateAccount(ctx, "Mallory", 0); !errors.Is(err, domain.ErrForbidden) {
+		t.Errorf("expected customer principals to be refused, got %v", err)
+	}
+
+	// Unscoped principals see everything
+	if _, err := svc.GetAccount(context.Background(), "2"); err != nil {
+		t.Errorf("expected unscoped caller to see account 2, got %v", err)
+	}
+}
+
+func TestScopedCustomerService(t *testing.T) {
+	svc := auth.NewScopedCustomerService(nil)
+
+	if _, err := svc.GetCustomer(customerCtx("cust1"), "cust2"); !errors.Is(err, domain.ErrCustomerNotFound) {
+		t.Errorf("expected other customer to be not found, got %v", err)
+	}
+	if _, err := svc.GetCustomerBalances(customerCtx("cust1"), "cust2"); err == nil {
+		t.Error("expected balances of other customer to be hidden")
+	}
+}
+
+func TestScopedTransactionService(t *testing.T) {
+	inner := &fakeTransactions{}
+	svc := auth.NewScopedTransactionService(inner, auth.NewScopedAccountService(newFakeAccounts()))
+	ctx := customerCtx("cust1")
+
+	if err := svc.ProcessTransaction(ctx, &domain.Transaction{FromAccountID: 1, ToAccountID: 2, Amount: 5}); err != nil {
+		t.Errorf("expected transfer out of own account to pass, got %v", err)
+	}
+	if err := svc.ProcessTransaction(ctx, &domain.Transaction{FromAccountID: 2, ToAccountID: 1, Amount: 5}); err == nil {
+		t.Error("expected transfer out of another customer's account to fail")
+	}
+	if inner.processed != 1 {
+		t.Errorf("expected exactly one transfer to reach the service, got %d", inner.processed)
+	}
+}
+
+type fakeAPIKeys struct {
+	domain.APIKeyService
+	created int
+}
+
+func (f *fakeAPIKeys) CreateKey(ctx context.Context, name, role, customerID string) (*domain.APIKey, string, error) {
+	f.created++
+	return &domain.APIKey{Role: role}, "lk_plaintext", nil
+}
+
+type fakeWebhooks struct {
+	domain.WebhookService
+}
+
+func (fakeWebhooks) ListSubscriptions(ctx context.Context) ([]*domain.WebhookSubscription, error) {
+	return []*domain.WebhookSubscription{{ID: "sub-1"}}, nil
+}
+
+func TestScopedAPIKeyService(t *testing.T) {
+	inner := &fakeAPIKeys{}
+	svc := auth.NewScopedAPIKeyService(inner)
+
+	if _, _, err := svc.CreateKey(customerCtx("cust1"), "escalate", domain.RoleAdmin, ""); !errors.Is(err, domain.ErrForbidden) {
+		t.Errorf("expected customer principals to be refused, got %v", err)
+	}
+	if _, err := svc.ListKeys(customerCtx("cust1")); !errors.Is(err, domain.ErrForbidden) {
+		t.Errorf("expected key listing to be refused, got %v", err)
+	}
+	if inner.created != 0 {
+		t.Errorf("inner service should not have been called, created %d", inner.created)
+	}
+
+	if _, _, err := svc.CreateKey(context.Background(), "portal", domain.RoleViewer, "cust1"); err != nil || inner.created != 1 {
+		t.Errorf("expected unscoped caller to create keys, got %v", err)
+	}
+}
+
+func TestScopedWebhookService(t *testing.T) {
+	svc := auth.NewScopedWebhookService(fakeWebhooks{})
+
+	if _, err := svc.ListSubscriptions(customerCtx("cust1")); !errors.Is(err, domain.ErrForbidden) {
+		t.Errorf("expected customer principals to be refused, got %v", err)
+	}
+	if _, err := svc.ListDeliveries(customerCtx("cust1"), "sub-1"); !errors.Is(err, domain.ErrForbidden) {
+		t.Errorf("expected delivery listing to be refused, got %v", err)
+	}
+	if subs, err := svc.ListSubscriptions(context.Background()); err != nil || len(subs) != 1 {
+		t.Errorf("expected unscoped caller to list subscriptions, got %v %v", subs, err)
+	}
+}
@@ -2,21 +2,34 @@ package domain
 
 import "context"
 
+// Account statuses
+const (
+	AccountStatusActive = "ACTIVE"
+	AccountStatusFrozen = "FROZEN"
+)
+
 // Account represents a bank account entity
 type Account struct {
-	ID        string  `json:"id"`
-	OwnerName string  `json:"owner_name"`
-	Balance   float64 `json:"balance"`
-	Currency  string  `json:"currency"`
-	CreatedAt string  `json:"created_at"` // or time.Time if you prefer
+	ID         string  `json:"id"`
+	CustomerID string  `json:"customer_id,omitempty"`
+	OwnerName  string  `json:"owner_name"`
+	Balance    float64 `json:"balance"`
+	Currency   string  `json:"currency"`
+	Status     string  `json:"status"`
+	CreatedAt  string  `json:"created_at"` // or time.Time if you prefer
 }
 
 // AccountRepository defines DB operations related to accounts
 type AccountRepository interface {
+	// Create stores acc. An account without an ID is given the next account
+	// number, which transfers refer to it by.
 	Create(ctx context.Context, acc *Account) error
 	GetByID(ctx context.Context, id string) (*Account, error)
 	GetAll(ctx context.Context) ([]*Account, error)
+	List(ctx context.Context, opts AccountListOptions) (*AccountPage, error)
+	GetByCustomerID(ctx context.Context, customerID string) ([]*Account, error)
 	UpdateBalance(ctx context.Context, id string, newBalance float64) error
+	UpdateStatus(ctx context.Context, id string, status string) error
 	Delete(ctx context.Context, id string) error
 }
 
@@ -25,8 +38,13 @@ type AccountService interface {
 	CreateAccount(ctx context.Context, ownerName string, initialBalance float64) error
 	GetAccount(ctx context.Context, id string) (*Account, error)
 	GetAllAccounts(ctx context.Context) ([]*Account, error)
+	ListAccounts(ctx context.Context, opts AccountListOptions) (*AccountPage, error)
 	UpdateAccountBalance(ctx context.Context, id string, newBalance float64) error
+	FreezeAccount(ctx context.Context, id string) error
+	UnfreezeAccount(ctx context.Context, id string) error
 	DeleteAccount(ctx context.Context, id string) error
 }
 
-var ErrAccountNotFound = "account not found"
+var ErrAccountNotFound = newError(ErrNotFound, "account_not_found", "account not found")
+
+var ErrAccountFrozen = newError(ErrPrecondition, "account_frozen", "account is frozen")
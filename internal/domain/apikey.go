@@ -0,0 +1,56 @@
+package domain
+
+import "context"
+
+// Roles, from least to most privileged. Each role may do everything the ones before it can.
+const (
+	RoleViewer   = "viewer"
+	RoleOperator = "operator"
+	RoleAdmin    = "admin"
+)
+
+// Roles lists the known roles in order of privilege
+var Roles = []string{RoleViewer, RoleOperator, RoleAdmin}
+
+// RoleRank returns the privilege level of role, or -1 if it is unknown
+func RoleRank(role string) int {
+	for i, r := range Roles {
+		if r == role {
+			return i
+		}
+	}
+	return -1
+}
+
+// APIKey is a credential for machine clients. Only a hash of the key is stored.
+type APIKey struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	Prefix     string `json:"prefix"` // first characters of the key, to tell keys apart
+	KeyHash    string `json:"-"`
+	Role       string `json:"role"`
+	CustomerID string `json:"customer_id,omitempty"` // limits the key to one customer's data
+	Revoked    bool   `json:"revoked"`
+	CreatedAt  string `json:"created_at"`
+}
+
+// APIKeyRepository persists API keys
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *APIKey) error
+	// GetByHash returns the active (non-revoked) key with the given hash
+	GetByHash(ctx context.Context, hash string) (*APIKey, error)
+	List(ctx context.Context) ([]*APIKey, error)
+	Revoke(ctx context.Context, id string) error
+}
+
+// APIKeyService issues and revokes API keys
+type APIKeyService interface {
+	// CreateKey returns the stored key and its plaintext, which is not retrievable later
+	CreateKey(ctx context.Context, name, role, customerID string) (*APIKey, string, error)
+	ListKeys(ctx context.Context) ([]*APIKey, error)
+	RevokeKey(ctx context.Context, id string) error
+}
+
+var ErrAPIKeyNotFound = newError(ErrNotFound, "api_key_not_found", "api key not found")
+
+var ErrInvalidAPIKey = newError(ErrValidation, "invalid_api_key", "invalid api key")
@@ -0,0 +1,50 @@
+package domain
+
+import "context"
+
+// Customer represents a person or business that owns one or more accounts
+type Customer struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Email     string `json:"email"`
+	CreatedAt string `json:"created_at"`
+}
+
+// CurrencyBalance is the total balance a customer holds in a single currency
+type CurrencyBalance struct {
+	Currency string  `json:"currency"`
+	Total    float64 `json:"total"`
+	Accounts int     `json:"accounts"`
+}
+
+// CustomerBalances aggregates the balances of every account owned by a customer
+type CustomerBalances struct {
+	CustomerID string            `json:"customer_id"`
+	Accounts   int               `json:"accounts"`
+	Balances   []CurrencyBalance `json:"balances"`
+}
+
+// CustomerRepository defines DB operations related to customers
+type CustomerRepository interface {
+	Create(ctx context.Context, c *Customer) error
+	GetByID(ctx context.Context, id string) (*Customer, error)
+	GetAll(ctx context.Context) ([]*Customer, error)
+	Update(ctx context.Context, c *Customer) error
+	Delete(ctx context.Context, id string) error
+}
+
+// CustomerService defines business logic operations for customers and their accounts
+type CustomerService interface {
+	CreateCustomer(ctx context.Context, name, email string) (*Customer, error)
+	GetCustomer(ctx context.Context, id string) (*Customer, error)
+	GetAllCustomers(ctx context.Context) ([]*Customer, error)
+	UpdateCustomer(ctx context.Context, id, name, email string) (*Customer, error)
+	DeleteCustomer(ctx context.Context, id string) error
+	OpenAccount(ctx context.Context, customerID, currency string, initialBalance float64) (*Account, error)
+	GetCustomerAccounts(ctx context.Context, customerID string) ([]*Account, error)
+	GetCustomerBalances(ctx context.Context, customerID string) (*CustomerBalances, error)
+}
+
+var ErrCustomerNotFound = newError(ErrNotFound, "customer_not_found", "customer not found")
+
+var ErrCustomerHasAccounts = newError(ErrConflict, "customer_has_accounts", "customer still owns accounts")
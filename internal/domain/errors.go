@@ -0,0 +1,44 @@
+package domain
+
+// Error is a domain failure with a stable, machine-readable code. Specific errors
+// belong to one of the categories below, so callers can test either with errors.Is:
+//
+//	errors.Is(err, ErrAccountNotFound) // this exact failure
+//	errors.Is(err, ErrNotFound)        // any missing resource
+type Error struct {
+	Code    string
+	Message string
+	// Kind is the category this error belongs to; nil for the categories themselves
+	Kind *Error
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Unwrap exposes the category to errors.Is and errors.As
+func (e *Error) Unwrap() error {
+	if e.Kind == nil {
+		return nil
+	}
+	return e.Kind
+}
+
+// newError declares a specific error within a category
+func newError(kind *Error, code, message string) *Error {
+	return &Error{Code: code, Message: message, Kind: kind}
+}
+
+// Error categories
+var (
+	ErrNotFound = &Error{Code: "not_found", Message: "not found"}
+	// ErrValidation marks malformed or out-of-range input
+	ErrValidation = &Error{Code: "validation_failed", Message: "validation failed"}
+	// ErrConflict marks a request that clashes with the current state of a resource
+	ErrConflict = &Error{Code: "conflict", Message: "conflict"}
+	// ErrPrecondition marks a well-formed request that a business rule rejects
+	ErrPrecondition    = &Error{Code: "precondition_failed", Message: "precondition failed"}
+	ErrUnauthenticated = &Error{Code: "unauthenticated", Message: "authentication required"}
+	ErrForbidden       = &Error{Code: "forbidden", Message: "operation not permitted"}
+	ErrRateLimited     = &Error{Code: "rate_limited", Message: "rate limit exceeded"}
+)
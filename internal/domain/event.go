@@ -0,0 +1,51 @@
+package domain
+
+import "context"
+
+// Event types emitted by the services
+const (
+	EventTransactionSucceeded = "transaction.succeeded"
+	EventTransactionFailed    = "transaction.failed"
+	EventAccountFrozen        = "account.frozen"
+	EventBalanceLow           = "balance.low"
+	EventBalanceUpdated       = "balance.updated"
+)
+
+// EventTypes lists every event type subscribers may register for
+var EventTypes = []string{
+	EventTransactionSucceeded,
+	EventTransactionFailed,
+	EventAccountFrozen,
+	EventBalanceLow,
+	EventBalanceUpdated,
+}
+
+// Event is something that happened to one or more accounts
+type Event struct {
+	ID         string   `json:"id"`
+	Type       string   `json:"type"`
+	AccountIDs []string `json:"account_ids"`
+	OccurredAt string   `json:"occurred_at"` // RFC3339, UTC
+	Data       any      `json:"data"`
+}
+
+// EventPublisher is notified of events. Implementations may record an event
+// before returning but must not block the caller on delivering it.
+type EventPublisher interface {
+	Publish(ctx context.Context, evt Event)
+}
+
+// NopEventPublisher discards every event
+type NopEventPublisher struct{}
+
+func (NopEventPublisher) Publish(context.Context, Event) {}
+
+// IsEventType reports whether t is a known event type
+func IsEventType(t string) bool {
+	for _, known := range EventTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
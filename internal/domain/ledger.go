@@ -5,5 +5,8 @@ import "context"
 // LedgerRepository defines how ledger entries are persisted and queried from MongoDB
 type LedgerRepository interface {
 	SaveEntry(ctx context.Context, entry *LedgerEntry) error
+	// GetEntryByTransactionID returns ErrTransactionNotFound when the transfer is not recorded
+	GetEntryByTransactionID(ctx context.Context, transactionID string) (*LedgerEntry, error)
 	GetEntriesByAccountID(ctx context.Context, accountID int64) ([]*LedgerEntry, error)
+	ListEntriesByAccountID(ctx context.Context, accountID int64, filter HistoryFilter) (*LedgerPage, error)
 }
@@ -0,0 +1,113 @@
+package domain
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+)
+
+const (
+	// DefaultPageSize is used when a listing request does not ask for a page size
+	DefaultPageSize = 50
+	// MaxPageSize caps how many rows a single page may return
+	MaxPageSize = 200
+)
+
+// Account listing sort fields
+const (
+	SortByID        = "id"
+	SortByOwnerName = "owner_name"
+	SortByBalance   = "balance"
+	SortByCreatedAt = "created_at"
+)
+
+// Transaction history directions, relative to the account being queried
+const (
+	DirectionIncoming = "in"
+	DirectionOutgoing = "out"
+)
+
+var ErrInvalidCursor = newError(ErrValidation, "invalid_cursor", "invalid cursor")
+
+// Cursor marks the position of the last item returned in a page. Clients only
+// ever see it in its encoded, opaque form.
+type Cursor struct {
+	Sort string `json:"s,omitempty"`
+	Key  string `json:"k"`
+	ID   string `json:"id"`
+}
+
+// EncodeCursor turns a cursor into an opaque URL-safe token
+func EncodeCursor(c Cursor) string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DecodeCursor parses a token produced by EncodeCursor. An empty token yields a nil cursor.
+func DecodeCursor(token string) (*Cursor, error) {
+	if token == "" {
+		return nil, nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c Cursor
+	if err := json.Unmarshal(b, &c); err != nil || c.ID == "" {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// ClampPageSize applies the default and maximum page sizes to a requested limit
+func ClampPageSize(limit int) int {
+	if limit <= 0 {
+		return DefaultPageSize
+	}
+	if limit > MaxPageSize {
+		return MaxPageSize
+	}
+	return limit
+}
+
+// AccountListOptions controls paging and ordering of account listings
+type AccountListOptions struct {
+	Cursor string
+	Limit  int
+	SortBy string // one of the SortBy* constants, defaults to SortByID
+	Desc   bool
+	// CustomerID restricts the listing to one customer's accounts when set
+	CustomerID string
+}
+
+// AccountPage is a single page of accounts
+type AccountPage struct {
+	Accounts   []*Account `json:"accounts"`
+	NextCursor string     `json:"next_cursor,omitempty"`
+}
+
+// HistoryFilter narrows and pages an account's transaction history. Zero values mean "no filter".
+type HistoryFilter struct {
+	Cursor         string
+	Limit          int
+	From           time.Time
+	To             time.Time
+	MinAmount      float64
+	MaxAmount      float64
+	Currency       string
+	Status         string
+	CounterpartyID int64
+	Direction      string // DirectionIncoming, DirectionOutgoing or empty for both
+}
+
+// LedgerPage is a single page of ledger entries, newest first
+type LedgerPage struct {
+	Entries    []*LedgerEntry
+	NextCursor string
+}
+
+// TransactionPage is a single page of transaction history, newest first
+type TransactionPage struct {
+	Transactions []*Transaction `json:"transactions"`
+	NextCursor   string         `json:"next_cursor,omitempty"`
+}
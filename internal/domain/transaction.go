@@ -15,18 +15,49 @@ type Transaction struct {
 
 // LedgerEntry represents a transaction stored in MongoDB (audit log)
 type LedgerEntry struct {
-	ID            string  `json:"id"` // UUID
-	TransactionID string  `json:"transaction_id"`
-	FromAccountID int64   `json:"from_account_id"`
-	ToAccountID   int64   `json:"to_account_id"`
-	Amount        float64 `json:"amount"`
-	Currency      string  `json:"currency"`
-	Status        string  `json:"status"`
-	Timestamp     string  `json:"timestamp"` // or time.Time
+	ID            string  `json:"id" bson:"id"` // UUID
+	TransactionID string  `json:"transaction_id" bson:"transaction_id"`
+	FromAccountID int64   `json:"from_account_id" bson:"from_account_id"`
+	ToAccountID   int64   `json:"to_account_id" bson:"to_account_id"`
+	Amount        float64 `json:"amount" bson:"amount"`
+	Currency      string  `json:"currency" bson:"currency"`
+	Status        string  `json:"status" bson:"status"`
+	Timestamp     string  `json:"timestamp" bson:"timestamp"` // RFC3339, UTC
+	// CorrelationID traces the entry back to the HTTP request or queue message that caused it
+	CorrelationID string `json:"correlation_id,omitempty" bson:"correlation_id,omitempty"`
 }
 
 // TransactionService handles business logic for transfers
 type TransactionService interface {
 	ProcessTransaction(ctx context.Context, tx *Transaction) error
 	GetTransactionHistory(ctx context.Context, accountID int64) ([]*Transaction, error)
+	ListTransactionHistory(ctx context.Context, accountID int64, filter HistoryFilter) (*TransactionPage, error)
+}
+
+// Transactor runs fn in one database transaction. Repository calls made with
+// the context passed to fn commit together when fn returns nil and roll back
+// otherwise. Only stores holding both accounts and the ledger provide one.
+type Transactor interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// Locker serializes work on a key. Lock blocks until the key is free or ctx is
+// done; the caller releases the key by calling unlock.
+type Locker interface {
+	Lock(ctx context.Context, key string) (unlock func(), err error)
 }
+
+var ErrInsufficientFunds = newError(ErrPrecondition, "insufficient_funds", "insufficient funds")
+
+var ErrCurrencyMismatch = newError(ErrPrecondition, "currency_mismatch", "currency does not match the account")
+
+var ErrNoTransactions = newError(ErrNotFound, "transactions_not_found", "no transactions found for account")
+
+var ErrTransactionNotFound = newError(ErrNotFound, "transaction_not_found", "transaction not found")
+
+var ErrTransactionIDReused = newError(ErrConflict, "transaction_id_reused", "transaction ID already used for a different transfer")
+
+// ErrTransferIncomplete marks a transfer that failed after debiting the source
+// account. It belongs to no category, so it is reported as an internal error,
+// but it must not be retried: the debit would be applied again.
+var ErrTransferIncomplete = &Error{Code: "transfer_incomplete", Message: "transfer was only partially applied"}
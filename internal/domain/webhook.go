@@ -0,0 +1,74 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// Webhook delivery statuses
+const (
+	DeliveryPending   = "PENDING"
+	DeliverySucceeded = "SUCCEEDED"
+	DeliveryFailed    = "FAILED"
+)
+
+// WebhookSubscription is a URL that receives signed event notifications
+type WebhookSubscription struct {
+	ID         string   `json:"id"`
+	URL        string   `json:"url"`
+	Secret     string   `json:"secret,omitempty"` // only returned when the subscription is created
+	EventTypes []string `json:"event_types"`
+	Active     bool     `json:"active"`
+	CreatedAt  string   `json:"created_at"`
+}
+
+// WebhookDelivery records sending one event to one subscription
+type WebhookDelivery struct {
+	ID             string          `json:"id"`
+	SubscriptionID string          `json:"subscription_id"`
+	EventID        string          `json:"event_id"`
+	EventType      string          `json:"event_type"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	ResponseCode   int             `json:"response_code,omitempty"`
+	LastError      string          `json:"last_error,omitempty"`
+	CreatedAt      string          `json:"created_at"`
+	UpdatedAt      string          `json:"updated_at"`
+	// NextAttemptAt is when a sweeper may pick up a pending delivery that
+	// nobody is working on, e.g. after a restart
+	NextAttemptAt time.Time `json:"-"`
+}
+
+// WebhookRepository persists subscriptions and their delivery log
+type WebhookRepository interface {
+	CreateSubscription(ctx context.Context, sub *WebhookSubscription) error
+	GetSubscription(ctx context.Context, id string) (*WebhookSubscription, error)
+	ListSubscriptions(ctx context.Context) ([]*WebhookSubscription, error)
+	ListSubscriptionsForEvent(ctx context.Context, eventType string) ([]*WebhookSubscription, error)
+	DeleteSubscription(ctx context.Context, id string) error
+	CreateDelivery(ctx context.Context, d *WebhookDelivery) error
+	UpdateDelivery(ctx context.Context, d *WebhookDelivery) error
+	GetDelivery(ctx context.Context, id string) (*WebhookDelivery, error)
+	ListDeliveries(ctx context.Context, subscriptionID string) ([]*WebhookDelivery, error)
+	// ClaimDueDeliveries returns up to limit pending deliveries whose next
+	// attempt is due at now, moving it to until so no other sweeper claims them
+	ClaimDueDeliveries(ctx context.Context, now, until time.Time, limit int) ([]*WebhookDelivery, error)
+}
+
+// WebhookService manages subscriptions and replays deliveries
+type WebhookService interface {
+	Subscribe(ctx context.Context, url string, eventTypes []string) (*WebhookSubscription, error)
+	GetSubscription(ctx context.Context, id string) (*WebhookSubscription, error)
+	ListSubscriptions(ctx context.Context) ([]*WebhookSubscription, error)
+	Unsubscribe(ctx context.Context, id string) error
+	ListDeliveries(ctx context.Context, subscriptionID string) ([]*WebhookDelivery, error)
+	ReplayDelivery(ctx context.Context, deliveryID string) (*WebhookDelivery, error)
+}
+
+var ErrSubscriptionNotFound = newError(ErrNotFound, "subscription_not_found", "webhook subscription not found")
+
+var ErrDeliveryNotFound = newError(ErrNotFound, "delivery_not_found", "webhook delivery not found")
+
+var ErrInvalidSubscription = newError(ErrValidation, "invalid_subscription", "invalid webhook subscription")
@@ -0,0 +1,104 @@
+// Package errmap classifies service errors so that every transport (REST, gRPC)
+// reports the same failure with the same status.
+package errmap
+
+import (
+	"errors"
+	"net/http"
+
+	"google.golang.org/grpc/codes"
+
+	"ledger/internal/domain"
+)
+
+// Kind is a transport-independent error category
+type Kind int
+
+const (
+	Internal Kind = iota
+	NotFound
+	InvalidArgument
+	FailedPrecondition
+	Conflict
+	Unauthenticated
+	PermissionDenied
+	ResourceExhausted
+)
+
+// categories maps domain error categories to their kind, checked in order with errors.Is
+var categories = []struct {
+	err  error
+	kind Kind
+}{
+	{domain.ErrNotFound, NotFound},
+	{domain.ErrValidation, InvalidArgument},
+	{domain.ErrPrecondition, FailedPrecondition},
+	{domain.ErrConflict, Conflict},
+	{domain.ErrUnauthenticated, Unauthenticated},
+	{domain.ErrForbidden, PermissionDenied},
+	{domain.ErrRateLimited, ResourceExhausted},
+}
+
+// Classify returns the kind of err, defaulting to Internal
+func Classify(err error) Kind {
+	for _, c := range categories {
+		if errors.Is(err, c.err) {
+			return c.kind
+		}
+	}
+	return Internal
+}
+
+// Code returns the stable code of the most specific domain error in err's chain,
+// or "internal" for errors outside the taxonomy
+func Code(err error) string {
+	var de *domain.Error
+	if errors.As(err, &de) {
+		return de.Code
+	}
+	return "internal"
+}
+
+// HTTPStatus maps err to an HTTP status code
+func HTTPStatus(err error) int {
+	switch Classify(err) {
+	case NotFound:
+		return http.StatusNotFound
+	case InvalidArgument:
+		return http.StatusBadRequest
+	case FailedPrecondition:
+		return http.StatusUnprocessableEntity
+	case Conflict:
+		return http.StatusConflict
+	case Unauthenticated:
+		return http.StatusUnauthorized
+	case PermissionDenied:
+		return http.StatusForbidden
+	case ResourceExhausted:
+		return http.StatusTooManyRequests
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// GRPCCode maps err to a gRPC status code
+func GRPCCode(err error) codes.Code {
+	switch Classify(err) {
+	case NotFound:
+		return codes.NotFound
+	case InvalidArgument:
+		return codes.InvalidArgument
+	case FailedPrecondition:
+		return codes.FailedPrecondition
+	case Conflict:
+		return codes.AlreadyExists
+	case Unauthenticated:
+		return codes.Unauthenticated
+	case PermissionDenied:
+		return codes.PermissionDenied
+	case ResourceExhausted:
+		return codes.ResourceExhausted
+	default:
+		return codes.Internal
+	}
+}
@@ -0,0 +1,61 @@
+package errmap_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+
+	"ledger/internal/domain"
+	"ledger/internal/errmap"
+)
+
+func TestMapping(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+		code   codes.Code
+		name   string
+	}{
+		{domain.ErrAccountNotFound, http.StatusNotFound, codes.NotFound, "account_not_found"},
+		{fmt.Errorf("fetch source account: %w", domain.ErrAccountNotFound), http.StatusNotFound, codes.NotFound, "account_not_found"},
+		{domain.ErrInvalidCursor, http.StatusBadRequest, codes.InvalidArgument, "invalid_cursor"},
+		{fmt.Errorf("%w: limit too large", domain.ErrValidation), http.StatusBadRequest, codes.InvalidArgument, "validation_failed"},
+		{domain.ErrInsufficientFunds, http.StatusUnprocessableEntity, codes.FailedPrecondition, "insufficient_funds"},
+		{domain.ErrCurrencyMismatch, http.StatusUnprocessableEntity, codes.FailedPrecondition, "currency_mismatch"},
+		{domain.ErrCustomerHasAccounts, http.StatusConflict, codes.AlreadyExists, "customer_has_accounts"},
+		{domain.ErrUnauthenticated, http.StatusUnauthorized, codes.Unauthenticated, "unauthenticated"},
+		{domain.ErrForbidden, http.StatusForbidden, codes.PermissionDenied, "forbidden"},
+		{domain.ErrRateLimited, http.StatusTooManyRequests, codes.ResourceExhausted, "rate_limited"},
+		{errors.New("connection refused"), http.StatusInternalServerError, codes.Internal, "internal"},
+		{fmt.Errorf("%w: log transaction: %w", domain.ErrTransferIncomplete, errors.New("timeout")), http.StatusInternalServerError, codes.Internal, "transfer_incomplete"},
+		// Messages no longer matter, only the wrapped error does
+		{errors.New("account not found"), http.StatusInternalServerError, codes.Internal, "internal"},
+	}
+
+	for _, tt := range tests {
+		if got := errmap.HTTPStatus(tt.err); got != tt.status {
+			t.Errorf("%q: expected HTTP %d, got %d", tt.err, tt.status, got)
+		}
+		if got := errmap.GRPCCode(tt.err); got != tt.code {
+			t.Errorf("%q: expected gRPC %s, got %s", tt.err, tt.code, got)
+		}
+		if got := errmap.Code(tt.err); got != tt.name {
+			t.Errorf("%q: expected code %s, got %s", tt.err, tt.name, got)
+		}
+	}
+}
+
+func TestCategories(t *testing.T) {
+	if !errors.Is(domain.ErrInsufficientFunds, domain.ErrPrecondition) {
+		t.Error("insufficient funds should be a precondition failure")
+	}
+	if !errors.Is(fmt.Errorf("wrapped: %w", domain.ErrCustomerNotFound), domain.ErrNotFound) {
+		t.Error("wrapped customer not found should match ErrNotFound")
+	}
+	if errors.Is(domain.ErrAccountNotFound, domain.ErrCustomerNotFound) {
+		t.Error("distinct errors in one category must not match each other")
+	}
+}
@@ -0,0 +1,137 @@
+// Package events fans domain events out to in-process subscribers such as
+// streaming HTTP clients.
+package events
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"ledger/internal/domain"
+)
+
+// Message is an event together with its position on the bus. Seq increases by
+// one for every published event and is what clients resume from.
+type Message struct {
+	Seq   uint64
+	Event domain.Event
+}
+
+// Bus is an in-process domain.EventPublisher that keeps a bounded history so
+// subscribers can resume after a disconnect. Publish never blocks: a subscriber
+// that falls behind by more than its buffer is closed and has to resubscribe.
+type Bus struct {
+	mu      sync.Mutex
+	seq     uint64
+	history []Message // ring buffer, oldest entry at history[next] once full
+	next    int
+	subs    map[*Subscription]struct{}
+	buffer  int
+	closed  bool
+}
+
+// NewBus creates a bus remembering the last historySize events
+func NewBus(historySize int) *Bus {
+	if historySize < 1 {
+		historySize = 1
+	}
+	return &Bus{
+		history: make([]Message, 0, historySize),
+		subs:    make(map[*Subscription]struct{}),
+		buffer:  64,
+	}
+}
+
+// Subscription receives the events for one account
+type Subscription struct {
+	bus       *Bus
+	accountID string
+	ch        chan Message
+	closed    bool
+}
+
+// C is closed when the subscription ends, either through Close or because the
+// subscriber fell too far behind
+func (s *Subscription) C() <-chan Message {
+	return s.ch
+}
+
+// Close stops delivery; it is safe to call more than once
+func (s *Subscription) Close() {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+	s.bus.remove(s)
+}
+
+// Publish records evt and delivers it to every subscriber of the accounts it concerns
+func (b *Bus) Publish(ctx context.Context, evt domain.Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.seq++
+	msg := Message{Seq: b.seq, Event: evt}
+	if len(b.history) < cap(b.history) {
+		b.history = append(b.history, msg)
+	} else {
+		b.history[b.next] = msg
+		b.next = (b.next + 1) % len(b.history)
+	}
+
+	for sub := range b.subs {
+		if !slices.Contains(evt.AccountIDs, sub.accountID) {
+			continue
+		}
+		select {
+		case sub.ch <- msg:
+		default:
+			b.remove(sub)
+		}
+	}
+}
+
+// Subscribe starts delivering events for accountID. When after is non-zero the
+// buffered events for the account with a greater Seq are returned for replay;
+// nothing published after the call can be missed between replay and C.
+func (b *Bus) Subscribe(accountID string, after uint64) (*Subscription, []Message) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var replay []Message
+	if after > 0 {
+		for i := range b.history {
+			msg := b.history[(b.next+i)%len(b.history)]
+			if msg.Seq > after && slices.Contains(msg.Event.AccountIDs, accountID) {
+				replay = append(replay, msg)
+			}
+		}
+	}
+
+	sub := &Subscription{bus: b, accountID: accountID, ch: make(chan Message, b.buffer)}
+	if b.closed {
+		sub.closed = true
+		close(sub.ch)
+		return sub, replay
+	}
+	b.subs[sub] = struct{}{}
+	return sub, replay
+}
+
+// Close ends every subscription, as if each had fallen behind, so streaming
+// clients reconnect elsewhere during shutdown. Later subscriptions start closed.
+func (b *Bus) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for sub := range b.subs {
+		b.remove(sub)
+	}
+}
+
+func (b *Bus) remove(sub *Subscription) {
+	if sub.closed {
+		return
+	}
+	sub.closed = true
+	delete(b.subs, sub)
+	close(sub.ch)
+}
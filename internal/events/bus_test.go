@@ -0,0 +1,110 @@
+package events_test
+
+import (
+	"context"
+	"testing"
+
+	"ledger/internal/domain"
+	"ledger/internal/events"
+)
+
+func publish(b *events.Bus, id string, accountIDs ...string) {
+	b.Publish(context.Background(), domain.Event{ID: id, Type: domain.EventTransactionSucceeded, AccountIDs: accountIDs})
+}
+
+func TestBus_DeliversToMatchingAccount(t *testing.T) {
+	b := events.NewBus(10)
+	sub, replay := b.Subscribe("acc1", 0)
+	defer sub.Close()
+
+	if len(replay) != 0 {
+		t.Fatalf("expected no replay without a resume point, got %d", len(replay))
+	}
+
+	publish(b, "evt1", "acc2")
+	publish(b, "evt2", "acc1", "acc2")
+
+	msg := <-sub.C()
+	if msg.Event.ID != "evt2" || msg.Seq != 2 {
+		t.Errorf("expected evt2 at seq 2, got %s at %d", msg.Event.ID, msg.Seq)
+	}
+	select {
+	case msg := <-sub.C():
+		t.Errorf("unexpected event %s", msg.Event.ID)
+	default:
+	}
+}
+
+func TestBus_ResumeReplaysMissedEvents(t *testing.T) {
+	b := events.NewBus(3)
+	publish(b, "evt1", "acc1")
+	publish(b, "evt2", "acc1")
+	publish(b, "evt3", "acc2")
+	publish(b, "evt4", "acc1")
+
+	sub, replay := b.Subscribe("acc1", 1)
+	defer sub.Close()
+
+	// evt1 is before the resume point; the ring only holds evt2..evt4
+	var ids []string
+	for _, m := range replay {
+		ids = append(ids, m.Event.ID)
+	}
+	if len(ids) != 2 || ids[0] != "evt2" || ids[1] != "evt4" {
+		t.Errorf("expected replay [evt2 evt4], got %v", ids)
+	}
+}
+
+func TestBus_SlowSubscriberIsClosed(t *testing.T) {
+	b := events.NewBus(1)
+	sub, _ := b.Subscribe("acc1", 0)
+
+	for i := 0; i < 100; i++ {
+		publish(b, "evt", "acc1")
+	}
+
+	n := 0
+	for range sub.C() {
+		n++
+	}
+	if n == 0 || n >= 100 {
+		t.Errorf("expected the subscription to be closed after filling its buffer, drained %d", n)
+	}
+	sub.Close()
+}
+
+func TestFanout(t *testing.T) {
+	b1, b2 := events.NewBus(1), events.NewBus(1)
+	s1, _ := b1.Subscribe("acc1", 0)
+	s2, _ := b2.Subscribe("acc1", 0)
+
+	events.Fanout{b1, b2}.Publish(context.Background(), domain.Event{ID: "evt1", AccountIDs: []string{"acc1"}})
+
+	if (<-s1.C()).Event.ID != "evt1" || (<-s2.C()).Event.ID != "evt1" {
+		t.Error("expected both buses to receive the event")
+	}
+}
+
+func TestBus_CloseEndsSubscriptions(t *testing.T) {
+	b := events.NewBus(4)
+	sub, _ := b.Subscribe("acc1", 0)
+	publish(b, "evt1", "acc1")
+	b.Close()
+
+	n := 0
+	for range sub.C() {
+		n++
+	}
+	if n != 1 {
+		t.Errorf("expected the buffered event before close, drained %d", n)
+	}
+
+	late, replay := b.Subscribe("acc1", 0)
+	if _, ok := <-late.C(); ok {
+		t.Error("subscriptions after Close should start closed")
+	}
+	if len(replay) != 0 {
+		t.Errorf("expected no replay, got %d", len(replay))
+	}
+	sub.Close()
+}
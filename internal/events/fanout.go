@@ -0,0 +1,16 @@
+package events
+
+import (
+	"context"
+
+	"ledger/internal/domain"
+)
+
+// Fanout publishes every event to each of its publishers in order
+type Fanout []domain.EventPublisher
+
+func (f Fanout) Publish(ctx context.Context, evt domain.Event) {
+	for _, p := range f {
+		p.Publish(ctx, evt)
+	}
+}
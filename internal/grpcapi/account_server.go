@@ -0,0 +1,112 @@
+package grpcapi
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"ledger/internal/domain"
+	"ledger/internal/grpcapi/ledgerv1"
+)
+
+// AccountServer implements ledgerv1.AccountServiceServer on top of domain.AccountService
+type AccountServer struct {
+	ledgerv1.UnimplementedAccountServiceServer
+	AccountService domain.AccountService
+}
+
+// NewAccountServer creates a new AccountServer instance.
+func NewAccountServer(service domain.AccountService) *AccountServer {
+	return &AccountServer{AccountService: service}
+}
+
+var accountSortFields = map[ledgerv1.AccountSort]string{
+	ledgerv1.AccountSort_ACCOUNT_SORT_UNSPECIFIED: domain.SortByID,
+	ledgerv1.AccountSort_ACCOUNT_SORT_ID:          domain.SortByID,
+	ledgerv1.AccountSort_ACCOUNT_SORT_OWNER_NAME:  domain.SortByOwnerName,
+	ledgerv1.AccountSort_ACCOUNT_SORT_BALANCE:     domain.SortByBalance,
+	ledgerv1.AccountSort_ACCOUNT_SORT_CREATED_AT:  domain.SortByCreatedAt,
+}
+
+func (s *AccountServer) CreateAccount(ctx context.Context, req *ledgerv1.CreateAccountRequest) (*ledgerv1.CreateAccountResponse, error) {
+	if req.GetOwnerName() == "" || req.GetInitialBalance() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "invalid account data")
+	}
+
+	if err := s.AccountService.CreateAccount(ctx, req.GetOwnerName(), req.GetInitialBalance()); err != nil {
+		return nil, toStatus(err)
+	}
+	return &ledgerv1.CreateAccountResponse{}, nil
+}
+
+func (s *AccountServer) GetAccount(ctx context.Context, req *ledgerv1.GetAccountRequest) (*ledgerv1.GetAccountResponse, error) {
+	if req.GetId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing account ID")
+	}
+
+	account, err := s.AccountService.GetAccount(ctx, req.GetId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &ledgerv1.GetAccountResponse{Account: toProtoAccount(account)}, nil
+}
+
+func (s *AccountServer) ListAccounts(ctx context.Context, req *ledgerv1.ListAccountsRequest) (*ledgerv1.ListAccountsResponse, error) {
+	sortBy, ok := accountSortFields[req.GetSortBy()]
+	if !ok {
+		return nil, status.Error(codes.InvalidArgument, "unsupported sort field")
+	}
+	if req.GetPageSize() < 0 || req.GetPageSize() > domain.MaxPageSize {
+		return nil, status.Errorf(codes.InvalidArgument, "page_size must be between 0 and %d", domain.MaxPageSize)
+	}
+
+	page, err := s.AccountService.ListAccounts(ctx, domain.AccountListOptions{
+		Cursor: req.GetCursor(),
+		Limit:  int(req.GetPageSize()),
+		SortBy: sortBy,
+		Desc:   req.GetDescending(),
+	})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	resp := &ledgerv1.ListAccountsResponse{NextCursor: page.NextCursor}
+	for _, account := range page.Accounts {
+		resp.Accounts = append(resp.Accounts, toProtoAccount(account))
+	}
+	return resp, nil
+}
+
+func (s *AccountServer) UpdateBalance(ctx context.Context, req *ledgerv1.UpdateBalanceRequest) (*ledgerv1.UpdateBalanceResponse, error) {
+	if req.GetId() == "" || req.GetAmount() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "invalid account data")
+	}
+
+	if err := s.AccountService.UpdateAccountBalance(ctx, req.GetId(), req.GetAmount()); err != nil {
+		return nil, toStatus(err)
+	}
+	return &ledgerv1.UpdateBalanceResponse{}, nil
+}
+
+func (s *AccountServer) DeleteAccount(ctx context.Context, req *ledgerv1.DeleteAccountRequest) (*ledgerv1.DeleteAccountResponse, error) {
+	if req.GetId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing account ID")
+	}
+
+	if err := s.AccountService.DeleteAccount(ctx, req.GetId()); err != nil {
+		return nil, toStatus(err)
+	}
+	return &ledgerv1.DeleteAccountResponse{}, nil
+}
+
+func toProtoAccount(a *domain.Account) *ledgerv1.Account {
+	return &ledgerv1.Account{
+		Id:         a.ID,
+		CustomerId: a.CustomerID,
+		OwnerName:  a.OwnerName,
+		Balance:    a.Balance,
+		Currency:   a.Currency,
+		CreatedAt:  a.CreatedAt,
+	}
+}
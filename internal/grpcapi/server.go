@@ -0,0 +1,40 @@
+// Package grpcapi exposes the account and transfer services over gRPC,
+// alongside the REST handlers in package handler.
+package grpcapi
+
+//go:generate sh -c "cd ../.. && buf generate"
+
+import (
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+
+	"ledger/internal/domain"
+	"ledger/internal/errmap"
+	"ledger/internal/grpcapi/ledgerv1"
+)
+
+// NewServer creates a gRPC server with the account and transfer services registered
+func NewServer(accounts domain.AccountService, transactions domain.TransactionService, opts ...grpc.ServerOption) *grpc.Server {
+	s := grpc.NewServer(opts...)
+	ledgerv1.RegisterAccountServiceServer(s, NewAccountServer(accounts))
+	ledgerv1.RegisterTransferServiceServer(s, NewTransferServer(transactions))
+	return s
+}
+
+// MethodRoles is the least role needed for each RPC, mirroring the REST routes.
+// Pass it to auth.UnaryServerInterceptor and auth.StreamServerInterceptor.
+var MethodRoles = map[string]string{
+	ledgerv1.AccountService_CreateAccount_FullMethodName:   domain.RoleOperator,
+	ledgerv1.AccountService_GetAccount_FullMethodName:      domain.RoleViewer,
+	ledgerv1.AccountService_ListAccounts_FullMethodName:    domain.RoleViewer,
+	ledgerv1.AccountService_UpdateBalance_FullMethodName:   domain.RoleAdmin,
+	ledgerv1.AccountService_DeleteAccount_FullMethodName:   domain.RoleAdmin,
+	ledgerv1.TransferService_CreateTransfer_FullMethodName: domain.RoleOperator,
+	ledgerv1.TransferService_GetHistory_FullMethodName:     domain.RoleViewer,
+	ledgerv1.TransferService_StreamHistory_FullMethodName:  domain.RoleViewer,
+}
+
+// toStatus converts a service error into a gRPC status using the same mapping as REST
+func toStatus(err error) error {
+	return status.Error(errmap.GRPCCode(err), err.Error())
+}
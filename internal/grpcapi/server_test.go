@@ -0,0 +1,217 @@
+package grpcapi_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"ledger/internal/auth"
+	"ledger/internal/domain"
+	"ledger/internal/grpcapi"
+	"ledger/internal/grpcapi/ledgerv1"
+)
+
+type mockAccountService struct {
+	domain.AccountService
+	GetAccountFn func(ctx context.Context, id string) (*domain.Account, error)
+}
+
+func (m *mockAccountService) GetAccount(ctx context.Context, id string) (*domain.Account, error) {
+	return m.GetAccountFn(ctx, id)
+}
+
+type mockTransactionService struct {
+	domain.TransactionService
+	ProcessFunc func(ctx context.Context, tx *domain.Transaction) error
+	ListFunc    func(ctx context.Context, accountID int64, filter domain.HistoryFilter) (*domain.TransactionPage, error)
+}
+
+func (m *mockTransactionService) ProcessTransaction(ctx context.Context, tx *domain.Transaction) error {
+	return m.ProcessFunc(ctx, tx)
+}
+
+func (m *mockTransactionService) ListTransactionHistory(ctx context.Context, accountID int64, filter domain.HistoryFilter) (*domain.TransactionPage, error) {
+	return m.ListFunc(ctx, accountID, filter)
+}
+
+func dial(t *testing.T, accounts domain.AccountService, transactions domain.TransactionService, opts ...grpc.ServerOption) *grpc.ClientConn {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := grpcapi.NewServer(accounts, transactions, opts...)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestGetAccount(t *testing.T) {
+	conn := dial(t, &mockAccountService{
+		GetAccountFn: func(ctx context.Context, id string) (*domain.Account, error) {
+			return &domain.Account{ID: id, OwnerName: "Alice", Balance: 100}, nil
+		},
+	}, nil)
+
+	resp, err := ledgerv1.NewAccountServiceClient(conn).GetAccount(context.Background(), &ledgerv1.GetAccountRequest{Id: "acc1"})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.GetAccount().GetOwnerName() != "Alice" {
+		t.Errorf("unexpected account %v", resp.GetAccount())
+	}
+}
+
+func TestGetAccount_NotFound(t *testing.T) {
+	conn := dial(t, &mockAccountService{
+		GetAccountFn: func(ctx context.Context, id string) (*domain.Account, error) {
+			return nil, domain.ErrAccountNotFound
+		},
+	}, nil)
+
+	_, err := ledgerv1.NewAccountServiceClient(conn).GetAccount(context.Background(), &ledgerv1.GetAccountRequest{Id: "missing"})
+
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("expected NotFound, got %v", err)
+	}
+}
+
+func TestCreateTransfer(t *testing.T) {
+	conn := dial(t, nil, &mockTransactionService{
+		ProcessFunc: func(ctx context.Context, tx *domain.Transaction) error {
+			tx.ID = "txn123"
+			return nil
+		},
+	})
+	client := ledgerv1.NewTransferServiceClient(conn)
+
+	resp, err := client.CreateTransfer(context.Background(), &ledgerv1.CreateTransferRequest{
+		FromAccountId: 1, ToAccountId: 2, Amount: 10, Currency: "USD",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.GetTransactionId() != "txn123" {
+		t.Errorf("expected txn123, got %s", resp.GetTransactionId())
+	}
+
+	_, err = client.CreateTransfer(context.Background(), &ledgerv1.CreateTransferRequest{FromAccountId: 1})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument, got %v", err)
+	}
+}
+
+func TestCreateTransfer_InsufficientFunds(t *testing.T) {
+	conn := dial(t, nil, &mockTransactionService{
+		ProcessFunc: func(ctx context.Context, tx *domain.Transaction) error {
+			return domain.ErrInsufficientFunds
+		},
+	})
+
+	_, err := ledgerv1.NewTransferServiceClient(conn).CreateTransfer(context.Background(), &ledgerv1.CreateTransferRequest{
+		FromAccountId: 1, ToAccountId: 2, Amount: 10, Currency: "USD",
+	})
+
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("expected FailedPrecondition, got %v", err)
+	}
+}
+
+func TestStreamHistory(t *testing.T) {
+	pages := map[string]*domain.TransactionPage{
+		"": {
+			Transactions: []*domain.Transaction{{ID: "tx3"}, {ID: "tx2"}},
+			NextCursor:   "page2",
+		},
+		"page2": {
+			Transactions: []*domain.Transaction{{ID: "tx1"}},
+		},
+	}
+	conn := dial(t, nil, &mockTransactionService{
+		ListFunc: func(ctx context.Context, accountID int64, filter domain.HistoryFilter) (*domain.TransactionPage, error) {
+			if filter.Direction != domain.DirectionIncoming {
+				return nil, errors.New("filter not forwarded")
+			}
+			return pages[filter.Cursor], nil
+		},
+	})
+
+	stream, err := ledgerv1.NewTransferServiceClient(conn).StreamHistory(context.Background(), &ledgerv1.StreamHistoryRequest{
+		AccountId: 1,
+		Filter:    &ledgerv1.HistoryFilter{Direction: ledgerv1.Direction_DIRECTION_INCOMING},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var ids []string
+	for {
+		msg, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unexpected stream error: %v", err)
+		}
+		ids = append(ids, msg.GetTransaction().GetId())
+	}
+	if len(ids) != 3 || ids[0] != "tx3" || ids[2] != "tx1" {
+		t.Errorf("unexpected stream order %v", ids)
+	}
+}
+
+func TestAuthInterceptors(t *testing.T) {
+	a := &auth.Authenticator{BootstrapKey: "admin-key"}
+	v, _ := auth.NewJWTVerifier(auth.JWTConfig{HMACSecret: "secret"})
+	a.JWT = v
+	viewer, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, auth.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{Subject: "u1", ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))},
+		Role:             domain.RoleViewer,
+	}).SignedString([]byte("secret"))
+
+	conn := dial(t, &mockAccountService{
+		GetAccountFn: func(ctx context.Context, id string) (*domain.Account, error) {
+			if p, ok := auth.FromContext(ctx); !ok || p.Subject != "u1" {
+				t.Errorf("expected principal u1 in context, got %+v", p)
+			}
+			return &domain.Account{ID: id}, nil
+		},
+	}, nil,
+		grpc.ChainUnaryInterceptor(auth.UnaryServerInterceptor(a, grpcapi.MethodRoles)),
+		grpc.ChainStreamInterceptor(auth.StreamServerInterceptor(a, grpcapi.MethodRoles)),
+	)
+	client := ledgerv1.NewAccountServiceClient(conn)
+
+	_, err := client.GetAccount(context.Background(), &ledgerv1.GetAccountRequest{Id: "acc1"})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("expected Unauthenticated without credentials, got %v", err)
+	}
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+viewer)
+	if _, err := client.GetAccount(ctx, &ledgerv1.GetAccountRequest{Id: "acc1"}); err != nil {
+		t.Errorf("expected viewer to read accounts, got %v", err)
+	}
+
+	_, err = client.DeleteAccount(ctx, &ledgerv1.DeleteAccountRequest{Id: "acc1"})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Errorf("expected PermissionDenied for viewer deleting, got %v", err)
+	}
+}
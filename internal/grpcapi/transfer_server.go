@@ -0,0 +1,133 @@
+package grpcapi
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"ledger/internal/domain"
+	"ledger/internal/grpcapi/ledgerv1"
+)
+
+// TransferServer implements ledgerv1.TransferServiceServer on top of domain.TransactionService
+type TransferServer struct {
+	ledgerv1.UnimplementedTransferServiceServer
+	TransactionService domain.TransactionService
+}
+
+// NewTransferServer creates a new TransferServer instance.
+func NewTransferServer(service domain.TransactionService) *TransferServer {
+	return &TransferServer{TransactionService: service}
+}
+
+var directions = map[ledgerv1.Direction]string{
+	ledgerv1.Direction_DIRECTION_UNSPECIFIED: "",
+	ledgerv1.Direction_DIRECTION_INCOMING:    domain.DirectionIncoming,
+	ledgerv1.Direction_DIRECTION_OUTGOING:    domain.DirectionOutgoing,
+}
+
+func (s *TransferServer) CreateTransfer(ctx context.Context, req *ledgerv1.CreateTransferRequest) (*ledgerv1.CreateTransferResponse, error) {
+	tx := &domain.Transaction{
+		ID:            req.GetId(),
+		FromAccountID: req.GetFromAccountId(),
+		ToAccountID:   req.GetToAccountId(),
+		Amount:        req.GetAmount(),
+		Currency:      req.GetCurrency(),
+	}
+	if tx.FromAccountID == 0 || tx.ToAccountID == 0 || tx.Amount <= 0 || tx.Currency == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing required transaction fields")
+	}
+
+	if err := s.TransactionService.ProcessTransaction(ctx, tx); err != nil {
+		return nil, toStatus(err)
+	}
+	return &ledgerv1.CreateTransferResponse{TransactionId: tx.ID, Status: "success"}, nil
+}
+
+func (s *TransferServer) GetHistory(ctx context.Context, req *ledgerv1.GetHistoryRequest) (*ledgerv1.GetHistoryResponse, error) {
+	if req.GetPageSize() < 0 || req.GetPageSize() > domain.MaxPageSize {
+		return nil, status.Errorf(codes.InvalidArgument, "page_size must be between 0 and %d", domain.MaxPageSize)
+	}
+	filter, err := toHistoryFilter(req.GetFilter())
+	if err != nil {
+		return nil, err
+	}
+	filter.Cursor = req.GetCursor()
+	filter.Limit = int(req.GetPageSize())
+
+	page, err := s.TransactionService.ListTransactionHistory(ctx, req.GetAccountId(), filter)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	resp := &ledgerv1.GetHistoryResponse{NextCursor: page.NextCursor}
+	for _, tx := range page.Transactions {
+		resp.Transactions = append(resp.Transactions, toProtoTransaction(tx))
+	}
+	return resp, nil
+}
+
+// StreamHistory walks every page of the account's history and sends it one transaction at a time
+func (s *TransferServer) StreamHistory(req *ledgerv1.StreamHistoryRequest, stream ledgerv1.TransferService_StreamHistoryServer) error {
+	filter, err := toHistoryFilter(req.GetFilter())
+	if err != nil {
+		return err
+	}
+	filter.Limit = domain.MaxPageSize
+
+	ctx := stream.Context()
+	for {
+		page, err := s.TransactionService.ListTransactionHistory(ctx, req.GetAccountId(), filter)
+		if err != nil {
+			return toStatus(err)
+		}
+		for _, tx := range page.Transactions {
+			if err := stream.Send(&ledgerv1.StreamHistoryResponse{Transaction: toProtoTransaction(tx)}); err != nil {
+				return err
+			}
+		}
+		if page.NextCursor == "" {
+			return nil
+		}
+		filter.Cursor = page.NextCursor
+	}
+}
+
+func toHistoryFilter(f *ledgerv1.HistoryFilter) (domain.HistoryFilter, error) {
+	direction, ok := directions[f.GetDirection()]
+	if !ok {
+		return domain.HistoryFilter{}, status.Error(codes.InvalidArgument, "unsupported direction")
+	}
+	if f.GetMinAmount() < 0 || f.GetMaxAmount() < 0 {
+		return domain.HistoryFilter{}, status.Error(codes.InvalidArgument, "amounts must be non-negative")
+	}
+
+	filter := domain.HistoryFilter{
+		MinAmount:      f.GetMinAmount(),
+		MaxAmount:      f.GetMaxAmount(),
+		Currency:       f.GetCurrency(),
+		Status:         f.GetStatus(),
+		CounterpartyID: f.GetCounterpartyId(),
+		Direction:      direction,
+	}
+	if f.GetFrom() != nil {
+		filter.From = f.GetFrom().AsTime()
+	}
+	if f.GetTo() != nil {
+		filter.To = f.GetTo().AsTime()
+	}
+	return filter, nil
+}
+
+func toProtoTransaction(tx *domain.Transaction) *ledgerv1.Transaction {
+	return &ledgerv1.Transaction{
+		Id:            tx.ID,
+		FromAccountId: tx.FromAccountID,
+		ToAccountId:   tx.ToAccountID,
+		Amount:        tx.Amount,
+		Currency:      tx.Currency,
+		Status:        tx.Status,
+		CreatedAt:     tx.CreatedAt,
+	}
+}
@@ -3,7 +3,8 @@ package handler
 import (
 	"encoding/json"
 	"net/http"
-	_ "strconv"
+
+	"github.com/gorilla/mux"
 
 	"ledger/internal/domain"
 )
@@ -28,18 +29,18 @@ func (h *AccountHandler) CreateAccount(w http.ResponseWriter, r *http.Request) {
 		InitialBalance float64 `json:"initial_balance"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid input", http.StatusBadRequest)
+		WriteError(w, r, invalid("request body must be valid JSON"))
 		return
 	}
 
 	if req.OwnerName == "" || req.InitialBalance < 0 {
-		http.Error(w, "Invalid account data", http.StatusBadRequest)
+		WriteError(w, r, invalid("owner_name is required and initial_balance must not be negative"))
 		return
 	}
 
 	err := h.AccountService.CreateAccount(r.Context(), req.OwnerName, req.InitialBalance)
 	if err != nil {
-		http.Error(w, "Account creation failed: "+err.Error(), http.StatusInternalServerError)
+		WriteError(w, r, err)
 		return
 	}
 
@@ -49,40 +50,40 @@ func (h *AccountHandler) CreateAccount(w http.ResponseWriter, r *http.Request) {
 
 // GetAccount handles GET /accounts/{id}
 func (h *AccountHandler) GetAccount(w http.ResponseWriter, r *http.Request) {
-	accountID := r.URL.Query().Get("id")
+	accountID := mux.Vars(r)["id"]
 	if accountID == "" {
-		http.Error(w, "Missing account ID", http.StatusBadRequest)
+		WriteError(w, r, invalid("missing account ID"))
 		return
 	}
 
 	account, err := h.AccountService.GetAccount(r.Context(), accountID)
 	if err != nil {
-		http.Error(w, "Account not found: "+err.Error(), http.StatusNotFound)
+		WriteError(w, r, err)
 		return
 	}
 
 	writeJSON(w, http.StatusOK, account)
 }
 
-// UpdateBalance handles PUT /accounts/balance
+// UpdateBalance handles PUT /accounts/{id}/balance
 func (h *AccountHandler) UpdateBalance(w http.ResponseWriter, r *http.Request) {
+	accountID := mux.Vars(r)["id"]
 	var req struct {
-		ID      string  `json:"id"`
 		Balance float64 `json:"balance"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid input", http.StatusBadRequest)
+		WriteError(w, r, invalid("request body must be valid JSON"))
 		return
 	}
 
-	if req.ID == "" || req.Balance < 0 {
-		http.Error(w, "Invalid account data", http.StatusBadRequest)
+	if accountID == "" || req.Balance < 0 {
+		WriteError(w, r, invalid("balance must not be negative"))
 		return
 	}
 
-	err := h.AccountService.UpdateAccountBalance(r.Context(), req.ID, req.Balance)
+	err := h.AccountService.UpdateAccountBalance(r.Context(), accountID, req.Balance)
 	if err != nil {
-		http.Error(w, "Failed to update balance: "+err.Error(), http.StatusInternalServerError)
+		WriteError(w, r, err)
 		return
 	}
 
@@ -90,29 +91,59 @@ func (h *AccountHandler) UpdateBalance(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte("Balance updated successfully"))
 }
 
-// GetAllAccounts handles GET /accounts
+// FreezeAccount handles POST /accounts/{id}/freeze
+func (h *AccountHandler) FreezeAccount(w http.ResponseWriter, r *http.Request) {
+	err := h.AccountService.FreezeAccount(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		WriteError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// UnfreezeAccount handles POST /accounts/{id}/unfreeze
+func (h *AccountHandler) UnfreezeAccount(w http.ResponseWriter, r *http.Request) {
+	err := h.AccountService.UnfreezeAccount(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		WriteError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetAllAccounts handles GET /accounts?limit=&cursor=&sort=
 func (h *AccountHandler) GetAllAccounts(w http.ResponseWriter, r *http.Request) {
-	accounts, err := h.AccountService.GetAllAccounts(r.Context())
+	opts, err := parseAccountListOptions(r.URL.Query())
 	if err != nil {
-		http.Error(w, "Failed to fetch accounts: "+err.Error(), http.StatusInternalServerError)
+		WriteError(w, r, err)
 		return
 	}
 
-	writeJSON(w, http.StatusOK, accounts)
+	page, err := h.AccountService.ListAccounts(r.Context(), opts)
+	if err != nil {
+		WriteError(w, r, err)
+		return
+	}
+
+	if page.NextCursor != "" {
+		w.Header().Set(nextCursorHeader, page.NextCursor)
+	}
+	writeJSON(w, http.StatusOK, page.Accounts)
 }
 
 // DeleteAccount handles DELETE /accounts/{id}
 func (h *AccountHandler) DeleteAccount(w http.ResponseWriter, r *http.Request) {
-
-	accountID := r.URL.Query().Get("id")
+	accountID := mux.Vars(r)["id"]
 	if accountID == "" {
-		http.Error(w, "Missing account ID", http.StatusBadRequest)
+		WriteError(w, r, invalid("missing account ID"))
 		return
 	}
 
 	err := h.AccountService.DeleteAccount(r.Context(), accountID)
 	if err != nil {
-		http.Error(w, "Failed to delete account: "+err.Error(), http.StatusInternalServerError)
+		WriteError(w, r, err)
 		return
 	}
 
@@ -11,6 +11,8 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+
+	"github.com/gorilla/mux"
 )
 
 type mockAccountService struct {
@@ -18,7 +20,10 @@ type mockAccountService struct {
 	GetAccountFn           func(ctx context.Context, id string) (*domain.Account, error)
 	UpdateAccountBalanceFn func(ctx context.Context, id string, balance float64) error
 	GetAllAccountsFn       func(ctx context.Context) ([]*domain.Account, error)
+	ListAccountsFn         func(ctx context.Context, opts domain.AccountListOptions) (*domain.AccountPage, error)
 	DeleteAccountFn        func(ctx context.Context, id string) error
+	FreezeAccountFn        func(ctx context.Context, id string) error
+	UnfreezeAccountFn      func(ctx context.Context, id string) error
 }
 
 func (m *mockAccountService) CreateAccount(ctx context.Context, owner string, balance float64) error {
@@ -33,9 +38,18 @@ func (m *mockAccountService) UpdateAccountBalance(ctx context.Context, id string
 func (m *mockAccountService) GetAllAccounts(ctx context.Context) ([]*domain.Account, error) {
 	return m.GetAllAccountsFn(ctx)
 }
+func (m *mockAccountService) ListAccounts(ctx context.Context, opts domain.AccountListOptions) (*domain.AccountPage, error) {
+	return m.ListAccountsFn(ctx, opts)
+}
 func (m *mockAccountService) DeleteAccount(ctx context.Context, id string) error {
 	return m.DeleteAccountFn(ctx, id)
 }
+func (m *mockAccountService) FreezeAccount(ctx context.Context, id string) error {
+	return m.FreezeAccountFn(ctx, id)
+}
+func (m *mockAccountService) UnfreezeAccount(ctx context.Context, id string) error {
+	return m.UnfreezeAccountFn(ctx, id)
+}
 
 func TestCreateAccount_Success(t *testing.T) {
 	h := handler.NewAccountHandler(&mockAccountService{
@@ -77,7 +91,7 @@ func TestGetAccount_Success(t *testing.T) {
 			return &domain.Account{ID: "123", OwnerName: "Alice", Balance: 100}, nil
 		},
 	})
-	r := httptest.NewRequest("GET", "/accounts?id=123", nil)
+	r := mux.SetURLVars(httptest.NewRequest("GET", "/accounts/123", nil), map[string]string{"id": "123"})
 	w := httptest.NewRecorder()
 
 	h.GetAccount(w, r)
@@ -100,7 +114,7 @@ func TestDeleteAccount_Success(t *testing.T) {
 		},
 	})
 
-	r := httptest.NewRequest("DELETE", "/accounts?id=123", nil)
+	r := mux.SetURLVars(httptest.NewRequest("DELETE", "/accounts/123", nil), map[string]string{"id": "123"})
 	w := httptest.NewRecorder()
 
 	h.DeleteAccount(w, r)
@@ -113,7 +127,7 @@ func TestDeleteAccount_Success(t *testing.T) {
 func TestUpdateBalance_InvalidInput(t *testing.T) {
 	h := handler.NewAccountHandler(nil)
 
-	r := httptest.NewRequest("PUT", "/accounts/balance", bytes.NewBufferString(`bad-json`))
+	r := mux.SetURLVars(httptest.NewRequest("PUT", "/accounts/123/balance", bytes.NewBufferString(`bad-json`)), map[string]string{"id": "123"})
 	w := httptest.NewRecorder()
 
 	h.UpdateBalance(w, r)
@@ -125,12 +139,18 @@ func TestUpdateBalance_InvalidInput(t *testing.T) {
 
 func TestGetAllAccounts(t *testing.T) {
 	h := handler.NewAccountHandler(&mockAccountService{
-		GetAllAccountsFn: func(ctx context.Context) ([]*domain.Account, error) {
-			return []*domain.Account{{ID: "1", OwnerName: "Alice", Balance: 100}}, nil
+		ListAccountsFn: func(ctx context.Context, opts domain.AccountListOptions) (*domain.AccountPage, error) {
+			if opts.SortBy != domain.SortByBalance || !opts.Desc || opts.Limit != 1 || opts.Cursor != "abc" {
+				return nil, errors.New("unexpected options")
+			}
+			return &domain.AccountPage{
+				Accounts:   []*domain.Account{{ID: "1", OwnerName: "Alice", Balance: 100}},
+				NextCursor: "next",
+			}, nil
 		},
 	})
 
-	r := httptest.NewRequest("GET", "/accounts", nil)
+	r := httptest.NewRequest("GET", "/accounts?limit=1&cursor=abc&sort=-balance", nil)
 	w := httptest.NewRecorder()
 
 	h.GetAllAccounts(w, r)
@@ -138,6 +158,9 @@ func TestGetAllAccounts(t *testing.T) {
 	if w.Code != http.StatusOK {
 		t.Fatalf("expected 200, got %d", w.Code)
 	}
+	if w.Header().Get("X-Next-Cursor") != "next" {
+		t.Errorf("expected next cursor header, got %q", w.Header().Get("X-Next-Cursor"))
+	}
 
 	var resp []domain.Account
 	body, _ := io.ReadAll(w.Body)
@@ -157,8 +180,8 @@ func TestUpdateBalance_Success(t *testing.T) {
 		},
 	})
 
-	body := `{"id": "123", "balance": 200}`
-	r := httptest.NewRequest("PUT", "/accounts/balance", bytes.NewBufferString(body))
+	body := `{"balance": 200}`
+	r := mux.SetURLVars(httptest.NewRequest("PUT", "/accounts/123/balance", bytes.NewBufferString(body)), map[string]string{"id": "123"})
 	w := httptest.NewRecorder()
 
 	h.UpdateBalance(w, r)
@@ -170,3 +193,35 @@ func TestUpdateBalance_Success(t *testing.T) {
 		t.Error("expected success message")
 	}
 }
+
+func TestGetAllAccounts_InvalidParams(t *testing.T) {
+	h := handler.NewAccountHandler(nil)
+
+	for _, query := range []string{"limit=0", "limit=1000", "sort=unknown"} {
+		r := httptest.NewRequest("GET", "/accounts?"+query, nil)
+		w := httptest.NewRecorder()
+
+		h.GetAllAccounts(w, r)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", query, w.Code)
+		}
+	}
+}
+
+func TestGetAllAccounts_InvalidCursor(t *testing.T) {
+	h := handler.NewAccountHandler(&mockAccountService{
+		ListAccountsFn: func(ctx context.Context, opts domain.AccountListOptions) (*domain.AccountPage, error) {
+			return nil, domain.ErrInvalidCursor
+		},
+	})
+
+	r := httptest.NewRequest("GET", "/accounts?cursor=garbage", nil)
+	w := httptest.NewRecorder()
+
+	h.GetAllAccounts(w, r)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+}
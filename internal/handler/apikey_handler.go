@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"ledger/internal/domain"
+)
+
+// APIKeyHandler handles HTTP requests for managing API keys.
+type APIKeyHandler struct {
+	APIKeyService domain.APIKeyService
+}
+
+// NewAPIKeyHandler creates a new APIKeyHandler instance.
+func NewAPIKeyHandler(service domain.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{
+		APIKeyService: service,
+	}
+}
+
+// CreateKey handles POST /api-keys. The plaintext key is only returned here.
+func (h *APIKeyHandler) CreateKey(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name       string `json:"name"`
+		Role       string `json:"role"`
+		CustomerID string `json:"customer_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, r, invalid("request body must be valid JSON"))
+		return
+	}
+
+	if req.Name == "" || req.Role == "" {
+		WriteError(w, r, invalid("name and role are required"))
+		return
+	}
+
+	key, plaintext, err := h.APIKeyService.CreateKey(r.Context(), req.Name, req.Role, req.CustomerID)
+	if err != nil {
+		WriteError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, struct {
+		*domain.APIKey
+		Key string `json:"key"`
+	}{key, plaintext})
+}
+
+// ListKeys handles GET /api-keys
+func (h *APIKeyHandler) ListKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := h.APIKeyService.ListKeys(r.Context())
+	if err != nil {
+		WriteError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, keys)
+}
+
+// RevokeKey handles DELETE /api-keys/{id}
+func (h *APIKeyHandler) RevokeKey(w http.ResponseWriter, r *http.Request) {
+	err := h.APIKeyService.RevokeKey(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		WriteError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
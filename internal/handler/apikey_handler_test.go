@@ -0,0 +1,85 @@
+package handler_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"ledger/internal/domain"
+	"ledger/internal/handler"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+type mockAPIKeyService struct {
+	CreateKeyFn func(ctx context.Context, name, role, customerID string) (*domain.APIKey, string, error)
+	ListKeysFn  func(ctx context.Context) ([]*domain.APIKey, error)
+	RevokeKeyFn func(ctx context.Context, id string) error
+}
+
+func (m *mockAPIKeyService) CreateKey(ctx context.Context, name, role, customerID string) (*domain.APIKey, string, error) {
+	return m.CreateKeyFn(ctx, name, role, customerID)
+}
+func (m *mockAPIKeyService) ListKeys(ctx context.Context) ([]*domain.APIKey, error) {
+	return m.ListKeysFn(ctx)
+}
+func (m *mockAPIKeyService) RevokeKey(ctx context.Context, id string) error {
+	return m.RevokeKeyFn(ctx, id)
+}
+
+func TestCreateKey_ReturnsPlaintextOnce(t *testing.T) {
+	h := handler.NewAPIKeyHandler(&mockAPIKeyService{
+		CreateKeyFn: func(ctx context.Context, name, role, customerID string) (*domain.APIKey, string, error) {
+			return &domain.APIKey{ID: "key1", Name: name, Role: role, KeyHash: "hash"}, "lk_secret", nil
+		},
+	})
+
+	r := httptest.NewRequest("POST", "/api-keys", bytes.NewBufferString(`{"name": "ci", "role": "viewer"}`))
+	w := httptest.NewRecorder()
+
+	h.CreateKey(w, r)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", w.Code)
+	}
+	var resp map[string]any
+	_ = json.NewDecoder(w.Body).Decode(&resp)
+	if resp["key"] != "lk_secret" || resp["id"] != "key1" {
+		t.Errorf("unexpected response %v", resp)
+	}
+	if _, ok := resp["key_hash"]; ok {
+		t.Error("key hash must not be returned")
+	}
+}
+
+func TestCreateKey_MissingRole(t *testing.T) {
+	h := handler.NewAPIKeyHandler(&mockAPIKeyService{})
+
+	r := httptest.NewRequest("POST", "/api-keys", bytes.NewBufferString(`{"name": "ci"}`))
+	w := httptest.NewRecorder()
+
+	h.CreateKey(w, r)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestRevokeKey_NotFound(t *testing.T) {
+	h := handler.NewAPIKeyHandler(&mockAPIKeyService{
+		RevokeKeyFn: func(ctx context.Context, id string) error {
+			return domain.ErrAPIKeyNotFound
+		},
+	})
+
+	r := mux.SetURLVars(httptest.NewRequest("DELETE", "/api-keys/missing", nil), map[string]string{"id": "missing"})
+	w := httptest.NewRecorder()
+
+	h.RevokeKey(w, r)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", w.Code)
+	}
+}
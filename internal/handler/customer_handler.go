@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"ledger/internal/domain"
+)
+
+// CustomerHandler handles HTTP requests related to customers.
+type CustomerHandler struct {
+	CustomerService domain.CustomerService
+}
+
+// NewCustomerHandler creates a new CustomerHandler instance.
+func NewCustomerHandler(service domain.CustomerService) *CustomerHandler {
+	return &CustomerHandler{
+		CustomerService: service,
+	}
+}
+
+type customerRequest struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+// CreateCustomer handles POST /customers
+func (h *CustomerHandler) CreateCustomer(w http.ResponseWriter, r *http.Request) {
+	var req customerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, r, invalid("request body must be valid JSON"))
+		return
+	}
+
+	if req.Name == "" {
+		WriteError(w, r, invalid("name is required"))
+		return
+	}
+
+	customer, err := h.CustomerService.CreateCustomer(r.Context(), req.Name, req.Email)
+	if err != nil {
+		WriteError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, customer)
+}
+
+// GetCustomer handles GET /customers/{id}
+func (h *CustomerHandler) GetCustomer(w http.ResponseWriter, r *http.Request) {
+	customer, err := h.CustomerService.GetCustomer(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		WriteError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, customer)
+}
+
+// GetAllCustomers handles GET /customers
+func (h *CustomerHandler) GetAllCustomers(w http.ResponseWriter, r *http.Request) {
+	customers, err := h.CustomerService.GetAllCustomers(r.Context())
+	if err != nil {
+		WriteError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, customers)
+}
+
+// UpdateCustomer handles PUT /customers/{id}
+func (h *CustomerHandler) UpdateCustomer(w http.ResponseWriter, r *http.Request) {
+	var req customerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, r, invalid("request body must be valid JSON"))
+		return
+	}
+
+	if req.Name == "" {
+		WriteError(w, r, invalid("name is required"))
+		return
+	}
+
+	customer, err := h.CustomerService.UpdateCustomer(r.Context(), mux.Vars(r)["id"], req.Name, req.Email)
+	if err != nil {
+		WriteError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, customer)
+}
+
+// DeleteCustomer handles DELETE /customers/{id}
+func (h *CustomerHandler) DeleteCustomer(w http.ResponseWriter, r *http.Request) {
+	err := h.CustomerService.DeleteCustomer(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		WriteError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// OpenAccount handles POST /customers/{id}/accounts
+func (h *CustomerHandler) OpenAccount(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Currency       string  `json:"currency"`
+		InitialBalance float64 `json:"initial_balance"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, r, invalid("request body must be valid JSON"))
+		return
+	}
+
+	if req.Currency == "" || req.InitialBalance < 0 {
+		WriteError(w, r, invalid("currency is required and initial_balance must not be negative"))
+		return
+	}
+
+	account, err := h.CustomerService.OpenAccount(r.Context(), mux.Vars(r)["id"], req.Currency, req.InitialBalance)
+	if err != nil {
+		WriteError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, account)
+}
+
+// GetCustomerAccounts handles GET /customers/{id}/accounts
+func (h *CustomerHandler) GetCustomerAccounts(w http.ResponseWriter, r *http.Request) {
+	accounts, err := h.CustomerService.GetCustomerAccounts(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		WriteError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, accounts)
+}
+
+// GetCustomerBalances handles GET /customers/{id}/balances
+func (h *CustomerHandler) GetCustomerBalances(w http.ResponseWriter, r *http.Request) {
+	balances, err := h.CustomerService.GetCustomerBalances(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		WriteError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, balances)
+}
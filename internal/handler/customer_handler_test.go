@@ -0,0 +1,136 @@
+package handler_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"ledger/internal/domain"
+	"ledger/internal/handler"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+type mockCustomerService struct {
+	domain.CustomerService
+	CreateCustomerFn      func(ctx context.Context, name, email string) (*domain.Customer, error)
+	GetCustomerFn         func(ctx context.Context, id string) (*domain.Customer, error)
+	OpenAccountFn         func(ctx context.Context, customerID, currency string, initialBalance float64) (*domain.Account, error)
+	GetCustomerBalancesFn func(ctx context.Context, customerID string) (*domain.CustomerBalances, error)
+}
+
+func (m *mockCustomerService) CreateCustomer(ctx context.Context, name, email string) (*domain.Customer, error) {
+	return m.CreateCustomerFn(ctx, name, email)
+}
+func (m *mockCustomerService) GetCustomer(ctx context.Context, id string) (*domain.Customer, error) {
+	return m.GetCustomerFn(ctx, id)
+}
+func (m *mockCustomerService) OpenAccount(ctx context.Context, customerID, currency string, initialBalance float64) (*domain.Account, error) {
+	return m.OpenAccountFn(ctx, customerID, currency, initialBalance)
+}
+func (m *mockCustomerService) GetCustomerBalances(ctx context.Context, customerID string) (*domain.CustomerBalances, error) {
+	return m.GetCustomerBalancesFn(ctx, customerID)
+}
+
+func TestCreateCustomer_Success(t *testing.T) {
+	h := handler.NewCustomerHandler(&mockCustomerService{
+		CreateCustomerFn: func(ctx context.Context, name, email string) (*domain.Customer, error) {
+			return &domain.Customer{ID: "cust1", Name: name, Email: email}, nil
+		},
+	})
+
+	r := httptest.NewRequest("POST", "/customers", bytes.NewBufferString(`{"name": "Alice", "email": "alice@example.com"}`))
+	w := httptest.NewRecorder()
+
+	h.CreateCustomer(w, r)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", w.Code)
+	}
+	var resp domain.Customer
+	_ = json.NewDecoder(w.Body).Decode(&resp)
+	if resp.ID != "cust1" || resp.Name != "Alice" {
+		t.Errorf("unexpected customer %+v", resp)
+	}
+}
+
+func TestCreateCustomer_MissingName(t *testing.T) {
+	h := handler.NewCustomerHandler(nil)
+
+	r := httptest.NewRequest("POST", "/customers", bytes.NewBufferString(`{"email": "alice@example.com"}`))
+	w := httptest.NewRecorder()
+
+	h.CreateCustomer(w, r)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestGetCustomer_NotFound(t *testing.T) {
+	h := handler.NewCustomerHandler(&mockCustomerService{
+		GetCustomerFn: func(ctx context.Context, id string) (*domain.Customer, error) {
+			return nil, domain.ErrCustomerNotFound
+		},
+	})
+
+	r := mux.SetURLVars(httptest.NewRequest("GET", "/customers/missing", nil), map[string]string{"id": "missing"})
+	w := httptest.NewRecorder()
+
+	h.GetCustomer(w, r)
+
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", w.Code)
+	}
+}
+
+func TestOpenAccount_Success(t *testing.T) {
+	h := handler.NewCustomerHandler(&mockCustomerService{
+		OpenAccountFn: func(ctx context.Context, customerID, currency string, initialBalance float64) (*domain.Account, error) {
+			return &domain.Account{ID: "acc1", CustomerID: customerID, Currency: currency, Balance: initialBalance}, nil
+		},
+	})
+
+	r := httptest.NewRequest("POST", "/customers/cust1/accounts", bytes.NewBufferString(`{"currency": "EUR", "initial_balance": 10}`))
+	r = mux.SetURLVars(r, map[string]string{"id": "cust1"})
+	w := httptest.NewRecorder()
+
+	h.OpenAccount(w, r)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", w.Code)
+	}
+	var resp domain.Account
+	_ = json.NewDecoder(w.Body).Decode(&resp)
+	if resp.CustomerID != "cust1" || resp.Currency != "EUR" {
+		t.Errorf("unexpected account %+v", resp)
+	}
+}
+
+func TestGetCustomerBalances_Success(t *testing.T) {
+	h := handler.NewCustomerHandler(&mockCustomerService{
+		GetCustomerBalancesFn: func(ctx context.Context, customerID string) (*domain.CustomerBalances, error) {
+			return &domain.CustomerBalances{
+				CustomerID: customerID,
+				Accounts:   2,
+				Balances:   []domain.CurrencyBalance{{Currency: "USD", Total: 150, Accounts: 2}},
+			}, nil
+		},
+	})
+
+	r := mux.SetURLVars(httptest.NewRequest("GET", "/customers/cust1/balances", nil), map[string]string{"id": "cust1"})
+	w := httptest.NewRecorder()
+
+	h.GetCustomerBalances(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var resp domain.CustomerBalances
+	_ = json.NewDecoder(w.Body).Decode(&resp)
+	if len(resp.Balances) != 1 || resp.Balances[0].Total != 150 {
+		t.Errorf("unexpected balances %+v", resp)
+	}
+}
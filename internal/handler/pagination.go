@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"ledger/internal/domain"
+)
+
+// nextCursorHeader carries the opaque cursor for the next page; it is omitted on the last page
+const nextCursorHeader = "X-Next-Cursor"
+
+func parseLimit(q url.Values) (int, error) {
+	raw := q.Get("limit")
+	if raw == "" {
+		return domain.DefaultPageSize, nil
+	}
+	limit, err := strconv.Atoi(raw)
+	if err != nil || limit <= 0 || limit > domain.MaxPageSize {
+		return 0, invalid("limit must be between 1 and %d", domain.MaxPageSize)
+	}
+	return limit, nil
+}
+
+// parseAccountListOptions reads ?limit, ?cursor and ?sort (prefix with "-" for descending)
+func parseAccountListOptions(q url.Values) (domain.AccountListOptions, error) {
+	limit, err := parseLimit(q)
+	if err != nil {
+		return domain.AccountListOptions{}, err
+	}
+
+	opts := domain.AccountListOptions{
+		Cursor: q.Get("cursor"),
+		Limit:  limit,
+		SortBy: domain.SortByID,
+	}
+	if sort := q.Get("sort"); sort != "" {
+		opts.Desc = strings.HasPrefix(sort, "-")
+		opts.SortBy = strings.TrimPrefix(sort, "-")
+	}
+	switch opts.SortBy {
+	case domain.SortByID, domain.SortByOwnerName, domain.SortByBalance, domain.SortByCreatedAt:
+	default:
+		return domain.AccountListOptions{}, invalid("unsupported sort field %q", opts.SortBy)
+	}
+	return opts, nil
+}
+
+// parseHistoryFilter reads the paging and filter query parameters of the history endpoint
+func parseHistoryFilter(q url.Values) (domain.HistoryFilter, error) {
+	limit, err := parseLimit(q)
+	if err != nil {
+		return domain.HistoryFilter{}, err
+	}
+
+	filter := domain.HistoryFilter{
+		Cursor:   q.Get("cursor"),
+		Limit:    limit,
+		Currency: q.Get("currency"),
+		Status:   q.Get("status"),
+	}
+
+	if raw := q.Get("from"); raw != "" {
+		if filter.From, err = time.Parse(time.RFC3339, raw); err != nil {
+			return filter, invalid("from must be an RFC3339 timestamp")
+		}
+	}
+	if raw := q.Get("to"); raw != "" {
+		if filter.To, err = time.Parse(time.RFC3339, raw); err != nil {
+			return filter, invalid("to must be an RFC3339 timestamp")
+		}
+	}
+	if raw := q.Get("min_amount"); raw != "" {
+		if filter.MinAmount, err = strconv.ParseFloat(raw, 64); err != nil || filter.MinAmount < 0 {
+			return filter, invalid("min_amount must be a non-negative number")
+		}
+	}
+	if raw := q.Get("max_amount"); raw != "" {
+		if filter.MaxAmount, err = strconv.ParseFloat(raw, 64); err != nil || filter.MaxAmount < 0 {
+			return filter, invalid("max_amount must be a non-negative number")
+		}
+	}
+	if filter.MaxAmount > 0 && filter.MinAmount > filter.MaxAmount {
+		return filter, invalid("min_amount must not exceed max_amount")
+	}
+	if raw := q.Get("counterparty"); raw != "" {
+		if filter.CounterpartyID, err = strconv.ParseInt(raw, 10, 64); err != nil {
+			return filter, invalid("counterparty must be an account ID")
+		}
+	}
+
+	switch direction := q.Get("direction"); direction {
+	case "", domain.DirectionIncoming, domain.DirectionOutgoing:
+		filter.Direction = direction
+	default:
+		return filter, invalid("direction must be %q or %q", domain.DirectionIncoming, domain.DirectionOutgoing)
+	}
+	return filter, nil
+}
@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"ledger/internal/domain"
+	"ledger/internal/errmap"
+)
+
+// problemContentType is the media type of RFC 7807 error bodies
+const problemContentType = "application/problem+json"
+
+// Problem is an RFC 7807 problem details body. Code is stable and meant for
+// clients to branch on; Detail is for humans and may change.
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	Code     string `json:"code"`
+}
+
+// WriteError reports err as a problem+json response. It is the one place where
+// errors become HTTP statuses, for handlers and middleware alike. Internal errors
+// are logged and their details withheld from the client.
+func WriteError(w http.ResponseWriter, r *http.Request, err error) {
+	status := errmap.HTTPStatus(err)
+	p := Problem{
+		Type:     "urn:ledger:error:" + errmap.Code(err),
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   err.Error(),
+		Instance: r.URL.Path,
+		Code:     errmap.Code(err),
+	}
+	if status == http.StatusInternalServerError {
+		slog.ErrorContext(r.Context(), "request failed", "method", r.Method, "path", r.URL.Path, "error", err)
+		p.Detail = ""
+	}
+
+	w.Header().Set("Content-Type", problemContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(p)
+}
+
+// invalid describes a malformed request as a validation error
+func invalid(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", domain.ErrValidation, fmt.Sprintf(format, args...))
+}
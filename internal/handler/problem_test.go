@@ -0,0 +1,58 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"ledger/internal/domain"
+	"ledger/internal/handler"
+)
+
+func decodeProblem(t *testing.T, w *httptest.ResponseRecorder) handler.Problem {
+	t.Helper()
+	if ct := w.Header().Get("Content-Type"); ct != "application/problem+json" {
+		t.Fatalf("expected problem+json, got %q", ct)
+	}
+	var p handler.Problem
+	if err := json.NewDecoder(w.Body).Decode(&p); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestWriteError_DomainError(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/transactions", nil)
+	w := httptest.NewRecorder()
+
+	handler.WriteError(w, req, fmt.Errorf("transfer: %w", domain.ErrInsufficientFunds))
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422, got %d", w.Code)
+	}
+	p := decodeProblem(t, w)
+	if p.Code != "insufficient_funds" || p.Status != 422 || p.Type != "urn:ledger:error:insufficient_funds" {
+		t.Errorf("unexpected problem %+v", p)
+	}
+	if p.Detail != "transfer: insufficient funds" || p.Instance != "/api/v1/transactions" {
+		t.Errorf("unexpected detail or instance %+v", p)
+	}
+}
+
+func TestWriteError_InternalErrorHidesDetail(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/accounts", nil)
+	w := httptest.NewRecorder()
+
+	handler.WriteError(w, req, errors.New("pq: password authentication failed"))
+
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d", w.Code)
+	}
+	p := decodeProblem(t, w)
+	if p.Code != "internal" || p.Detail != "" {
+		t.Errorf("internal details should not leak, got %+v", p)
+	}
+}
@@ -0,0 +1,209 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+
+	"ledger/internal/domain"
+	"ledger/internal/events"
+)
+
+// StreamAuthorizer decides whether the caller behind r may watch accountID.
+// A non-nil error rejects the connection with 403.
+type StreamAuthorizer func(r *http.Request, accountID string) error
+
+// StreamHandler streams account events over Server-Sent Events and WebSocket.
+type StreamHandler struct {
+	Bus            *events.Bus
+	AccountService domain.AccountService
+	// Authorize is checked once per connection; nil allows every caller
+	Authorize StreamAuthorizer
+	// Heartbeat is how often an idle stream is pinged to keep proxies from closing it
+	Heartbeat time.Duration
+}
+
+// NewStreamHandler creates a new StreamHandler instance.
+func NewStreamHandler(bus *events.Bus, service domain.AccountService) *StreamHandler {
+	return &StreamHandler{
+		Bus:            bus,
+		AccountService: service,
+		Heartbeat:      15 * time.Second,
+	}
+}
+
+// streamMessage is the WebSocket frame for one event; ID is what clients resume from
+type streamMessage struct {
+	ID    string       `json:"id"`
+	Event domain.Event `json:"event"`
+}
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 4096,
+}
+
+const wsWriteWait = 10 * time.Second
+
+// Events handles GET /accounts/{id}/events as a Server-Sent Events stream. Clients
+// resume with the Last-Event-ID header (or ?last_event_id=) after reconnecting.
+func (h *StreamHandler) Events(w http.ResponseWriter, r *http.Request) {
+	accountID, after, ok := h.open(w, r)
+	if !ok {
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		WriteError(w, r, errors.New("response writer does not support streaming"))
+		return
+	}
+
+	sub, replay := h.Bus.Subscribe(accountID, after)
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	for _, msg := range replay {
+		if err := writeSSE(w, msg); err != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	ticker := time.NewTicker(h.Heartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case msg, ok := <-sub.C():
+			if !ok {
+				// Fell behind the bus; the client reconnects with Last-Event-ID
+				return
+			}
+			if err := writeSSE(w, msg); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-ticker.C:
+			if _, err := io.WriteString(w, ": ping\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// EventsWS handles GET /accounts/{id}/events/ws, sending each event as a JSON text
+// frame. Browsers cannot set headers on WebSocket requests, so resume uses ?last_event_id=.
+func (h *StreamHandler) EventsWS(w http.ResponseWriter, r *http.Request) {
+	accountID, after, ok := h.open(w, r)
+	if !ok {
+		return
+	}
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade has already replied with an error status
+		return
+	}
+	defer conn.Close()
+
+	sub, replay := h.Bus.Subscribe(accountID, after)
+	defer sub.Close()
+
+	// Clients send nothing, but reading is needed to handle control frames and notice disconnects
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	send := func(msg events.Message) error {
+		conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+		return conn.WriteJSON(streamMessage{ID: strconv.FormatUint(msg.Seq, 10), Event: msg.Event})
+	}
+	for _, msg := range replay {
+		if err := send(msg); err != nil {
+			return
+		}
+	}
+
+	ticker := time.NewTicker(h.Heartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-closed:
+			return
+		case msg, ok := <-sub.C():
+			if !ok {
+				conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "too slow, resume with last_event_id"),
+					time.Now().Add(wsWriteWait))
+				return
+			}
+			if err := send(msg); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteWait)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// open checks the account exists and the caller may watch it, and parses the resume point
+func (h *StreamHandler) open(w http.ResponseWriter, r *http.Request) (string, uint64, bool) {
+	accountID := mux.Vars(r)["id"]
+
+	lastID := r.Header.Get("Last-Event-ID")
+	if lastID == "" {
+		lastID = r.URL.Query().Get("last_event_id")
+	}
+	var after uint64
+	if lastID != "" {
+		var err error
+		if after, err = strconv.ParseUint(lastID, 10, 64); err != nil {
+			WriteError(w, r, invalid("Last-Event-ID must be an event sequence number"))
+			return "", 0, false
+		}
+	}
+
+	if _, err := h.AccountService.GetAccount(r.Context(), accountID); err != nil {
+		WriteError(w, r, err)
+		return "", 0, false
+	}
+
+	if h.Authorize != nil {
+		if err := h.Authorize(r, accountID); err != nil {
+			WriteError(w, r, fmt.Errorf("%w: %v", domain.ErrForbidden, err))
+			return "", 0, false
+		}
+	}
+
+	return accountID, after, true
+}
+
+func writeSSE(w io.Writer, msg events.Message) error {
+	data, err := json.Marshal(msg.Event)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", msg.Seq, msg.Event.Type, data)
+	return err
+}
@@ -0,0 +1,186 @@
+package handler_test
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"ledger/internal/domain"
+	"ledger/internal/events"
+	"ledger/internal/handler"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+)
+
+func newStreamServer(t *testing.T, authorize handler.StreamAuthorizer) (*httptest.Server, *events.Bus) {
+	t.Helper()
+	bus := events.NewBus(16)
+	h := handler.NewStreamHandler(bus, &mockAccountService{
+		GetAccountFn: func(ctx context.Context, id string) (*domain.Account, error) {
+			if id != "acc1" {
+				return nil, domain.ErrAccountNotFound
+			}
+			return &domain.Account{ID: id}, nil
+		},
+	})
+	h.Authorize = authorize
+
+	router := mux.NewRouter()
+	router.HandleFunc("/accounts/{id}/events", h.Events)
+	router.HandleFunc("/accounts/{id}/events/ws", h.EventsWS)
+	srv := httptest.NewServer(router)
+	t.Cleanup(srv.Close)
+	return srv, bus
+}
+
+func publishEvent(bus *events.Bus, id string) {
+	bus.Publish(context.Background(), domain.Event{ID: id, Type: domain.EventBalanceUpdated, AccountIDs: []string{"acc1"}})
+}
+
+// readSSE returns the id and data lines of the next event on the stream
+func readSSE(t *testing.T, r *bufio.Reader) (string, string) {
+	t.Helper()
+	var id, data string
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("stream ended: %v", err)
+		}
+		line = strings.TrimRight(line, "\n")
+		switch {
+		case line == "" && id != "":
+			return id, data
+		case strings.HasPrefix(line, "id: "):
+			id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+func TestEvents_SSEStreamAndResume(t *testing.T) {
+	srv, bus := newStreamServer(t, nil)
+	publishEvent(bus, "evt1")
+	publishEvent(bus, "evt2")
+
+	req, _ := http.NewRequest("GET", srv.URL+"/accounts/acc1/events", nil)
+	req.Header.Set("Last-Event-ID", "1")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("expected an event stream, got %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	body := bufio.NewReader(resp.Body)
+	id, data := readSSE(t, body)
+	if id != "2" || !strings.Contains(data, `"id":"evt2"`) {
+		t.Errorf("expected replay of evt2 at id 2, got %s %s", id, data)
+	}
+
+	publishEvent(bus, "evt3")
+	id, data = readSSE(t, body)
+	if id != "3" || !strings.Contains(data, `"id":"evt3"`) {
+		t.Errorf("expected live evt3 at id 3, got %s %s", id, data)
+	}
+}
+
+func TestEvents_InvalidLastEventID(t *testing.T) {
+	srv, _ := newStreamServer(t, nil)
+
+	resp, err := http.Get(srv.URL + "/accounts/acc1/events?last_event_id=abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", resp.StatusCode)
+	}
+}
+
+func TestEvents_UnknownAccount(t *testing.T) {
+	srv, _ := newStreamServer(t, nil)
+
+	resp, err := http.Get(srv.URL + "/accounts/missing/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", resp.StatusCode)
+	}
+}
+
+func TestEvents_Forbidden(t *testing.T) {
+	srv, _ := newStreamServer(t, func(r *http.Request, accountID string) error {
+		return errors.New("not your account")
+	})
+
+	resp, err := http.Get(srv.URL + "/accounts/acc1/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected 403, got %d", resp.StatusCode)
+	}
+}
+
+func TestEventsWS_StreamAndResume(t *testing.T) {
+	srv, bus := newStreamServer(t, nil)
+	publishEvent(bus, "evt1")
+	publishEvent(bus, "evt2")
+
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/accounts/acc1/events/ws?last_event_id=1"
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+
+	var msg struct {
+		ID    string       `json:"id"`
+		Event domain.Event `json:"event"`
+	}
+	if err := conn.ReadJSON(&msg); err != nil {
+		t.Fatal(err)
+	}
+	if msg.ID != "2" || msg.Event.ID != "evt2" {
+		t.Errorf("expected replay of evt2 at id 2, got %+v", msg)
+	}
+
+	publishEvent(bus, "evt3")
+	if err := conn.ReadJSON(&msg); err != nil {
+		t.Fatal(err)
+	}
+	if msg.ID != "3" || msg.Event.ID != "evt3" {
+		t.Errorf("expected live evt3 at id 3, got %+v", msg)
+	}
+}
+
+func TestEventsWS_Forbidden(t *testing.T) {
+	srv, _ := newStreamServer(t, func(r *http.Request, accountID string) error {
+		return errors.New("not your account")
+	})
+
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/accounts/acc1/events/ws"
+	_, resp, err := websocket.DefaultDialer.Dial(url, nil)
+	if err == nil {
+		t.Fatal("expected the handshake to be rejected")
+	}
+	if resp == nil || resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected 403, got %v", resp)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"ledger/internal/domain"
 	"net/http"
 	"strconv"
+
+	"github.com/gorilla/mux"
 )
 
 type TransactionHandler struct {
@@ -18,23 +20,24 @@ func NewTransactionHandler(service domain.TransactionService) *TransactionHandle
 	}
 }
 
+// ProcessTransaction handles POST /transactions
 func (t *TransactionHandler) ProcessTransaction(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
 	var tx domain.Transaction
 	if err := json.NewDecoder(r.Body).Decode(&tx); err != nil {
-		http.Error(w, "Invalid JSON body: "+err.Error(), http.StatusBadRequest)
+		WriteError(w, r, invalid("request body must be valid JSON"))
 		return
 	}
 
 	if tx.FromAccountID == 0 || tx.ToAccountID == 0 || tx.Amount <= 0 || tx.Currency == "" {
-		http.Error(w, "Missing required transaction fields", http.StatusBadRequest)
+		WriteError(w, r, invalid("from_account_id, to_account_id, a positive amount and currency are required"))
 		return
 	}
 
 	err := t.TransactionService.ProcessTransaction(ctx, &tx)
 	if err != nil {
-		http.Error(w, "Failed to process transaction: "+err.Error(), http.StatusInternalServerError)
+		WriteError(w, r, err)
 		return
 	}
 
@@ -48,29 +51,39 @@ func (t *TransactionHandler) ProcessTransaction(w http.ResponseWriter, r *http.R
 	}
 }
 
+// GetTransactionHistory handles GET /accounts/{id}/transactions
 func (t *TransactionHandler) GetTransactionHistory(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	accountIDStr := r.URL.Query().Get("account_id")
+	accountIDStr := mux.Vars(r)["id"]
 	if accountIDStr == "" {
-		http.Error(w, "Missing query param: account_id", http.StatusBadRequest)
+		WriteError(w, r, invalid("missing account ID"))
 		return
 	}
 
 	accountID, err := strconv.ParseInt(accountIDStr, 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid account_id", http.StatusBadRequest)
+		WriteError(w, r, invalid("account ID must be an integer"))
+		return
+	}
+
+	filter, err := parseHistoryFilter(r.URL.Query())
+	if err != nil {
+		WriteError(w, r, err)
 		return
 	}
 
-	txList, err := t.TransactionService.GetTransactionHistory(ctx, accountID)
+	page, err := t.TransactionService.ListTransactionHistory(ctx, accountID, filter)
 	if err != nil {
-		http.Error(w, "Error retrieving transactions: "+err.Error(), http.StatusInternalServerError)
+		WriteError(w, r, err)
 		return
 	}
 
+	if page.NextCursor != "" {
+		w.Header().Set(nextCursorHeader, page.NextCursor)
+	}
 	w.Header().Set("Content-Type", "application/json")
-	err = json.NewEncoder(w).Encode(txList)
+	err = json.NewEncoder(w).Encode(page.Transactions)
 	if err != nil {
 		return
 	}
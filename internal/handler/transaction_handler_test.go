@@ -11,12 +11,15 @@ import (
 	"net/http/httptest"
 	"testing"
 	"time"
+
+	"github.com/gorilla/mux"
 )
 
 // mock service
 type mockTransactionService struct {
 	ProcessFunc func(ctx context.Context, tx *domain.Transaction) error
 	HistoryFunc func(ctx context.Context, accountID int64) ([]*domain.Transaction, error)
+	ListFunc    func(ctx context.Context, accountID int64, filter domain.HistoryFilter) (*domain.TransactionPage, error)
 }
 
 func (m *mockTransactionService) ProcessTransaction(ctx context.Context, tx *domain.Transaction) error {
@@ -27,6 +30,15 @@ func (m *mockTransactionService) GetTransactionHistory(ctx context.Context, acco
 	return m.HistoryFunc(ctx, accountID)
 }
 
+func (m *mockTransactionService) ListTransactionHistory(ctx context.Context, accountID int64, filter domain.HistoryFilter) (*domain.TransactionPage, error) {
+	return m.ListFunc(ctx, accountID, filter)
+}
+
+func historyRequest(accountID, query string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/accounts/"+accountID+"/transactions?"+query, nil)
+	return mux.SetURLVars(req, map[string]string{"id": accountID})
+}
+
 func TestProcessTransaction_Success(t *testing.T) {
 	mockService := &mockTransactionService{
 		ProcessFunc: func(ctx context.Context, tx *domain.Transaction) error {
@@ -123,10 +135,29 @@ func TestProcessTransaction_ServiceError(t *testing.T) {
 	}
 }
 
+func TestProcessTransaction_ReusedID(t *testing.T) {
+	mockService := &mockTransactionService{
+		ProcessFunc: func(ctx context.Context, tx *domain.Transaction) error {
+			return domain.ErrTransactionIDReused
+		},
+	}
+
+	h := handler.NewTransactionHandler(mockService)
+
+	body := []byte(`{"id":"tx-1","from_account_id":1,"to_account_id":2,"amount":100,"currency":"USD"}`)
+	req := httptest.NewRequest(http.MethodPost, "/transaction", bytes.NewBuffer(body))
+	w := httptest.NewRecorder()
+
+	h.ProcessTransaction(w, req)
+	if w.Result().StatusCode != http.StatusConflict {
+		t.Errorf("expected 409, got %d", w.Result().StatusCode)
+	}
+}
+
 func TestGetTransactionHistory_Success(t *testing.T) {
 	mockService := &mockTransactionService{
-		HistoryFunc: func(ctx context.Context, accountID int64) ([]*domain.Transaction, error) {
-			return []*domain.Transaction{
+		ListFunc: func(ctx context.Context, accountID int64, filter domain.HistoryFilter) (*domain.TransactionPage, error) {
+			return &domain.TransactionPage{Transactions: []*domain.Transaction{
 				{
 					ID:            "txn1",
 					FromAccountID: accountID,
@@ -135,13 +166,13 @@ func TestGetTransactionHistory_Success(t *testing.T) {
 					Currency:      "USD",
 					CreatedAt:     time.Now().String(),
 				},
-			}, nil
+			}}, nil
 		},
 	}
 
 	h := handler.NewTransactionHandler(mockService)
 
-	req := httptest.NewRequest(http.MethodGet, "/transactions?account_id=1", nil)
+	req := historyRequest("1", "")
 	w := httptest.NewRecorder()
 
 	h.GetTransactionHistory(w, req)
@@ -165,7 +196,7 @@ func TestGetTransactionHistory_Success(t *testing.T) {
 func TestGetTransactionHistory_InvalidAccountID(t *testing.T) {
 	h := handler.NewTransactionHandler(&mockTransactionService{})
 
-	req := httptest.NewRequest(http.MethodGet, "/transactions?account_id=abc", nil)
+	req := historyRequest("abc", "")
 	w := httptest.NewRecorder()
 
 	h.GetTransactionHistory(w, req)
@@ -178,7 +209,7 @@ func TestGetTransactionHistory_InvalidAccountID(t *testing.T) {
 func TestGetTransactionHistory_MissingParam(t *testing.T) {
 	h := handler.NewTransactionHandler(&mockTransactionService{})
 
-	req := httptest.NewRequest(http.MethodGet, "/transactions", nil)
+	req := historyRequest("", "")
 	w := httptest.NewRecorder()
 
 	h.GetTransactionHistory(w, req)
@@ -190,12 +221,12 @@ func TestGetTransactionHistory_MissingParam(t *testing.T) {
 
 func TestGetTransactionHistory_ErrorFromService(t *testing.T) {
 	h := handler.NewTransactionHandler(&mockTransactionService{
-		HistoryFunc: func(ctx context.Context, accountID int64) ([]*domain.Transaction, error) {
+		ListFunc: func(ctx context.Context, accountID int64, filter domain.HistoryFilter) (*domain.TransactionPage, error) {
 			return nil, errors.New("db error")
 		},
 	})
 
-	req := httptest.NewRequest(http.MethodGet, "/transactions?account_id=1", nil)
+	req := historyRequest("1", "")
 	w := httptest.NewRecorder()
 
 	h.GetTransactionHistory(w, req)
@@ -204,3 +235,59 @@ func TestGetTransactionHistory_ErrorFromService(t *testing.T) {
 		t.Errorf("expected 500, got %d", w.Result().StatusCode)
 	}
 }
+
+func TestGetTransactionHistory_Filters(t *testing.T) {
+	var got domain.HistoryFilter
+	h := handler.NewTransactionHandler(&mockTransactionService{
+		ListFunc: func(ctx context.Context, accountID int64, filter domain.HistoryFilter) (*domain.TransactionPage, error) {
+			got = filter
+			return &domain.TransactionPage{Transactions: []*domain.Transaction{}, NextCursor: "next"}, nil
+		},
+	})
+
+	req := historyRequest("1", "limit=10&cursor=abc&from=2024-01-01T00:00:00Z&to=2024-02-01T00:00:00Z&min_amount=5&max_amount=500&currency=EUR&status=SUCCESS&counterparty=7&direction=out")
+	w := httptest.NewRecorder()
+
+	h.GetTransactionHistory(w, req)
+
+	if w.Result().StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Result().StatusCode)
+	}
+	if w.Header().Get("X-Next-Cursor") != "next" {
+		t.Errorf("expected next cursor header, got %q", w.Header().Get("X-Next-Cursor"))
+	}
+	want := domain.HistoryFilter{
+		Cursor:         "abc",
+		Limit:          10,
+		From:           time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		To:             time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+		MinAmount:      5,
+		MaxAmount:      500,
+		Currency:       "EUR",
+		Status:         "SUCCESS",
+		CounterpartyID: 7,
+		Direction:      domain.DirectionOutgoing,
+	}
+	if !got.From.Equal(want.From) || !got.To.Equal(want.To) {
+		t.Errorf("unexpected date range %v - %v", got.From, got.To)
+	}
+	got.From, got.To, want.From, want.To = time.Time{}, time.Time{}, time.Time{}, time.Time{}
+	if got != want {
+		t.Errorf("expected filter %+v, got %+v", want, got)
+	}
+}
+
+func TestGetTransactionHistory_InvalidFilters(t *testing.T) {
+	h := handler.NewTransactionHandler(&mockTransactionService{})
+
+	for _, query := range []string{"from=yesterday", "min_amount=-1", "min_amount=10&max_amount=5", "direction=sideways", "counterparty=x", "limit=500"} {
+		req := historyRequest("1", query)
+		w := httptest.NewRecorder()
+
+		h.GetTransactionHistory(w, req)
+
+		if w.Result().StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", query, w.Result().StatusCode)
+		}
+	}
+}
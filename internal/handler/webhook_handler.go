@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"ledger/internal/domain"
+)
+
+// WebhookHandler handles HTTP requests related to webhook subscriptions.
+type WebhookHandler struct {
+	WebhookService domain.WebhookService
+}
+
+// NewWebhookHandler creates a new WebhookHandler instance.
+func NewWebhookHandler(service domain.WebhookService) *WebhookHandler {
+	return &WebhookHandler{
+		WebhookService: service,
+	}
+}
+
+// CreateSubscription handles POST /webhooks. The signing secret is only returned here.
+func (h *WebhookHandler) CreateSubscription(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		URL        string   `json:"url"`
+		EventTypes []string `json:"event_types"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, r, invalid("request body must be valid JSON"))
+		return
+	}
+
+	if req.URL == "" || len(req.EventTypes) == 0 {
+		WriteError(w, r, invalid("url and event_types are required"))
+		return
+	}
+
+	sub, err := h.WebhookService.Subscribe(r.Context(), req.URL, req.EventTypes)
+	if err != nil {
+		WriteError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, sub)
+}
+
+// GetSubscription handles GET /webhooks/{id}
+func (h *WebhookHandler) GetSubscription(w http.ResponseWriter, r *http.Request) {
+	sub, err := h.WebhookService.GetSubscription(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		WriteError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, sub)
+}
+
+// ListSubscriptions handles GET /webhooks
+func (h *WebhookHandler) ListSubscriptions(w http.ResponseWriter, r *http.Request) {
+	subs, err := h.WebhookService.ListSubscriptions(r.Context())
+	if err != nil {
+		WriteError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, subs)
+}
+
+// DeleteSubscription handles DELETE /webhooks/{id}
+func (h *WebhookHandler) DeleteSubscription(w http.ResponseWriter, r *http.Request) {
+	err := h.WebhookService.Unsubscribe(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		WriteError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListDeliveries handles GET /webhooks/{id}/deliveries
+func (h *WebhookHandler) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	deliveries, err := h.WebhookService.ListDeliveries(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		WriteError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, deliveries)
+}
+
+// ReplayDelivery handles POST /webhooks/deliveries/{id}/replay
+func (h *WebhookHandler) ReplayDelivery(w http.ResponseWriter, r *http.Request) {
+	delivery, err := h.WebhookService.ReplayDelivery(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		WriteError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, delivery)
+}
@@ -0,0 +1,125 @@
+package handler_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"ledger/internal/domain"
+	"ledger/internal/handler"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+type mockWebhookService struct {
+	domain.WebhookService
+	SubscribeFn      func(ctx context.Context, url string, eventTypes []string) (*domain.WebhookSubscription, error)
+	ListDeliveriesFn func(ctx context.Context, subscriptionID string) ([]*domain.WebhookDelivery, error)
+	ReplayDeliveryFn func(ctx context.Context, deliveryID string) (*domain.WebhookDelivery, error)
+}
+
+func (m *mockWebhookService) Subscribe(ctx context.Context, url string, eventTypes []string) (*domain.WebhookSubscription, error) {
+	return m.SubscribeFn(ctx, url, eventTypes)
+}
+func (m *mockWebhookService) ListDeliveries(ctx context.Context, subscriptionID string) ([]*domain.WebhookDelivery, error) {
+	return m.ListDeliveriesFn(ctx, subscriptionID)
+}
+func (m *mockWebhookService) ReplayDelivery(ctx context.Context, deliveryID string) (*domain.WebhookDelivery, error) {
+	return m.ReplayDeliveryFn(ctx, deliveryID)
+}
+
+func TestCreateSubscription_Success(t *testing.T) {
+	h := handler.NewWebhookHandler(&mockWebhookService{
+		SubscribeFn: func(ctx context.Context, url string, eventTypes []string) (*domain.WebhookSubscription, error) {
+			return &domain.WebhookSubscription{ID: "sub1", URL: url, Secret: "s3cret", EventTypes: eventTypes, Active: true}, nil
+		},
+	})
+
+	body := `{"url": "https://example.com/hook", "event_types": ["transaction.succeeded"]}`
+	r := httptest.NewRequest("POST", "/webhooks", bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+
+	h.CreateSubscription(w, r)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", w.Code)
+	}
+	var resp domain.WebhookSubscription
+	_ = json.NewDecoder(w.Body).Decode(&resp)
+	if resp.ID != "sub1" || resp.Secret != "s3cret" {
+		t.Errorf("unexpected subscription %+v", resp)
+	}
+}
+
+func TestCreateSubscription_MissingEventTypes(t *testing.T) {
+	h := handler.NewWebhookHandler(&mockWebhookService{})
+
+	r := httptest.NewRequest("POST", "/webhooks", bytes.NewBufferString(`{"url": "https://example.com/hook"}`))
+	w := httptest.NewRecorder()
+
+	h.CreateSubscription(w, r)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestCreateSubscription_Invalid(t *testing.T) {
+	h := handler.NewWebhookHandler(&mockWebhookService{
+		SubscribeFn: func(ctx context.Context, url string, eventTypes []string) (*domain.WebhookSubscription, error) {
+			return nil, fmt.Errorf("%w: unknown event type", domain.ErrInvalidSubscription)
+		},
+	})
+
+	body := `{"url": "https://example.com/hook", "event_types": ["nope"]}`
+	r := httptest.NewRequest("POST", "/webhooks", bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+
+	h.CreateSubscription(w, r)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestListDeliveries_NotFound(t *testing.T) {
+	h := handler.NewWebhookHandler(&mockWebhookService{
+		ListDeliveriesFn: func(ctx context.Context, subscriptionID string) ([]*domain.WebhookDelivery, error) {
+			return nil, domain.ErrSubscriptionNotFound
+		},
+	})
+
+	r := mux.SetURLVars(httptest.NewRequest("GET", "/webhooks/missing/deliveries", nil), map[string]string{"id": "missing"})
+	w := httptest.NewRecorder()
+
+	h.ListDeliveries(w, r)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", w.Code)
+	}
+}
+
+func TestReplayDelivery_Accepted(t *testing.T) {
+	var gotID string
+	h := handler.NewWebhookHandler(&mockWebhookService{
+		ReplayDeliveryFn: func(ctx context.Context, deliveryID string) (*domain.WebhookDelivery, error) {
+			gotID = deliveryID
+			return &domain.WebhookDelivery{ID: deliveryID, Status: domain.DeliveryPending}, nil
+		},
+	})
+
+	r := mux.SetURLVars(httptest.NewRequest("POST", "/webhooks/deliveries/del1/replay", nil), map[string]string{"id": "del1"})
+	w := httptest.NewRecorder()
+
+	h.ReplayDelivery(w, r)
+
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d", w.Code)
+	}
+	if gotID != "del1" {
+		t.Errorf("expected delivery del1, got %q", gotID)
+	}
+}
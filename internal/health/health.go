@@ -0,0 +1,144 @@
+// Package health serves the liveness and readiness probes used by the orchestrator.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Check reports whether a dependency is usable; it must respect ctx's deadline
+type Check func(ctx context.Context) error
+
+// Status values reported by the probes
+const (
+	StatusOK       = "ok"
+	StatusReady    = "ready"
+	StatusNotReady = "not_ready"
+	StatusUp       = "up"
+	StatusDown     = "down"
+)
+
+// DefaultTimeout bounds each dependency check when Checker.Timeout is zero
+const DefaultTimeout = 2 * time.Second
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// Checker runs the readiness checks. The zero value has no checks and is ready.
+type Checker struct {
+	// Timeout bounds each check
+	Timeout time.Duration
+
+	checks       []namedCheck
+	shuttingDown atomic.Bool
+}
+
+// Add registers a readiness check reported under name. Checks must be added
+// before the probes are served.
+func (c *Checker) Add(name string, check Check) {
+	c.checks = append(c.checks, namedCheck{name: name, check: check})
+}
+
+// Shutdown marks the process as not ready so the orchestrator stops routing
+// traffic to it while in-flight requests drain
+func (c *Checker) Shutdown() {
+	c.shuttingDown.Store(true)
+}
+
+// DependencyStatus is the outcome of one check
+type DependencyStatus struct {
+	Status    string  `json:"status"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Report is the body of a readiness response
+type Report struct {
+	Status       string                      `json:"status"`
+	ShuttingDown bool                        `json:"shutting_down,omitempty"`
+	Checks       map[string]DependencyStatus `json:"checks"`
+}
+
+// Check runs every dependency check concurrently, each bounded by the timeout
+func (c *Checker) Check(ctx context.Context) Report {
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	report := Report{Status: StatusReady, Checks: make(map[string]DependencyStatus, len(c.checks))}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, nc := range c.checks {
+		wg.Add(1)
+		go func(nc namedCheck) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			start := time.Now()
+			err := run(ctx, nc.check)
+			status := DependencyStatus{Status: StatusUp, LatencyMS: float64(time.Since(start).Microseconds()) / 1000}
+			if err != nil {
+				status.Status = StatusDown
+				status.Error = err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[nc.name] = status
+			if err != nil {
+				report.Status = StatusNotReady
+			}
+		}(nc)
+	}
+	wg.Wait()
+
+	if c.shuttingDown.Load() {
+		report.Status = StatusNotReady
+		report.ShuttingDown = true
+	}
+	return report
+}
+
+// run returns the check's result, or ctx's error if the check ignores its deadline
+func run(ctx context.Context, check Check) error {
+	done := make(chan error, 1)
+	go func() { done <- check(ctx) }()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Liveness handles GET /healthz. It only shows that the process serves HTTP;
+// dependency failures must not get a healthy process restarted.
+func Liveness(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": StatusOK})
+}
+
+// Readiness handles GET /readyz, answering 503 when a dependency is down or
+// the process is shutting down
+func (c *Checker) Readiness(w http.ResponseWriter, r *http.Request) {
+	report := c.Check(r.Context())
+	status := http.StatusOK
+	if report.Status != StatusReady {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, report)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
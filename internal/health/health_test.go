@@ -0,0 +1,91 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func ok(context.Context) error { return nil }
+
+func readyz(t *testing.T, c *Checker) (int, Report) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	c.Readiness(rec, httptest.NewRequest("GET", "/readyz", nil))
+	var report Report
+	if err := json.NewDecoder(rec.Body).Decode(&report); err != nil {
+		t.Fatalf("decode report: %v", err)
+	}
+	return rec.Code, report
+}
+
+func TestReadiness_AllUp(t *testing.T) {
+	c := &Checker{}
+	c.Add("postgres", ok)
+	c.Add("mongo", ok)
+
+	code, report := readyz(t, c)
+	if code != http.StatusOK || report.Status != StatusReady {
+		t.Errorf("expected 200 ready, got %d %s", code, report.Status)
+	}
+	if len(report.Checks) != 2 || report.Checks["mongo"].Status != StatusUp {
+		t.Errorf("expected both dependencies up, got %+v", report.Checks)
+	}
+}
+
+func TestReadiness_DependencyDown(t *testing.T) {
+	c := &Checker{}
+	c.Add("postgres", ok)
+	c.Add("rabbitmq", func(context.Context) error { return errors.New("connection refused") })
+
+	code, report := readyz(t, c)
+	if code != http.StatusServiceUnavailable || report.Status != StatusNotReady {
+		t.Errorf("expected 503 not_ready, got %d %s", code, report.Status)
+	}
+	if got := report.Checks["rabbitmq"]; got.Status != StatusDown || got.Error != "connection refused" {
+		t.Errorf("unexpected rabbitmq status %+v", got)
+	}
+	if report.Checks["postgres"].Status != StatusUp {
+		t.Errorf("postgres should still be reported up")
+	}
+}
+
+func TestReadiness_CheckTimesOut(t *testing.T) {
+	c := &Checker{Timeout: 20 * time.Millisecond}
+	block := make(chan struct{})
+	defer close(block)
+	// A check that ignores its context must not hang the probe
+	c.Add("mongo", func(context.Context) error { <-block; return nil })
+
+	start := time.Now()
+	code, report := readyz(t, c)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("probe took %v", elapsed)
+	}
+	if code != http.StatusServiceUnavailable || report.Checks["mongo"].Error != context.DeadlineExceeded.Error() {
+		t.Errorf("expected a deadline error, got %d %+v", code, report.Checks["mongo"])
+	}
+}
+
+func TestReadiness_ShuttingDown(t *testing.T) {
+	c := &Checker{}
+	c.Add("postgres", ok)
+	c.Shutdown()
+
+	code, report := readyz(t, c)
+	if code != http.StatusServiceUnavailable || !report.ShuttingDown {
+		t.Errorf("expected 503 while shutting down, got %d %+v", code, report)
+	}
+}
+
+func TestLiveness(t *testing.T) {
+	rec := httptest.NewRecorder()
+	Liveness(rec, httptest.NewRequest("GET", "/healthz", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d", rec.Code)
+	}
+}
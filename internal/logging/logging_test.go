@@ -0,0 +1,91 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMiddleware_GeneratesAndPropagatesID(t *testing.T) {
+	var seen string
+	h := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = CorrelationID(r.Context())
+	}))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/accounts", nil))
+
+	if seen == "" {
+		t.Fatal("handler should see a correlation ID")
+	}
+	if got := w.Header().Get(RequestIDHeader); got != seen {
+		t.Errorf("response header %q does not match context ID %q", got, seen)
+	}
+}
+
+func TestMiddleware_KeepsCallerID(t *testing.T) {
+	var seen string
+	h := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = CorrelationID(r.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(RequestIDHeader, "req-123")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	if seen != "req-123" {
+		t.Errorf("expected caller's ID, got %q", seen)
+	}
+
+	// IDs that could corrupt logs or headers are replaced
+	req.Header.Set(RequestIDHeader, "bad id\n")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	if seen == "bad id\n" || seen == "" {
+		t.Errorf("expected a generated ID, got %q", seen)
+	}
+}
+
+func TestMiddleware_PassesFlusherThrough(t *testing.T) {
+	h := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := w.(http.Flusher); !ok {
+			t.Error("streaming handlers need an http.Flusher")
+		}
+		if _, ok := w.(http.Hijacker); !ok {
+			t.Error("WebSocket upgrades need an http.Hijacker")
+		}
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
+
+func TestNewLogger_AddsCorrelationID(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := NewLogger(&buf, "json", "info")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logger.InfoContext(WithCorrelationID(context.Background(), "abc"), "hello", "n", 1)
+	logger.DebugContext(context.Background(), "hidden")
+
+	var line map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatalf("expected exactly one JSON line, got %q: %v", buf.String(), err)
+	}
+	if line["correlation_id"] != "abc" || line["msg"] != "hello" {
+		t.Errorf("unexpected record %v", line)
+	}
+}
+
+func TestNewLogger_RejectsUnknownSettings(t *testing.T) {
+	if _, err := NewLogger(&bytes.Buffer{}, "xml", "info"); err == nil {
+		t.Error("expected error for unknown format")
+	}
+	if _, err := NewLogger(&bytes.Buffer{}, "json", "loud"); err == nil {
+		t.Error("expected error for unknown level")
+	}
+}
+
+var _ slog.Handler = contextHandler{}
@@ -0,0 +1,144 @@
+package logging
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// RequestIDHeader carries the correlation ID on HTTP requests and responses
+const RequestIDHeader = "X-Request-ID"
+
+// Middleware takes the correlation ID from X-Request-ID, or generates one, echoes it
+// in the response, stores it in the request context and logs each request once it completes.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validCorrelationID(id) {
+			id = NewCorrelationID()
+		}
+		w.Header().Set(RequestIDHeader, id)
+		ctx := WithCorrelationID(r.Context(), id)
+
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r.WithContext(ctx))
+
+		level := slog.LevelInfo
+		if rec.status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		slog.Log(ctx, level, "http request",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", rec.status,
+			"bytes", rec.bytes,
+			"duration_ms", time.Since(start).Milliseconds(),
+			"remote_addr", r.RemoteAddr,
+		)
+	})
+}
+
+// statusRecorder captures the status and size of a response. It passes Flush and
+// Hijack through so Server-Sent Events and WebSocket upgrades keep working.
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	bytes       int
+	wroteHeader bool
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += n
+	return n, err
+}
+
+func (r *statusRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (r *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer does not support hijacking")
+	}
+	r.status = http.StatusSwitchingProtocols
+	return h.Hijack()
+}
+
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// grpcRequestIDKey is the gRPC metadata key carrying the correlation ID
+const grpcRequestIDKey = "x-request-id"
+
+// UnaryServerInterceptor gives every gRPC call a correlation ID, taken from
+// x-request-id metadata when present
+func UnaryServerInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx = grpcContext(ctx)
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	logCall(ctx, info.FullMethod, start, err)
+	return resp, err
+}
+
+// StreamServerInterceptor is the streaming counterpart of UnaryServerInterceptor
+func StreamServerInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx := grpcContext(ss.Context())
+	start := time.Now()
+	err := handler(srv, contextStream{ss, ctx})
+	logCall(ctx, info.FullMethod, start, err)
+	return err
+}
+
+func grpcContext(ctx context.Context) context.Context {
+	var id string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get(grpcRequestIDKey); len(v) > 0 {
+			id = v[0]
+		}
+	}
+	if !validCorrelationID(id) {
+		id = NewCorrelationID()
+	}
+	return WithCorrelationID(ctx, id)
+}
+
+func logCall(ctx context.Context, method string, start time.Time, err error) {
+	attrs := []any{"method", method, "duration_ms", time.Since(start).Milliseconds()}
+	if err != nil {
+		slog.WarnContext(ctx, "grpc call failed", append(attrs, "error", err)...)
+		return
+	}
+	slog.InfoContext(ctx, "grpc call", attrs...)
+}
+
+// contextStream overrides the context of a server stream
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s contextStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,36 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+type routeKey struct{}
+
+// Middleware records request counts and latencies labelled with the mux route
+// template, e.g. /api/v1/accounts/{id}, so label cardinality stays bounded. It must
+// be installed with Router.Use so the matched route is known.
+func (m *Metrics) Middleware(next http.Handler) http.Handler {
+	if m == nil {
+		return next
+	}
+	route := promhttp.WithLabelFromCtx("route", func(ctx context.Context) string {
+		r, _ := ctx.Value(routeKey{}).(string)
+		return r
+	})
+	h := promhttp.InstrumentHandlerDuration(m.httpDuration, next, route)
+	h = promhttp.InstrumentHandlerCounter(m.httpRequests, h, route)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		template := "unmatched"
+		if cr := mux.CurrentRoute(r); cr != nil {
+			if t, err := cr.GetPathTemplate(); err == nil {
+				template = t
+			}
+		}
+		h.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), routeKey{}, template)))
+	})
+}
@@ -0,0 +1,166 @@
+// Package metrics exposes Prometheus metrics for HTTP traffic, transfers, the
+// transaction queue and repository calls. A nil *Metrics records nothing, so
+// components can be instrumented optionally.
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics holds every collector of the service
+type Metrics struct {
+	httpRequests *prometheus.CounterVec
+	httpDuration *prometheus.HistogramVec
+
+	transfers      *prometheus.CounterVec
+	transferAmount *prometheus.HistogramVec
+
+	consumed          *prometheus.CounterVec
+	consumeDuration   prometheus.Histogram
+	consumeLag        prometheus.Histogram
+	published         prometheus.Counter
+	publishErrors     prometheus.Counter
+	brokerConnected   *prometheus.GaugeVec
+	brokerReconnects  *prometheus.CounterVec
+	repositoryLatency *prometheus.HistogramVec
+}
+
+// New creates the collectors and registers them with reg
+func New(reg prometheus.Registerer) *Metrics {
+	m := &Metrics{
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "ledger_http_requests_total",
+			Help: "HTTP requests by method, route template and status code.",
+		}, []string{"method", "route", "code"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "ledger_http_request_duration_seconds",
+			Help:    "HTTP request latency by method, route template and status code.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "route", "code"}),
+		transfers: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "ledger_transfers_total",
+			Help: "Transfers by currency and outcome (success or an error code).",
+		}, []string{"currency", "outcome"}),
+		transferAmount: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "ledger_transfer_amount",
+			Help:    "Transferred amounts by currency and outcome.",
+			Buckets: prometheus.ExponentialBuckets(1, 10, 7),
+		}, []string{"currency", "outcome"}),
+		consumed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "ledger_consumer_messages_total",
+			Help: "Queued transactions consumed, by outcome (processed, failed, invalid, retried or dead_lettered).",
+		}, []string{"outcome"}),
+		consumeDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "ledger_consumer_processing_seconds",
+			Help:    "Time spent processing one queued transaction.",
+			Buckets: prometheus.DefBuckets,
+		}),
+		consumeLag: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "ledger_consumer_lag_seconds",
+			Help:    "Time between publishing a transaction and the consumer picking it up.",
+			Buckets: prometheus.ExponentialBuckets(0.005, 4, 10),
+		}),
+		published: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "ledger_publisher_messages_total",
+			Help: "Transactions published to the queue.",
+		}),
+		publishErrors: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "ledger_publisher_errors_total",
+			Help: "Transactions that could not be published to the queue.",
+		}),
+		brokerConnected: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "ledger_broker_connected",
+			Help: "Whether the queue client (publisher or consumer) is connected to its broker: 1 or 0.",
+		}, []string{"broker", "client"}),
+		brokerReconnects: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "ledger_broker_reconnect_attempts_total",
+			Help: "Attempts to reconnect to the broker by broker, client and outcome (success or error).",
+		}, []string{"broker", "client", "outcome"}),
+		repositoryLatency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "ledger_repository_duration_seconds",
+			Help:    "Repository call latency by repository, method and outcome.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"repository", "method", "outcome"}),
+	}
+	reg.MustRegister(
+		m.httpRequests, m.httpDuration,
+		m.transfers, m.transferAmount,
+		m.consumed, m.consumeDuration, m.consumeLag,
+		m.published, m.publishErrors,
+		m.brokerConnected, m.brokerReconnects,
+		m.repositoryLatency,
+	)
+	return m
+}
+
+// Consumer outcomes
+const (
+	ConsumeProcessed = "processed"
+	ConsumeFailed    = "failed"
+	ConsumeInvalid   = "invalid"
+	// ConsumeRetried messages failed with a transient error and were scheduled for another attempt
+	ConsumeRetried = "retried"
+	// ConsumeDeadLettered messages failed on every attempt and were moved to the dead-letter queue
+	ConsumeDeadLettered = "dead_lettered"
+)
+
+// ObserveConsume records one consumed message. lag is skipped when the publish time is unknown.
+func (m *Metrics) ObserveConsume(outcome string, duration, lag time.Duration) {
+	if m == nil {
+		return
+	}
+	m.consumed.WithLabelValues(outcome).Inc()
+	m.consumeDuration.Observe(duration.Seconds())
+	if lag > 0 {
+		m.consumeLag.Observe(lag.Seconds())
+	}
+}
+
+// ObservePublish records one publish attempt
+func (m *Metrics) ObservePublish(err error) {
+	if m == nil {
+		return
+	}
+	if err != nil {
+		m.publishErrors.Inc()
+		return
+	}
+	m.published.Inc()
+}
+
+// SetBrokerConnected records whether a queue client is connected to broker
+func (m *Metrics) SetBrokerConnected(broker, client string, connected bool) {
+	if m == nil {
+		return
+	}
+	v := 0.0
+	if connected {
+		v = 1
+	}
+	m.brokerConnected.WithLabelValues(broker, client).Set(v)
+}
+
+// ObserveReconnect records one attempt of a queue client to reconnect to broker
+func (m *Metrics) ObserveReconnect(broker, client string, err error) {
+	if m == nil {
+		return
+	}
+	outcome := "success"
+	if err != nil {
+		outcome = "error"
+	}
+	m.brokerReconnects.WithLabelValues(broker, client, outcome).Inc()
+}
+
+func (m *Metrics) observeRepository(repository, method string, start time.Time, err error) {
+	if m == nil {
+		return
+	}
+	outcome := "ok"
+	if err != nil {
+		outcome = "error"
+	}
+	m.repositoryLatency.WithLabelValues(repository, method, outcome).Observe(time.Since(start).Seconds())
+}
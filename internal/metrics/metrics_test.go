@@ -0,0 +1,127 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"ledger/internal/domain"
+)
+
+func TestMiddleware_LabelsByRouteTemplate(t *testing.T) {
+	m := New(prometheus.NewRegistry())
+
+	router := mux.NewRouter()
+	router.Use(m.Middleware)
+	api := router.PathPrefix("/api/v1").Subrouter()
+	api.HandleFunc("/accounts/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}).Methods("GET")
+
+	for _, id := range []string{"1", "2"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/v1/accounts/"+id, nil))
+	}
+
+	got := testutil.ToFloat64(m.httpRequests.WithLabelValues("get", "/api/v1/accounts/{id}", "404"))
+	if got != 2 {
+		t.Errorf("expected 2 requests for the route template, got %v", got)
+	}
+	if n := testutil.CollectAndCount(m.httpDuration); n != 1 {
+		t.Errorf("expected one latency series, got %d", n)
+	}
+}
+
+type stubTransactions struct {
+	domain.TransactionService
+	err error
+}
+
+func (s stubTransactions) ProcessTransaction(ctx context.Context, tx *domain.Transaction) error {
+	return s.err
+}
+
+func TestTransactionService_CountsByOutcome(t *testing.T) {
+	m := New(prometheus.NewRegistry())
+
+	NewTransactionService(stubTransactions{}, m).ProcessTransaction(context.Background(), &domain.Transaction{Amount: 25, Currency: "USD"})
+	NewTransactionService(stubTransactions{err: domain.ErrInsufficientFunds}, m).ProcessTransaction(context.Background(), &domain.Transaction{Amount: 5, Currency: "usd; drop"})
+
+	if got := testutil.ToFloat64(m.transfers.WithLabelValues("USD", "success")); got != 1 {
+		t.Errorf("expected one successful USD transfer, got %v", got)
+	}
+	if got := testutil.ToFloat64(m.transfers.WithLabelValues("other", "insufficient_funds")); got != 1 {
+		t.Errorf("expected one failed transfer with a sanitized currency, got %v", got)
+	}
+}
+
+type stubAccounts struct {
+	domain.AccountRepository
+}
+
+func (stubAccounts) GetByID(ctx context.Context, id string) (*domain.Account, error) {
+	if id == "missing" {
+		return nil, domain.ErrAccountNotFound
+	}
+	return &domain.Account{ID: id}, nil
+}
+
+func TestAccountRepository_ObservesLatency(t *testing.T) {
+	m := New(prometheus.NewRegistry())
+	repo := NewAccountRepository(stubAccounts{}, m)
+
+	repo.GetByID(context.Background(), "1")
+	if _, err := repo.GetByID(context.Background(), "missing"); !errors.Is(err, domain.ErrAccountNotFound) {
+		t.Errorf("errors must pass through unchanged, got %v", err)
+	}
+
+	if n := testutil.CollectAndCount(m.repositoryLatency); n != 2 {
+		t.Errorf("expected ok and error series, got %d", n)
+	}
+}
+
+func TestNilMetricsRecordNothing(t *testing.T) {
+	var m *Metrics
+	m.ObservePublish(errors.New("closed"))
+	m.ObserveConsume(ConsumeFailed, time.Second, time.Second)
+	NewTransactionService(stubTransactions{}, nil).ProcessTransaction(context.Background(), &domain.Transaction{})
+	NewAccountRepository(stubAccounts{}, nil).GetByID(context.Background(), "1")
+}
+
+func TestObserveConsume(t *testing.T) {
+	m := New(prometheus.NewRegistry())
+	m.ObserveConsume(ConsumeProcessed, 10*time.Millisecond, 0)
+	m.ObserveConsume(ConsumeFailed, 10*time.Millisecond, 2*time.Second)
+
+	if got := testutil.ToFloat64(m.consumed.WithLabelValues(ConsumeFailed)); got != 1 {
+		t.Errorf("expected one failed message, got %v", got)
+	}
+	// Messages without a publish time do not contribute to lag
+	if got := testutil.CollectAndCount(m.consumeLag); got != 1 {
+		t.Errorf("expected the lag histogram to be collected, got %d", got)
+	}
+}
+
+func TestBrokerConnectionState(t *testing.T) {
+	m := New(prometheus.NewRegistry())
+
+	m.SetBrokerConnected("rabbitmq", "consumer", false)
+	m.ObserveReconnect("rabbitmq", "consumer", errors.New("connection refused"))
+	m.ObserveReconnect("rabbitmq", "consumer", nil)
+	m.SetBrokerConnected("rabbitmq", "consumer", true)
+
+	if got := testutil.ToFloat64(m.brokerConnected.WithLabelValues("rabbitmq", "consumer")); got != 1 {
+		t.Errorf("expected the consumer to be reported connected, got %v", got)
+	}
+	if got := testutil.ToFloat64(m.brokerReconnects.WithLabelValues("rabbitmq", "consumer", "error")); got != 1 {
+		t.Errorf("expected one failed reconnect, got %v", got)
+	}
+	var nilMetrics *Metrics
+	nilMetrics.SetBrokerConnected("rabbitmq", "consumer", true) // must not panic
+}
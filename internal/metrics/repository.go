@@ -0,0 +1,120 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"ledger/internal/domain"
+)
+
+// AccountRepository records the latency of every call to a domain.AccountRepository
+type AccountRepository struct {
+	next    domain.AccountRepository
+	metrics *Metrics
+}
+
+func NewAccountRepository(next domain.AccountRepository, m *Metrics) *AccountRepository {
+	return &AccountRepository{next: next, metrics: m}
+}
+
+func (r *AccountRepository) observe(method string, start time.Time, err error) {
+	r.metrics.observeRepository("account", method, start, err)
+}
+
+func (r *AccountRepository) Create(ctx context.Context, acc *domain.Account) error {
+	start := time.Now()
+	err := r.next.Create(ctx, acc)
+	r.observe("Create", start, err)
+	return err
+}
+
+func (r *AccountRepository) GetByID(ctx context.Context, id string) (*domain.Account, error) {
+	start := time.Now()
+	acc, err := r.next.GetByID(ctx, id)
+	r.observe("GetByID", start, err)
+	return acc, err
+}
+
+func (r *AccountRepository) GetAll(ctx context.Context) ([]*domain.Account, error) {
+	start := time.Now()
+	accounts, err := r.next.GetAll(ctx)
+	r.observe("GetAll", start, err)
+	return accounts, err
+}
+
+func (r *AccountRepository) List(ctx context.Context, opts domain.AccountListOptions) (*domain.AccountPage, error) {
+	start := time.Now()
+	page, err := r.next.List(ctx, opts)
+	r.observe("List", start, err)
+	return page, err
+}
+
+func (r *AccountRepository) GetByCustomerID(ctx context.Context, customerID string) ([]*domain.Account, error) {
+	start := time.Now()
+	accounts, err := r.next.GetByCustomerID(ctx, customerID)
+	r.observe("GetByCustomerID", start, err)
+	return accounts, err
+}
+
+func (r *AccountRepository) UpdateBalance(ctx context.Context, id string, amount float64) error {
+	start := time.Now()
+	err := r.next.UpdateBalance(ctx, id, amount)
+	r.observe("UpdateBalance", start, err)
+	return err
+}
+
+func (r *AccountRepository) UpdateStatus(ctx context.Context, id string, status string) error {
+	start := time.Now()
+	err := r.next.UpdateStatus(ctx, id, status)
+	r.observe("UpdateStatus", start, err)
+	return err
+}
+
+func (r *AccountRepository) Delete(ctx context.Context, id string) error {
+	start := time.Now()
+	err := r.next.Delete(ctx, id)
+	r.observe("Delete", start, err)
+	return err
+}
+
+// LedgerRepository records the latency of every call to a domain.LedgerRepository
+type LedgerRepository struct {
+	next    domain.LedgerRepository
+	metrics *Metrics
+}
+
+func NewLedgerRepository(next domain.LedgerRepository, m *Metrics) *LedgerRepository {
+	return &LedgerRepository{next: next, metrics: m}
+}
+
+func (r *LedgerRepository) observe(method string, start time.Time, err error) {
+	r.metrics.observeRepository("ledger", method, start, err)
+}
+
+func (r *LedgerRepository) SaveEntry(ctx context.Context, entry *domain.LedgerEntry) error {
+	start := time.Now()
+	err := r.next.SaveEntry(ctx, entry)
+	r.observe("SaveEntry", start, err)
+	return err
+}
+
+func (r *LedgerRepository) GetEntryByTransactionID(ctx context.Context, transactionID string) (*domain.LedgerEntry, error) {
+	start := time.Now()
+	entry, err := r.next.GetEntryByTransactionID(ctx, transactionID)
+	r.observe("GetEntryByTransactionID", start, err)
+	return entry, err
+}
+
+func (r *LedgerRepository) GetEntriesByAccountID(ctx context.Context, accountID int64) ([]*domain.LedgerEntry, error) {
+	start := time.Now()
+	entries, err := r.next.GetEntriesByAccountID(ctx, accountID)
+	r.observe("GetEntriesByAccountID", start, err)
+	return entries, err
+}
+
+func (r *LedgerRepository) ListEntriesByAccountID(ctx context.Context, accountID int64, filter domain.HistoryFilter) (*domain.LedgerPage, error) {
+	start := time.Now()
+	page, err := r.next.ListEntriesByAccountID(ctx, accountID, filter)
+	r.observe("ListEntriesByAccountID", start, err)
+	return page, err
+}
@@ -0,0 +1,46 @@
+package metrics
+
+import (
+	"context"
+
+	"ledger/internal/domain"
+	"ledger/internal/errmap"
+)
+
+// TransactionService counts transfers and their amounts by currency and outcome
+type TransactionService struct {
+	domain.TransactionService
+	metrics *Metrics
+}
+
+func NewTransactionService(next domain.TransactionService, m *Metrics) *TransactionService {
+	return &TransactionService{TransactionService: next, metrics: m}
+}
+
+func (s *TransactionService) ProcessTransaction(ctx context.Context, tx *domain.Transaction) error {
+	err := s.TransactionService.ProcessTransaction(ctx, tx)
+	if s.metrics != nil {
+		outcome := "success"
+		if err != nil {
+			outcome = errmap.Code(err)
+		}
+		currency := currencyLabel(tx.Currency)
+		s.metrics.transfers.WithLabelValues(currency, outcome).Inc()
+		s.metrics.transferAmount.WithLabelValues(currency, outcome).Observe(tx.Amount)
+	}
+	return err
+}
+
+// currencyLabel keeps the label set bounded: anything that is not a three-letter
+// upper-case code is reported as "other"
+func currencyLabel(c string) string {
+	if len(c) != 3 {
+		return "other"
+	}
+	for i := 0; i < len(c); i++ {
+		if c[i] < 'A' || c[i] > 'Z' {
+			return "other"
+		}
+	}
+	return c
+}
@@ -0,0 +1,134 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad_SortsAndPairsFiles(t *testing.T) {
+	fsys := fstest.MapFS{
+		"m/0002_add_status.up.sql":   {Data: []byte("ALTER TABLE a ADD status TEXT")},
+		"m/0002_add_status.down.sql": {Data: []byte("ALTER TABLE a DROP status")},
+		"m/0001_create.up.sql":       {Data: []byte("CREATE TABLE a ()")},
+		"m/README.md":                {Data: []byte("ignored")},
+	}
+
+	migrations, err := Load(fsys, "m")
+	require.NoError(t, err)
+	require.Len(t, migrations, 2)
+	assert.Equal(t, int64(1), migrations[0].Version)
+	assert.Equal(t, "create", migrations[0].Name)
+	assert.Empty(t, migrations[0].Down)
+	assert.Equal(t, "ALTER TABLE a DROP status", migrations[1].Down)
+}
+
+func TestLoad_RejectsBrokenSets(t *testing.T) {
+	_, err := Load(fstest.MapFS{"m/0001_create.down.sql": {Data: []byte("DROP TABLE a")}}, "m")
+	assert.Error(t, err, "a version without an up file")
+
+	_, err = Load(fstest.MapFS{
+		"m/0001_create.up.sql": {Data: []byte("CREATE TABLE a ()")},
+		"m/0001_other.up.sql":  {Data: []byte("CREATE TABLE b ()")},
+	}, "m")
+	assert.Error(t, err, "two migrations sharing a version")
+}
+
+func TestEmbeddedMigrationsAreReversible(t *testing.T) {
+	migrations, err := Load(embedded, "psql")
+	require.NoError(t, err)
+	require.NotEmpty(t, migrations)
+	for i, m := range migrations {
+		assert.Equal(t, int64(i+1), m.Version, "versions should be consecutive")
+		assert.NotEmpty(t, m.Down, "migration %d_%s has no down file", m.Version, m.Name)
+	}
+}
+
+var testMigrations = []Migration{
+	{Version: 1, Name: "create", Up: "CREATE TABLE a", Down: "DROP TABLE a"},
+	{Version: 2, Name: "add_status", Up: "ALTER TABLE a ADD status", Down: "ALTER TABLE a DROP status"},
+}
+
+func expectLocked(mock sqlmock.Sqlmock, applied *sqlmock.Rows) {
+	mock.ExpectExec(`SELECT pg_advisory_lock`).WithArgs(lockKey).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`CREATE TABLE IF NOT EXISTS schema_migrations`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT version, applied_at FROM schema_migrations`).WillReturnRows(applied)
+}
+
+func TestUp_AppliesPendingMigrations(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	expectLocked(mock, sqlmock.NewRows([]string{"version", "applied_at"}).AddRow(1, time.Now()))
+	mock.ExpectBegin()
+	mock.ExpectExec(`ALTER TABLE a ADD status`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`INSERT INTO schema_migrations`).WithArgs(int64(2), "add_status").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectExec(`SELECT pg_advisory_unlock`).WithArgs(lockKey).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	applied, err := NewWithMigrations(db, testMigrations).Up(context.Background())
+	require.NoError(t, err)
+	require.Len(t, applied, 1)
+	assert.Equal(t, int64(2), applied[0].Version)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUp_RollsBackFailedMigration(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	expectLocked(mock, sqlmock.NewRows([]string{"version", "applied_at"}))
+	mock.ExpectBegin()
+	mock.ExpectExec(`CREATE TABLE a`).WillReturnError(errors.New("syntax error"))
+	mock.ExpectRollback()
+	mock.ExpectExec(`SELECT pg_advisory_unlock`).WithArgs(lockKey).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	applied, err := NewWithMigrations(db, testMigrations).Up(context.Background())
+	assert.ErrorContains(t, err, "apply migration 1_create")
+	assert.Empty(t, applied)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDown_RevertsLatest(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	expectLocked(mock, sqlmock.NewRows([]string{"version", "applied_at"}).AddRow(1, time.Now()).AddRow(2, time.Now()))
+	mock.ExpectBegin()
+	mock.ExpectExec(`ALTER TABLE a DROP status`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`DELETE FROM schema_migrations`).WithArgs(int64(2)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectExec(`SELECT pg_advisory_unlock`).WithArgs(lockKey).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	reverted, err := NewWithMigrations(db, testMigrations).Down(context.Background(), 1)
+	require.NoError(t, err)
+	require.Len(t, reverted, 1)
+	assert.Equal(t, "add_status", reverted[0].Name)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStatus(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	at := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	expectLocked(mock, sqlmock.NewRows([]string{"version", "applied_at"}).AddRow(1, at))
+	mock.ExpectExec(`SELECT pg_advisory_unlock`).WithArgs(lockKey).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	statuses, err := NewWithMigrations(db, testMigrations).Status(context.Background())
+	require.NoError(t, err)
+	require.Len(t, statuses, 2)
+	require.NotNil(t, statuses[0].AppliedAt)
+	assert.True(t, statuses[0].AppliedAt.Equal(at))
+	assert.Nil(t, statuses[1].AppliedAt)
+}
@@ -1,4 +1,3 @@
-
 CREATE TABLE IF NOT EXISTS accounts (
     id SERIAL PRIMARY KEY,
     owner_name TEXT NOT NULL,
@@ -7,7 +6,6 @@ CREATE TABLE IF NOT EXISTS accounts (
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
-
 CREATE TABLE IF NOT EXISTS transactions (
     id SERIAL PRIMARY KEY,
     from_account_id INT REFERENCES accounts(id),
@@ -16,4 +14,4 @@ CREATE TABLE IF NOT EXISTS transactions (
     currency TEXT NOT NULL,
     status TEXT NOT NULL,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-    );
+);
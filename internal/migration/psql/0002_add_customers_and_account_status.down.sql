@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_accounts_customer_id;
+ALTER TABLE accounts DROP COLUMN IF EXISTS status;
+ALTER TABLE accounts DROP COLUMN IF EXISTS customer_id;
+DROP TABLE IF EXISTS customers;
@@ -0,0 +1,189 @@
+// Package openapi embeds the OpenAPI 3 document describing the REST API and
+// provides middleware that validates traffic against it.
+package openapi
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+
+	"ledger/internal/domain"
+	"ledger/internal/handler"
+)
+
+//go:embed openapi.yaml
+var specYAML []byte
+
+// Load parses and validates the embedded OpenAPI document
+func Load() (*openapi3.T, error) {
+	loader := openapi3.NewLoader()
+	doc, err := loader.LoadFromData(specYAML)
+	if err != nil {
+		return nil, fmt.Errorf("parse openapi spec: %w", err)
+	}
+	if err := doc.Validate(context.Background()); err != nil {
+		return nil, fmt.Errorf("invalid openapi spec: %w", err)
+	}
+	return doc, nil
+}
+
+// SpecHandler serves the embedded document as YAML, or as JSON when the path ends in .json
+func SpecHandler(doc *openapi3.T) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, ".json") {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(doc)
+			return
+		}
+		w.Header().Set("Content-Type", "application/yaml")
+		w.Write(specYAML)
+	}
+}
+
+// Validator checks requests, and optionally responses, against the spec
+type Validator struct {
+	router routers.Router
+	// StrictResponses replaces responses that violate the spec with a 500 instead of only logging them
+	StrictResponses bool
+}
+
+// NewValidator builds a Validator for the given document
+func NewValidator(doc *openapi3.T) (*Validator, error) {
+	router, err := gorillamux.NewRouter(doc)
+	if err != nil {
+		return nil, fmt.Errorf("build openapi router: %w", err)
+	}
+	return &Validator{router: router}, nil
+}
+
+// Middleware rejects requests that do not match the spec with 400 and checks every
+// response produced for a documented operation. Routes missing from the spec are passed through.
+func (v *Validator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route, pathParams, err := v.router.FindRoute(r)
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		reqInput := &openapi3filter.RequestValidationInput{
+			Request:    r,
+			PathParams: pathParams,
+			Route:      route,
+			Options: &openapi3filter.Options{
+				AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+			},
+		}
+		if err := openapi3filter.ValidateRequest(r.Context(), reqInput); err != nil {
+			handler.WriteError(w, r, fmt.Errorf("%w: request does not match API spec: %s", domain.ErrValidation, requestErrorMessage(err)))
+			return
+		}
+
+		// Streams never finish, so their responses cannot be buffered for validation
+		if isStream(route.Operation) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		rec := &responseRecorder{header: make(http.Header), status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		respInput := &openapi3filter.ResponseValidationInput{
+			RequestValidationInput: reqInput,
+			Status:                 rec.status,
+			Header:                 rec.header,
+			Body:                   io.NopCloser(bytes.NewReader(rec.body.Bytes())),
+			Options:                &openapi3filter.Options{IncludeResponseStatus: true},
+		}
+		if err := openapi3filter.ValidateResponse(r.Context(), respInput); err != nil {
+			slog.WarnContext(r.Context(), "response does not match API spec", "method", r.Method, "route", route.Path, "error", err)
+			if v.StrictResponses {
+				handler.WriteError(w, r, fmt.Errorf("response does not match API spec: %w", err))
+				return
+			}
+		}
+
+		rec.flush(w)
+	})
+}
+
+// isStream reports whether op documents a text/event-stream or WebSocket (101) response
+func isStream(op *openapi3.Operation) bool {
+	if op.Responses == nil {
+		return false
+	}
+	for code, resp := range op.Responses.Map() {
+		if code == "101" {
+			return true
+		}
+		if resp.Value != nil && resp.Value.Content.Get("text/event-stream") != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// requestErrorMessage keeps the client-facing part of a validation error short
+func requestErrorMessage(err error) string {
+	var reqErr *openapi3filter.RequestError
+	if errors.As(err, &reqErr) {
+		if reqErr.Parameter != nil {
+			return fmt.Sprintf("parameter %q: %s", reqErr.Parameter.Name, reqErr.Reason)
+		}
+		if reqErr.RequestBody != nil {
+			return fmt.Sprintf("request body: %v", reqErr.Err)
+		}
+	}
+	return err.Error()
+}
+
+// responseRecorder buffers a response so it can be validated before it is sent
+type responseRecorder struct {
+	header      http.Header
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (r *responseRecorder) Header() http.Header {
+	return r.header
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if r.wroteHeader {
+		return
+	}
+	r.status = status
+	r.wroteHeader = true
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	if !r.wroteHeader {
+		r.WriteHeader(http.StatusOK)
+	}
+	// Mirror net/http, which sniffs a content type for bodies written without one
+	if r.header.Get("Content-Type") == "" {
+		r.header.Set("Content-Type", http.DetectContentType(b))
+	}
+	return r.body.Write(b)
+}
+
+func (r *responseRecorder) flush(w http.ResponseWriter) {
+	for k, v := range r.header {
+		w.Header()[k] = v
+	}
+	w.WriteHeader(r.status)
+	w.Write(r.body.Bytes())
+}
@@ -0,0 +1,165 @@
+package openapi_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"ledger/internal/domain"
+	"ledger/internal/handler"
+	"ledger/internal/openapi"
+)
+
+func newTestRouter(t *testing.T, strict bool, h http.HandlerFunc) *mux.Router {
+	t.Helper()
+	spec, err := openapi.Load()
+	if err != nil {
+		t.Fatalf("failed to load spec: %v", err)
+	}
+	validator, err := openapi.NewValidator(spec)
+	if err != nil {
+		t.Fatalf("failed to build validator: %v", err)
+	}
+	validator.StrictResponses = strict
+
+	router := mux.NewRouter()
+	api := router.PathPrefix("/api/v1").Subrouter()
+	api.Use(validator.Middleware)
+	api.HandleFunc("/openapi.yaml", openapi.SpecHandler(spec)).Methods("GET")
+	api.HandleFunc("/accounts/{id}", h).Methods("GET")
+	api.HandleFunc("/transactions", h).Methods("POST")
+	api.HandleFunc("/accounts/{id}/events", h).Methods("GET")
+	return router
+}
+
+func TestValidator_RejectsInvalidRequestBody(t *testing.T) {
+	called := false
+	router := newTestRouter(t, false, func(w http.ResponseWriter, r *http.Request) { called = true })
+
+	body := `{"from_account_id": 1, "to_account_id": 2, "amount": -5, "currency": "USD"}`
+	r := httptest.NewRequest("POST", "/api/v1/transactions", bytes.NewBufferString(body))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, r)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+	if !bytes.Contains(w.Body.Bytes(), []byte(`"code":"validation_failed"`)) {
+		t.Errorf("expected a validation problem, got %s", w.Body.String())
+	}
+	if called {
+		t.Error("handler should not run for an invalid request")
+	}
+}
+
+func TestValidator_PassesValidRequest(t *testing.T) {
+	router := newTestRouter(t, true, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"status": "success", "transaction_id": "tx1"}`))
+	})
+
+	body := `{"from_account_id": 1, "to_account_id": 2, "amount": 5, "currency": "USD"}`
+	r := httptest.NewRequest("POST", "/api/v1/transactions", bytes.NewBufferString(body))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, r)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestValidator_StrictResponses(t *testing.T) {
+	router := newTestRouter(t, true, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"owner_name": "Alice"}`)) // missing required fields
+	})
+
+	r := httptest.NewRequest("GET", "/api/v1/accounts/acc1", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, r)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d", w.Code)
+	}
+}
+
+func TestValidator_ProblemResponsesMatchSpec(t *testing.T) {
+	router := newTestRouter(t, true, func(w http.ResponseWriter, r *http.Request) {
+		handler.WriteError(w, r, domain.ErrAccountNotFound)
+	})
+
+	r := httptest.NewRequest("GET", "/api/v1/accounts/acc1", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, r)
+
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d: %s", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/problem+json" {
+		t.Errorf("expected problem+json, got %q", ct)
+	}
+}
+
+func TestValidator_LogsResponsesWhenNotStrict(t *testing.T) {
+	router := newTestRouter(t, false, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"owner_name": "Alice"}`))
+	})
+
+	r := httptest.NewRequest("GET", "/api/v1/accounts/acc1", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+}
+
+func TestSpecHandler(t *testing.T) {
+	router := newTestRouter(t, false, nil)
+
+	r := httptest.NewRequest("GET", "/api/v1/openapi.yaml", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK || !bytes.Contains(w.Body.Bytes(), []byte("openapi: 3.0.3")) {
+		t.Fatalf("expected the spec, got %d", w.Code)
+	}
+}
+
+func TestValidator_StreamsAreNotBuffered(t *testing.T) {
+	router := newTestRouter(t, true, func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := w.(http.Flusher); !ok {
+			t.Error("stream handler should get the underlying writer")
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("id: 1\nevent: balance.updated\ndata: {}\n\n"))
+	})
+
+	r := httptest.NewRequest("GET", "/api/v1/accounts/acc1/events", nil)
+	r.Header.Set("Last-Event-ID", "oops")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected request validation to still apply, got %d", w.Code)
+	}
+
+	r = httptest.NewRequest("GET", "/api/v1/accounts/acc1/events", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "text/event-stream" {
+		t.Errorf("expected the stream to pass through, got %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+}
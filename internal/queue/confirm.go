@@ -0,0 +1,198 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/streadway/amqp"
+)
+
+// Errors returned by TransactionPublisher.Publish
+var (
+	// ErrUnavailable means the publisher is reconnecting to the broker
+	ErrUnavailable = errors.New("rabbitmq is unavailable")
+	// ErrUnroutable means the broker accepted the message but no queue was bound to take it
+	ErrUnroutable = errors.New("message was not routed to any queue")
+	// ErrNacked means the broker could not take responsibility for the message
+	ErrNacked = errors.New("rabbitmq rejected the message")
+	// ErrUnconfirmed means the broker did not confirm the message in time, or
+	// the channel closed first; the message may or may not have been queued
+	ErrUnconfirmed = errors.New("message was not confirmed by rabbitmq")
+)
+
+// confirmChannel is a channel in confirm mode that publishes mandatory
+// messages and waits for the broker to confirm each one. It is safe for
+// concurrent use; publishes are serialized only until they are sent.
+type confirmChannel struct {
+	conn *amqp.Connection
+	ch   *amqp.Channel
+
+	// publishMu keeps delivery tags in step with the order messages are sent
+	publishMu sync.Mutex
+	lastTag   uint64
+
+	mu      sync.Mutex
+	pending map[uint64]*pendingPublish
+	byID    map[string]*pendingPublish
+
+	// closed is closed once the channel is gone and every pending publish has failed
+	closed chan struct{}
+}
+
+type pendingPublish struct {
+	tag      uint64
+	id       string
+	returned *amqp.Return
+	done     chan error
+}
+
+// newConfirmChannel puts ch in confirm mode and starts tracking confirmations
+// and returned messages
+func newConfirmChannel(conn *amqp.Connection, ch *amqp.Channel) (*confirmChannel, error) {
+	if err := ch.Confirm(false); err != nil {
+		return nil, fmt.Errorf("enable publisher confirms: %w", err)
+	}
+	c := newConfirmTracker()
+	c.conn, c.ch = conn, ch
+	// Listeners must be drained, or they block the connection's reader
+	go c.listen(ch.NotifyPublish(make(chan amqp.Confirmation, 64)), ch.NotifyReturn(make(chan amqp.Return, 16)))
+	return c, nil
+}
+
+func newConfirmTracker() *confirmChannel {
+	return &confirmChannel{
+		pending: make(map[uint64]*pendingPublish),
+		byID:    make(map[string]*pendingPublish),
+		closed:  make(chan struct{}),
+	}
+}
+
+// publish sends msg and waits until the broker confirms it, timeout passes or ctx is done
+func (c *confirmChannel) publish(ctx context.Context, exchange, key string, msg amqp.Publishing, timeout time.Duration) error {
+	if msg.MessageId == "" {
+		msg.MessageId = uuid.NewString()
+	}
+
+	c.publishMu.Lock()
+	p := c.track(c.lastTag+1, msg.MessageId)
+	if err := c.ch.Publish(exchange, key, true, false, msg); err != nil {
+		c.publishMu.Unlock()
+		c.untrack(p)
+		return err
+	}
+	c.lastTag++
+	c.publishMu.Unlock()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case err := <-p.done:
+		return err
+	case <-timer.C:
+		c.untrack(p)
+		return fmt.Errorf("%w within %s", ErrUnconfirmed, timeout)
+	case <-ctx.Done():
+		c.untrack(p)
+		return fmt.Errorf("%w: %w", ErrUnconfirmed, ctx.Err())
+	}
+}
+
+func (c *confirmChannel) track(tag uint64, id string) *pendingPublish {
+	p := &pendingPublish{tag: tag, id: id, done: make(chan error, 1)}
+	c.mu.Lock()
+	c.pending[tag] = p
+	c.byID[id] = p
+	c.mu.Unlock()
+	return p
+}
+
+func (c *confirmChannel) untrack(p *pendingPublish) {
+	c.mu.Lock()
+	delete(c.pending, p.tag)
+	delete(c.byID, p.id)
+	c.mu.Unlock()
+}
+
+// listen resolves pending publishes until the channel closes. The broker sends
+// basic.return before the confirmation of the same message, so returns that
+// are already buffered are applied before each confirmation.
+func (c *confirmChannel) listen(confirms <-chan amqp.Confirmation, returns <-chan amqp.Return) {
+	defer close(c.closed)
+	for {
+		select {
+		case r, ok := <-returns:
+			if !ok {
+				returns = nil
+				continue
+			}
+			c.returned(r)
+		case confirm, ok := <-confirms:
+			if !ok {
+				c.failAll(fmt.Errorf("%w: channel closed", ErrUnconfirmed))
+				return
+			}
+			for drained := false; !drained; {
+				select {
+				case r, ok := <-returns:
+					if ok {
+						c.returned(r)
+					} else {
+						returns, drained = nil, true
+					}
+				default:
+					drained = true
+				}
+			}
+			c.confirmed(confirm)
+		}
+	}
+}
+
+func (c *confirmChannel) returned(r amqp.Return) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if p, ok := c.byID[r.MessageId]; ok {
+		p.returned = &r
+	}
+}
+
+func (c *confirmChannel) confirmed(confirm amqp.Confirmation) {
+	c.mu.Lock()
+	p, ok := c.pending[confirm.DeliveryTag]
+	if ok {
+		delete(c.pending, p.tag)
+		delete(c.byID, p.id)
+	}
+	c.mu.Unlock()
+	if !ok {
+		// The publisher stopped waiting for it
+		return
+	}
+	switch {
+	case !confirm.Ack:
+		p.done <- ErrNacked
+	case p.returned != nil:
+		p.done <- fmt.Errorf("%w: %s", ErrUnroutable, p.returned.ReplyText)
+	default:
+		p.done <- nil
+	}
+}
+
+func (c *confirmChannel) failAll(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for tag, p := range c.pending {
+		p.done <- err
+		delete(c.pending, tag)
+		delete(c.byID, p.id)
+	}
+}
+
+func (c *confirmChannel) close() {
+	c.ch.Close()
+	c.conn.Close()
+}
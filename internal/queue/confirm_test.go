@@ -0,0 +1,91 @@
+package queue
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+// startTracker runs a confirm tracker on channels the test feeds in place of the broker
+func startTracker() (*confirmChannel, chan amqp.Confirmation, chan amqp.Return) {
+	c := newConfirmTracker()
+	confirms := make(chan amqp.Confirmation, 8)
+	returns := make(chan amqp.Return, 8)
+	go c.listen(confirms, returns)
+	return c, confirms, returns
+}
+
+func result(t *testing.T, p *pendingPublish) error {
+	t.Helper()
+	select {
+	case err := <-p.done:
+		return err
+	case <-time.After(time.Second):
+		t.Fatal("publish was never resolved")
+		return nil
+	}
+}
+
+func TestConfirmTrackerResolvesByDeliveryTag(t *testing.T) {
+	c, confirms, _ := startTracker()
+	first, second := c.track(1, "a"), c.track(2, "b")
+
+	confirms <- amqp.Confirmation{DeliveryTag: 2, Ack: false}
+	confirms <- amqp.Confirmation{DeliveryTag: 1, Ack: true}
+
+	if err := result(t, first); err != nil {
+		t.Errorf("acked publish failed: %v", err)
+	}
+	if err := result(t, second); !errors.Is(err, ErrNacked) {
+		t.Errorf("nacked publish returned %v, want ErrNacked", err)
+	}
+}
+
+func TestConfirmTrackerReportsReturnedMessages(t *testing.T) {
+	c, confirms, returns := startTracker()
+	p := c.track(1, "a")
+
+	// The broker sends the return before the ack of the same message
+	returns <- amqp.Return{MessageId: "a", ReplyText: "NO_ROUTE"}
+	confirms <- amqp.Confirmation{DeliveryTag: 1, Ack: true}
+
+	if err := result(t, p); !errors.Is(err, ErrUnroutable) {
+		t.Errorf("returned publish returned %v, want ErrUnroutable", err)
+	}
+}
+
+func TestConfirmTrackerFailsPendingWhenClosed(t *testing.T) {
+	c, confirms, returns := startTracker()
+	p := c.track(1, "a")
+
+	close(returns)
+	close(confirms)
+
+	if err := result(t, p); !errors.Is(err, ErrUnconfirmed) {
+		t.Errorf("pending publish returned %v, want ErrUnconfirmed", err)
+	}
+	select {
+	case <-c.closed:
+	case <-time.After(time.Second):
+		t.Error("tracker did not report the channel as closed")
+	}
+}
+
+func TestConfirmTrackerIgnoresAbandonedPublishes(t *testing.T) {
+	c, confirms, _ := startTracker()
+	abandoned := c.track(1, "a")
+	c.untrack(abandoned)
+	p := c.track(2, "b")
+
+	confirms <- amqp.Confirmation{DeliveryTag: 1, Ack: true}
+	confirms <- amqp.Confirmation{DeliveryTag: 2, Ack: true}
+
+	if err := result(t, p); err != nil {
+		t.Errorf("publish failed: %v", err)
+	}
+	if len(abandoned.done) != 0 {
+		t.Error("an abandoned publish was resolved")
+	}
+}
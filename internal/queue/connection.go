@@ -0,0 +1,54 @@
+package queue
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/streadway/amqp"
+
+	"ledger/internal/metrics"
+)
+
+// reconnectBackoff spaces out attempts to reach the broker after losing it
+var reconnectBackoff = RetryPolicy{BaseDelay: 500 * time.Millisecond, MaxDelay: 30 * time.Second}
+
+// dial opens a connection and a channel, and runs setup on the channel to
+// declare the topology the caller needs
+func dial(amqpURL string, setup func(ch *amqp.Channel) error) (*amqp.Connection, *amqp.Channel, error) {
+	conn, err := amqp.Dial(amqpURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	ch, err := conn.Channel()
+	if err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	if err := setup(ch); err != nil {
+		ch.Close()
+		conn.Close()
+		return nil, nil, err
+	}
+	return conn, ch, nil
+}
+
+// reconnect calls connect until it succeeds, waiting reconnectBackoff between
+// attempts, and records every attempt of client in m. It gives up and returns
+// false once stop is closed.
+func reconnect(stop <-chan struct{}, client string, m *metrics.Metrics, connect func() error) bool {
+	for attempt := 1; ; attempt++ {
+		delay := reconnectBackoff.Delay(attempt)
+		select {
+		case <-stop:
+			return false
+		case <-time.After(delay):
+		}
+		err := connect()
+		m.ObserveReconnect(systemRabbitMQ, client, err)
+		if err == nil {
+			slog.Info("reconnected to rabbitmq", "client", client, "attempts", attempt)
+			return true
+		}
+		slog.Warn("failed to reconnect to rabbitmq", "client", client, "attempt", attempt, "error", err)
+	}
+}
@@ -2,120 +2,359 @@ package queue
 
 import (
 	"context"
-	"encoding/json"
-	"log"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/streadway/amqp"
 	"ledger/internal/domain"
+	"ledger/internal/logging"
+	"ledger/internal/metrics"
+	"ledger/internal/tracing"
 )
 
+// TransactionConsumer applies queued transactions. When its connection or
+// channel to the broker is lost it reconnects with backoff, re-declares its
+// queues and resumes consuming, until Shutdown is called.
 type TransactionConsumer struct {
-	conn               *amqp.Connection
-	channel            *amqp.Channel
+	amqpURL            string
 	queueName          string
 	transactionService domain.TransactionService
+	options            ConsumerOptions
+	// Metrics, when set, records processing outcomes, durations, queue lag and the connection state
+	Metrics *metrics.Metrics
+
+	mu      sync.Mutex
+	conn    *amqp.Connection
+	channel *amqp.Channel
+	// republisher sends retries and dead letters on a second channel, in confirm mode
+	republisher *confirmChannel
+	// connected is whether the consumer is subscribed to the queue
+	connected atomic.Bool
+	// stop is closed by Shutdown, so a lost connection is not re-established
+	stop     chan struct{}
+	stopOnce sync.Once
+	// done is closed once the consumer has stopped and its workers have handled their last message
+	done chan struct{}
+}
+
+// ConsumerOptions tunes how a TransactionConsumer processes and retries messages
+type ConsumerOptions struct {
+	Retry RetryPolicy
+	// Workers is the number of transfers processed in parallel; defaults to 1
+	Workers int
+	// Prefetch is how many unacknowledged messages the broker hands the
+	// consumer at once; defaults to four per worker, so workers whose accounts
+	// are busy do not leave the others idle
+	Prefetch int
 }
 
+func (o ConsumerOptions) withDefaults() ConsumerOptions {
+	if o.Workers < 1 {
+		o.Workers = 1
+	}
+	if o.Prefetch < 1 {
+		o.Prefetch = 4 * o.Workers
+	}
+	return o
+}
+
+// consumerTag identifies the subscription so Shutdown can cancel it
+const consumerTag = "ledger-transaction-consumer"
+
+// Client names used in logs and connection metrics
+const (
+	clientConsumer  = "consumer"
+	clientPublisher = "publisher"
+)
+
 // StartTransactionConsumer wrapper for the transaction consumer that listens to a RabbitMQ queue and processes transactions
-func StartTransactionConsumer(amqpURL, queueName string, service domain.TransactionService) error {
-	consumer, err := NewTransactionConsumer(amqpURL, queueName, service)
+func StartTransactionConsumer(amqpURL, queueName string, service domain.TransactionService, m *metrics.Metrics, opts ConsumerOptions) (*TransactionConsumer, error) {
+	consumer, err := NewTransactionConsumer(amqpURL, queueName, service, opts)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	go func() {
-		if err := consumer.StartConsuming(); err != nil {
-			log.Fatalf("consumer failed: %v", err)
-		}
-	}()
-	return nil
+	consumer.Metrics = m
+	if err := consumer.StartConsuming(); err != nil {
+		consumer.Close()
+		return nil, err
+	}
+	return consumer, nil
 }
 
-func NewTransactionConsumer(amqpURL, queueName string, service domain.TransactionService) (*TransactionConsumer, error) {
-	conn, err := amqp.Dial(amqpURL)
-	if err != nil {
+// NewTransactionConsumer connects to the broker and declares queueName along
+// with the dead-letter and retry queues its retry policy needs
+func NewTransactionConsumer(amqpURL, queueName string, service domain.TransactionService, opts ConsumerOptions) (*TransactionConsumer, error) {
+	c := &TransactionConsumer{
+		amqpURL:            amqpURL,
+		queueName:          queueName,
+		transactionService: service,
+		options:            opts.withDefaults(),
+		stop:               make(chan struct{}),
+		done:               make(chan struct{}),
+	}
+	if err := c.connect(); err != nil {
 		return nil, err
 	}
+	return c, nil
+}
 
-	ch, err := conn.Channel()
+// connect opens a connection and channel and declares the topology
+func (c *TransactionConsumer) connect() error {
+	conn, ch, err := dial(c.amqpURL, func(ch *amqp.Channel) error {
+		if err := declareTopology(ch, c.queueName, c.options.Retry); err != nil {
+			return err
+		}
+		// Bound the unacknowledged deliveries, which also bounds the work queued on the workers
+		if err := ch.Qos(c.options.Prefetch, 0, false); err != nil {
+			return fmt.Errorf("set prefetch: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	rch, err := conn.Channel()
 	if err != nil {
 		conn.Close()
-		return nil, err
+		return err
 	}
-
-	_, err = ch.QueueDeclare(
-		queueName,
-		true,  // durable
-		false, // auto-delete
-		false, // exclusive
-		false, // no-wait
-		nil,   // arguments
-	)
+	republisher, err := newConfirmChannel(conn, rch)
 	if err != nil {
-		ch.Close()
 		conn.Close()
-		return nil, err
+		return err
 	}
+	c.mu.Lock()
+	c.conn, c.channel, c.republisher = conn, ch, republisher
+	c.mu.Unlock()
+	return nil
+}
 
-	return &TransactionConsumer{
-		conn:               conn,
-		channel:            ch,
-		queueName:          queueName,
-		transactionService: service,
-	}, nil
+func (c *TransactionConsumer) currentChannel() *amqp.Channel {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.channel
 }
 
-func (c *TransactionConsumer) StartConsuming() error {
-	ctx := context.Background()
-	if c.channel == nil {
-		return amqp.ErrClosed
+func (c *TransactionConsumer) currentRepublisher() *confirmChannel {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.republisher
+}
+
+// Ping reports an error while the consumer is not subscribed to the queue
+func (c *TransactionConsumer) Ping(ctx context.Context) error {
+	if !c.connected.Load() {
+		return errors.New("consumer is disconnected from rabbitmq")
 	}
-	if c.conn == nil {
-		return amqp.ErrClosed
+	return nil
+}
+
+func (c *TransactionConsumer) setConnected(connected bool) {
+	c.connected.Store(connected)
+	c.Metrics.SetBrokerConnected(systemRabbitMQ, clientConsumer, connected)
+}
+
+// StartConsuming subscribes to the queue and processes deliveries in the
+// background, resubscribing whenever the connection is lost
+func (c *TransactionConsumer) StartConsuming() error {
+	slog.Info("starting consumer", "queue", c.queueName, "workers", c.options.Workers, "prefetch", c.options.Prefetch)
+	msgs, closed, err := c.subscribe()
+	if err != nil {
+		return err
 	}
-	// Start consuming messages from the queue
-	log.Printf("Starting consumer for queue: %s", c.queueName)
+	go c.run(msgs, closed)
+	slog.Info("consumer started, waiting for messages", "queue", c.queueName)
+	return nil
+}
 
-	msgs, err := c.channel.Consume(
+// subscribe starts consuming on the current channel. closed receives the
+// error that closes the channel, if it is closed abnormally.
+func (c *TransactionConsumer) subscribe() (<-chan amqp.Delivery, <-chan *amqp.Error, error) {
+	ch := c.currentChannel()
+	if ch == nil {
+		return nil, nil, amqp.ErrClosed
+	}
+	// Registered before consuming: the channel reports its error before it
+	// closes the delivery channel
+	closed := ch.NotifyClose(make(chan *amqp.Error, 1))
+	msgs, err := ch.Consume(
 		c.queueName,
-		"",
-		true,  // auto-ack
+		consumerTag,
+		false, // auto-ack: deliveries are acked once handled, so a crash redelivers them
 		false, // exclusive
 		false,
 		false,
 		nil,
 	)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
+	c.setConnected(true)
+	return msgs, closed, nil
+}
 
-	go func() {
-		for d := range msgs {
-			var msg domain.Transaction
-			if err := json.Unmarshal(d.Body, &msg); err != nil {
-				log.Printf("Invalid transaction message: %v", err)
-				continue
-			}
+// run processes deliveries until the subscription ends, then resubscribes
+// unless the consumer is shutting down
+func (c *TransactionConsumer) run(msgs <-chan amqp.Delivery, closed <-chan *amqp.Error) {
+	defer close(c.done)
+	for {
+		dispatch(msgs, c.options.Workers, c.options.Prefetch, deliveryBody, c.handle)
+		c.setConnected(false)
+		select {
+		case <-c.stop:
+			return
+		default:
+		}
 
-			log.Printf("Processing transaction: %+v", msg)
+		select {
+		case err := <-closed:
+			slog.Error("consumer lost its rabbitmq channel, reconnecting", "queue", c.queueName, "error", err)
+		default:
+			// The channel is still open: the broker cancelled the subscription, e.g. because the queue was deleted
+			slog.Error("rabbitmq cancelled the consumer, reconnecting", "queue", c.queueName)
+		}
+		c.Close()
 
-			err := c.transactionService.ProcessTransaction(ctx, &msg)
-			if err != nil {
-				log.Printf("Failed to process transaction: %v", err)
-			} else {
-				log.Printf("Transaction processed successfully")
+		ok := reconnect(c.stop, clientConsumer, c.Metrics, func() error {
+			if err := c.connect(); err != nil {
+				return err
+			}
+			var err error
+			if msgs, closed, err = c.subscribe(); err != nil {
+				c.Close()
 			}
+			return err
+		})
+		if !ok {
+			return
 		}
-	}()
+	}
+}
 
-	log.Printf("Consumer started. Waiting for messages...")
-	return nil
+// handle processes one delivery inside a consumer span that continues the
+// publisher's trace. The delivery is acked once it is settled: processed,
+// failed for good, or scheduled for a retry or dead-lettered and the broker
+// has confirmed the copy. If it cannot be settled it is requeued.
+func (c *TransactionConsumer) handle(d amqp.Delivery) {
+	ctx := logging.WithCorrelationID(context.Background(), correlationID(d))
+	ctx, span := startProcessSpan(ctx, systemRabbitMQ, c.queueName, amqpHeaders(d.Headers))
+	start := time.Now()
+	lag := queueLag(d, start)
+
+	v := processMessage(ctx, c.transactionService, c.options.Retry, d.Body, attempts(d))
+	switch {
+	case v.DeadLetter != "":
+		c.settle(ctx, d, c.deadLetter(ctx, d, v.Body, v.DeadLetter, v.Err))
+	case v.RetryIn > 0:
+		headers := failureHeaders(d, attempts(d)+1, v.Err)
+		c.settle(ctx, d, c.republish(ctx, d, v.Body, "", retryQueue(c.queueName, v.RetryIn), headers))
+	default:
+		c.settle(ctx, d, nil)
+	}
+	c.Metrics.ObserveConsume(v.Outcome, time.Since(start), lag)
+	tracing.End(span, v.Err)
+}
+
+// deadLetter sends body to the dead-letter exchange with the reason it was rejected
+func (c *TransactionConsumer) deadLetter(ctx context.Context, d amqp.Delivery, body []byte, reason string, cause error) error {
+	headers := failureHeaders(d, attempts(d)+1, cause)
+	headers[DeadLetterReasonHeader] = reason
+	return c.republish(ctx, d, body, DeadLetterExchange(c.queueName), c.queueName, headers)
 }
 
+// republish publishes body to exchange and routing key as a persistent copy
+// of d, and waits for the broker to confirm it, so d is only acked once its
+// copy is safe
+func (c *TransactionConsumer) republish(ctx context.Context, d amqp.Delivery, body []byte, exchange, key string, headers amqp.Table) error {
+	republisher := c.currentRepublisher()
+	if republisher == nil {
+		return ErrUnavailable
+	}
+	return republisher.publish(ctx, exchange, key, amqp.Publishing{
+		ContentType:  d.ContentType,
+		DeliveryMode: amqp.Persistent,
+		Timestamp:    d.Timestamp,
+		Headers:      headers,
+		Body:         body,
+	}, DefaultConfirmTimeout)
+}
+
+// settle acks d, or requeues it when the copy that replaces it could not be published
+func (c *TransactionConsumer) settle(ctx context.Context, d amqp.Delivery, republishErr error) {
+	if republishErr != nil {
+		slog.ErrorContext(ctx, "failed to republish transaction, requeueing", "error", republishErr)
+		if err := d.Nack(false, true); err != nil {
+			slog.ErrorContext(ctx, "failed to requeue transaction", "error", err)
+		}
+		return
+	}
+	if err := d.Ack(false); err != nil {
+		slog.ErrorContext(ctx, "failed to ack transaction", "error", err)
+	}
+}
+
+// deliveryBody is the body of d, which dispatch partitions by
+func deliveryBody(d amqp.Delivery) []byte { return d.Body }
+
+// correlationID returns the ID the publisher attached to d, or a new one for
+// messages queued without it
+func correlationID(d amqp.Delivery) string {
+	if id, ok := d.Headers[CorrelationIDHeader].(string); ok && id != "" {
+		return id
+	}
+	return logging.NewCorrelationID()
+}
+
+// queueLag is how long d waited in the queue, or zero when it carries no publish time
+func queueLag(d amqp.Delivery, now time.Time) time.Duration {
+	ms, ok := d.Headers[PublishedAtHeader].(int64)
+	if !ok {
+		return 0
+	}
+	return now.Sub(time.UnixMilli(ms))
+}
+
+// Shutdown stops taking new deliveries, waits for the ones already received to
+// be processed and closes the connection. Messages still being handled when
+// ctx expires are abandoned.
+func (c *TransactionConsumer) Shutdown(ctx context.Context) error {
+	c.stopOnce.Do(func() { close(c.stop) })
+	var err error
+	if ch := c.currentChannel(); ch != nil {
+		if cancelErr := ch.Cancel(consumerTag, false); cancelErr != nil {
+			// The channel is already gone, so no further deliveries can arrive
+			slog.Warn("failed to cancel consumer", "queue", c.queueName, "error", cancelErr)
+		}
+	}
+	select {
+	case <-c.done:
+		slog.Info("consumer drained", "queue", c.queueName)
+	case <-ctx.Done():
+		err = fmt.Errorf("drain consumer: %w", ctx.Err())
+	}
+	c.Close()
+	return err
+}
+
+// Close closes the current connection. A running consumer reconnects unless
+// it is shut down first.
 func (c *TransactionConsumer) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	if c.channel != nil {
 		c.channel.Close()
+		c.channel = nil
+	}
+	if c.republisher != nil {
+		c.republisher.ch.Close()
+		c.republisher = nil
 	}
 	if c.conn != nil {
 		c.conn.Close()
+		c.conn = nil
 	}
 }
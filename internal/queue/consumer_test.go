@@ -0,0 +1,73 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// startFakeConsumer consumes queue on b with reconnects shortened to milliseconds
+func startFakeConsumer(t *testing.T, b *fakeAMQP, queue string, svc *stubService, opts ConsumerOptions) *TransactionConsumer {
+	t.Helper()
+	backoff := reconnectBackoff
+	reconnectBackoff = RetryPolicy{BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond}
+	t.Cleanup(func() { reconnectBackoff = backoff })
+
+	c, err := StartTransactionConsumer(b.url(), queue, svc, nil, opts)
+	if err != nil {
+		t.Fatalf("start consumer: %v", err)
+	}
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := c.Shutdown(ctx); err != nil {
+			t.Errorf("shutdown: %v", err)
+		}
+	})
+	return c
+}
+
+func TestConsumerResumesAfterLosingTheBroker(t *testing.T) {
+	b := newFakeAMQP(t)
+	svc := &stubService{}
+	c := startFakeConsumer(t, b, "transactions", svc, ConsumerOptions{})
+
+	b.publish("transactions", []byte(`{"id":"tx-1","from_account_id":1,"to_account_id":2,"amount":10}`))
+	eventually(t, func() bool { return svc.processedCount() == 1 }, "the first transfer")
+	if err := c.Ping(context.Background()); err != nil {
+		t.Errorf("ping while connected: %v", err)
+	}
+
+	b.drop()
+	eventually(t, func() bool { return c.Ping(context.Background()) != nil }, "ping to report the outage")
+	// Transfers published during the outage wait in the queue
+	b.publish("transactions", []byte(`{"id":"tx-2","from_account_id":1,"to_account_id":2,"amount":10}`))
+	time.Sleep(20 * time.Millisecond)
+	if err := c.Ping(context.Background()); err == nil {
+		t.Error("ping succeeded while the broker refuses connections")
+	}
+
+	b.restore()
+	eventually(t, func() bool { return c.Ping(context.Background()) == nil }, "the consumer to reconnect")
+	eventually(t, func() bool { return svc.processedCount() == 2 }, "the transfer queued during the outage")
+	eventually(t, func() bool { acked, _ := b.settled(); return acked == 2 }, "both deliveries to be acked")
+}
+
+func TestConsumerAcksRetriesOnlyOnceConfirmed(t *testing.T) {
+	b := newFakeAMQP(t)
+	svc := &stubService{fail: []error{errors.New("database unavailable")}}
+	startFakeConsumer(t, b, "transactions", svc, fastRetry)
+	retries := retryQueue("transactions", fastRetry.Retry.Delay(1))
+
+	b.hold()
+	b.publish("transactions", []byte(`{"id":"tx-1","from_account_id":1,"to_account_id":2,"amount":10}`))
+	eventually(t, func() bool { return len(b.ready(retries)) == 1 }, "the retry to be published")
+	time.Sleep(20 * time.Millisecond)
+	if acked, _ := b.settled(); acked != 0 {
+		t.Fatalf("the delivery was acked before its retry was confirmed")
+	}
+
+	b.releaseConfirms()
+	eventually(t, func() bool { acked, _ := b.settled(); return acked == 1 }, "the delivery to be acked")
+}
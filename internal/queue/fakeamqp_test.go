@@ -0,0 +1,510 @@
+package queue
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"sync"
+	"testing"
+)
+
+// fakeAMQP is an in-process stand-in for RabbitMQ. It speaks as much AMQP
+// 0-9-1 as the consumer and publisher use: queues, direct bindings, consuming
+// with acks and nacks, mandatory publishes and publisher confirms. drop cuts
+// every connection and refuses new ones until restore, like a broker restart.
+type fakeAMQP struct {
+	t  *testing.T
+	ln net.Listener
+
+	mu        sync.Mutex
+	down      bool
+	conns     map[*fakeConn]bool
+	queues    map[string][]fakeMessage
+	bindings  map[string]string // exchange and routing key to queue
+	consumers map[string]*fakeConsumer
+	acked     int
+	nacked    int
+	// holdConfirms, while set, keeps publisher confirms back until releaseConfirms
+	holdConfirms bool
+	held         []func()
+}
+
+type fakeMessage struct {
+	header []byte // the content header payload as published
+	body   []byte
+}
+
+type fakeConsumer struct {
+	conn    *fakeConn
+	channel uint16
+	tag     string
+}
+
+type fakeConn struct {
+	net.Conn
+	writeMu  sync.Mutex
+	channels map[uint16]*fakeChannel
+}
+
+type fakeChannel struct {
+	confirm   bool
+	published uint64
+	delivered uint64
+	unacked   map[uint64]fakeDelivery
+	// publishing is the message whose content frames are being received
+	publishing *fakePublish
+}
+
+type fakeDelivery struct {
+	queue string
+	msg   fakeMessage
+}
+
+type fakePublish struct {
+	exchange, key string
+	mandatory     bool
+	size          uint64
+	msg           fakeMessage
+}
+
+// AMQP 0-9-1 frame types
+const (
+	frameMethodType    = 1
+	frameHeaderType    = 2
+	frameBodyType      = 3
+	frameHeartbeatType = 8
+	frameEndOctet      = 0xCE
+)
+
+func newFakeAMQP(t *testing.T) *fakeAMQP {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := &fakeAMQP{
+		t:         t,
+		ln:        ln,
+		conns:     make(map[*fakeConn]bool),
+		queues:    make(map[string][]fakeMessage),
+		bindings:  make(map[string]string),
+		consumers: make(map[string]*fakeConsumer),
+	}
+	go b.accept()
+	t.Cleanup(func() {
+		ln.Close()
+		b.drop()
+	})
+	return b
+}
+
+func (b *fakeAMQP) url() string {
+	return "amqp://guest:guest@" + b.ln.Addr().String() + "/"
+}
+
+func (b *fakeAMQP) accept() {
+	for {
+		nc, err := b.ln.Accept()
+		if err != nil {
+			return
+		}
+		c := &fakeConn{Conn: nc, channels: make(map[uint16]*fakeChannel)}
+		b.mu.Lock()
+		if b.down {
+			nc.Close()
+			b.mu.Unlock()
+			continue
+		}
+		b.conns[c] = true
+		b.mu.Unlock()
+		go b.serve(c)
+	}
+}
+
+// drop closes every connection and refuses new ones until restore
+func (b *fakeAMQP) drop() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.down = true
+	for c := range b.conns {
+		c.Close()
+	}
+}
+
+func (b *fakeAMQP) restore() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.down = false
+}
+
+// publish queues body on queue as if a producer had published it
+func (b *fakeAMQP) publish(queue string, body []byte) {
+	var h amqpWriter
+	h.short(60) // basic
+	h.short(0)
+	h.longlong(uint64(len(body)))
+	h.short(0) // no properties
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.queues[queue] = append(b.queues[queue], fakeMessage{header: h.Bytes(), body: body})
+	b.dispatch()
+}
+
+// ready returns the bodies of the messages waiting on queue
+func (b *fakeAMQP) ready(queue string) [][]byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var bodies [][]byte
+	for _, m := range b.queues[queue] {
+		bodies = append(bodies, m.body)
+	}
+	return bodies
+}
+
+// settled returns how many deliveries were acked and nacked
+func (b *fakeAMQP) settled() (acked, nacked int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.acked, b.nacked
+}
+
+func (b *fakeAMQP) hold() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.holdConfirms = true
+}
+
+// releaseConfirms sends the confirms held back so far and stops holding them
+func (b *fakeAMQP) releaseConfirms() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.holdConfirms = false
+	for _, send := range b.held {
+		send()
+	}
+	b.held = nil
+}
+
+// serve runs one client connection until it closes
+func (b *fakeAMQP) serve(c *fakeConn) {
+	defer b.disconnect(c)
+	header := make([]byte, 8)
+	if _, err := io.ReadFull(c, header); err != nil {
+		return
+	}
+	c.method(0, 10, 10, func(w *amqpWriter) { // connection.start
+		w.octet(0)
+		w.octet(9)
+		w.long(0) // no server properties
+		w.longstr("PLAIN")
+		w.longstr("en_US")
+	})
+	for {
+		typ, channel, payload, err := readFakeFrame(c)
+		if err != nil {
+			return
+		}
+		switch typ {
+		case frameHeartbeatType:
+			c.frame(frameHeartbeatType, 0, nil)
+		case frameMethodType:
+			if !b.handleMethod(c, channel, &amqpReader{b: payload}) {
+				return
+			}
+		case frameHeaderType, frameBodyType:
+			b.handleContent(c, channel, typ, payload)
+		}
+	}
+}
+
+// disconnect forgets c, requeueing the messages it had not acked
+func (b *fakeAMQP) disconnect(c *fakeConn) {
+	c.Close()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.conns, c)
+	for id := range c.channels {
+		b.closeChannel(c, id)
+	}
+	b.dispatch()
+}
+
+// closeChannel drops the consumers of a channel and requeues its unacked
+// messages. Callers hold mu.
+func (b *fakeAMQP) closeChannel(c *fakeConn, id uint16) {
+	ch, ok := c.channels[id]
+	if !ok {
+		return
+	}
+	for queue, consumer := range b.consumers {
+		if consumer.conn == c && consumer.channel == id {
+			delete(b.consumers, queue)
+		}
+	}
+	for _, d := range ch.unacked {
+		b.queues[d.queue] = append([]fakeMessage{d.msg}, b.queues[d.queue]...)
+	}
+	delete(c.channels, id)
+}
+
+// handleMethod answers one method frame and reports whether the connection stays open
+func (b *fakeAMQP) handleMethod(c *fakeConn, channel uint16, r *amqpReader) bool {
+	class, method := r.short(), r.short()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	ch := c.channels[channel]
+
+	switch [2]uint16{class, method} {
+	case [2]uint16{10, 11}: // connection.start-ok
+		c.method(0, 10, 30, func(w *amqpWriter) { // connection.tune
+			w.short(0)
+			w.long(131072)
+			w.short(0)
+		})
+	case [2]uint16{10, 31}: // connection.tune-ok
+	case [2]uint16{10, 40}: // connection.open
+		c.method(0, 10, 41, func(w *amqpWriter) { w.shortstr("") })
+	case [2]uint16{10, 50}: // connection.close
+		c.method(0, 10, 51, nil)
+		return false
+	case [2]uint16{10, 51}: // connection.close-ok
+		return false
+	case [2]uint16{20, 10}: // channel.open
+		c.channels[channel] = &fakeChannel{unacked: make(map[uint64]fakeDelivery)}
+		c.method(channel, 20, 11, func(w *amqpWriter) { w.longstr("") })
+	case [2]uint16{20, 40}: // channel.close
+		b.closeChannel(c, channel)
+		c.method(channel, 20, 41, nil)
+		b.dispatch()
+	case [2]uint16{20, 41}: // channel.close-ok
+	case [2]uint16{40, 10}: // exchange.declare
+		c.method(channel, 40, 11, nil)
+	case [2]uint16{50, 10}: // queue.declare
+		r.short()
+		queue := r.shortstr()
+		if _, ok := b.queues[queue]; !ok {
+			b.queues[queue] = nil
+		}
+		c.method(channel, 50, 11, func(w *amqpWriter) {
+			w.shortstr(queue)
+			w.long(uint32(len(b.queues[queue])))
+			w.long(0)
+		})
+	case [2]uint16{50, 20}: // queue.bind
+		r.short()
+		queue, exchange, key := r.shortstr(), r.shortstr(), r.shortstr()
+		b.bindings[exchange+"\x00"+key] = queue
+		c.method(channel, 50, 21, nil)
+	case [2]uint16{60, 10}: // basic.qos
+		c.method(channel, 60, 11, nil)
+	case [2]uint16{60, 20}: // basic.consume
+		r.short()
+		queue, tag := r.shortstr(), r.shortstr()
+		b.consumers[queue] = &fakeConsumer{conn: c, channel: channel, tag: tag}
+		c.method(channel, 60, 21, func(w *amqpWriter) { w.shortstr(tag) })
+		b.dispatch()
+	case [2]uint16{60, 30}: // basic.cancel
+		tag := r.shortstr()
+		for queue, consumer := range b.consumers {
+			if consumer.conn == c && consumer.tag == tag {
+				delete(b.consumers, queue)
+			}
+		}
+		c.method(channel, 60, 31, func(w *amqpWriter) { w.shortstr(tag) })
+	case [2]uint16{60, 40}: // basic.publish
+		r.short()
+		exchange, key := r.shortstr(), r.shortstr()
+		ch.publishing = &fakePublish{exchange: exchange, key: key, mandatory: r.octet()&1 != 0}
+	case [2]uint16{60, 80}: // basic.ack
+		delete(ch.unacked, r.longlong())
+		b.acked++
+	case [2]uint16{60, 120}: // basic.nack
+		tag := r.longlong()
+		requeue := r.octet()&2 != 0
+		b.nacked++
+		if d, ok := ch.unacked[tag]; ok && requeue {
+			b.queues[d.queue] = append([]fakeMessage{d.msg}, b.queues[d.queue]...)
+		}
+		delete(ch.unacked, tag)
+		b.dispatch()
+	case [2]uint16{85, 10}: // confirm.select
+		ch.confirm = true
+		c.method(channel, 85, 11, nil)
+	default:
+		b.t.Logf("fake amqp: unexpected method %d.%d", class, method)
+	}
+	return true
+}
+
+// handleContent collects the header and body of a published message and routes it once complete
+func (b *fakeAMQP) handleContent(c *fakeConn, channel uint16, typ byte, payload []byte) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	ch := c.channels[channel]
+	p := ch.publishing
+	if p == nil {
+		return
+	}
+	if typ == frameHeaderType {
+		p.msg.header = payload
+		p.size = binary.BigEndian.Uint64(payload[4:12])
+	} else {
+		p.msg.body = append(p.msg.body, payload...)
+	}
+	if p.msg.header == nil || uint64(len(p.msg.body)) < p.size {
+		return
+	}
+	ch.publishing = nil
+	b.route(c, channel, ch, p)
+}
+
+// route queues a published message, returns it when it is mandatory and
+// unroutable, and confirms it on channels in confirm mode. Callers hold mu.
+func (b *fakeAMQP) route(c *fakeConn, channel uint16, ch *fakeChannel, p *fakePublish) {
+	queue, ok := p.key, p.exchange == ""
+	if ok {
+		_, ok = b.queues[queue]
+	} else {
+		queue, ok = b.bindings[p.exchange+"\x00"+p.key]
+	}
+	if ok {
+		b.queues[queue] = append(b.queues[queue], p.msg)
+	} else if p.mandatory {
+		c.method(channel, 60, 50, func(w *amqpWriter) { // basic.return
+			w.short(312)
+			w.shortstr("NO_ROUTE")
+			w.shortstr(p.exchange)
+			w.shortstr(p.key)
+		})
+		c.content(channel, p.msg)
+	}
+	if ch.confirm {
+		ch.published++
+		tag := ch.published
+		confirm := func() {
+			c.method(channel, 60, 80, func(w *amqpWriter) { // basic.ack
+				w.longlong(tag)
+				w.octet(0)
+			})
+		}
+		if b.holdConfirms {
+			b.held = append(b.held, confirm)
+		} else {
+			confirm()
+		}
+	}
+	b.dispatch()
+}
+
+// dispatch delivers waiting messages to their queue's consumer. Callers hold mu.
+func (b *fakeAMQP) dispatch() {
+	for queue, consumer := range b.consumers {
+		ch, ok := consumer.conn.channels[consumer.channel]
+		if !ok {
+			continue
+		}
+		for len(b.queues[queue]) > 0 {
+			msg := b.queues[queue][0]
+			b.queues[queue] = b.queues[queue][1:]
+			ch.delivered++
+			ch.unacked[ch.delivered] = fakeDelivery{queue: queue, msg: msg}
+			tag := ch.delivered
+			consumer.conn.method(consumer.channel, 60, 60, func(w *amqpWriter) { // basic.deliver
+				w.shortstr(consumer.tag)
+				w.longlong(tag)
+				w.octet(0)
+				w.shortstr("")
+				w.shortstr(queue)
+			})
+			consumer.conn.content(consumer.channel, msg)
+		}
+	}
+}
+
+func (c *fakeConn) method(channel uint16, class, method uint16, args func(w *amqpWriter)) {
+	var w amqpWriter
+	w.short(class)
+	w.short(method)
+	if args != nil {
+		args(&w)
+	}
+	c.frame(frameMethodType, channel, w.Bytes())
+}
+
+func (c *fakeConn) content(channel uint16, msg fakeMessage) {
+	c.frame(frameHeaderType, channel, msg.header)
+	if len(msg.body) > 0 {
+		c.frame(frameBodyType, channel, msg.body)
+	}
+}
+
+// frame writes one frame; errors surface as the connection closing
+func (c *fakeConn) frame(typ byte, channel uint16, payload []byte) {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	var w amqpWriter
+	w.octet(typ)
+	w.short(channel)
+	w.long(uint32(len(payload)))
+	w.Write(payload)
+	w.octet(frameEndOctet)
+	c.Write(w.Bytes())
+}
+
+func readFakeFrame(r io.Reader) (typ byte, channel uint16, payload []byte, err error) {
+	head := make([]byte, 7)
+	if _, err = io.ReadFull(r, head); err != nil {
+		return
+	}
+	payload = make([]byte, binary.BigEndian.Uint32(head[3:7])+1)
+	if _, err = io.ReadFull(r, payload); err != nil {
+		return
+	}
+	return head[0], binary.BigEndian.Uint16(head[1:3]), payload[:len(payload)-1], nil
+}
+
+// amqpWriter encodes AMQP field types
+type amqpWriter struct{ bytes.Buffer }
+
+func (w *amqpWriter) octet(v byte)      { w.WriteByte(v) }
+func (w *amqpWriter) short(v uint16)    { binary.Write(w, binary.BigEndian, v) }
+func (w *amqpWriter) long(v uint32)     { binary.Write(w, binary.BigEndian, v) }
+func (w *amqpWriter) longlong(v uint64) { binary.Write(w, binary.BigEndian, v) }
+
+func (w *amqpWriter) shortstr(s string) {
+	w.octet(byte(len(s)))
+	w.WriteString(s)
+}
+
+func (w *amqpWriter) longstr(s string) {
+	w.long(uint32(len(s)))
+	w.WriteString(s)
+}
+
+// amqpReader decodes the leading arguments of a method; the rest is ignored
+type amqpReader struct{ b []byte }
+
+func (r *amqpReader) octet() byte {
+	v := r.b[0]
+	r.b = r.b[1:]
+	return v
+}
+
+func (r *amqpReader) short() uint16 {
+	v := binary.BigEndian.Uint16(r.b)
+	r.b = r.b[2:]
+	return v
+}
+
+func (r *amqpReader) longlong() uint64 {
+	v := binary.BigEndian.Uint64(r.b)
+	r.b = r.b[8:]
+	return v
+}
+
+func (r *amqpReader) shortstr() string {
+	n := int(r.octet())
+	s := string(r.b[:n])
+	r.b = r.b[n:]
+	return s
+}
@@ -0,0 +1,278 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"ledger/internal/domain"
+	"ledger/internal/logging"
+	"ledger/internal/metrics"
+	"ledger/internal/tracing"
+)
+
+// KafkaDeadLetterTopic receives the exhausted and malformed records of topic
+func KafkaDeadLetterTopic(topic string) string {
+	return topic + ".dlq"
+}
+
+// KafkaPublisher produces transactions to a Kafka topic, keyed by source
+// account so each account's transfers land on one partition, in order
+type KafkaPublisher struct {
+	client *kgo.Client
+	topic  string
+	// Metrics, when set, counts published messages and publish errors
+	Metrics *metrics.Metrics
+}
+
+// NewKafkaPublisher connects to brokers. Produced records are acknowledged by
+// every in-sync replica before Publish returns.
+func NewKafkaPublisher(brokers []string, topic string, m *metrics.Metrics) (*KafkaPublisher, error) {
+	client, err := kgo.NewClient(kgo.SeedBrokers(brokers...), kgo.DefaultProduceTopic(topic))
+	if err != nil {
+		return nil, err
+	}
+	return &KafkaPublisher{client: client, topic: topic, Metrics: m}, nil
+}
+
+func (p *KafkaPublisher) Publish(ctx context.Context, msg domain.Transaction) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	record := &kgo.Record{
+		Key:   []byte(strconv.FormatInt(msg.FromAccountID, 10)),
+		Value: body,
+		Headers: []kgo.RecordHeader{
+			{Key: PublishedAtHeader, Value: []byte(strconv.FormatInt(time.Now().UnixMilli(), 10))},
+		},
+	}
+	if id := logging.CorrelationID(ctx); id != "" {
+		record.Headers = append(record.Headers, kgo.RecordHeader{Key: CorrelationIDHeader, Value: []byte(id)})
+	}
+	ctx, span := startPublishSpan(ctx, systemKafka, p.topic, kafkaHeaders{&record.Headers})
+
+	err = p.client.ProduceSync(ctx, record).FirstErr()
+	p.Metrics.ObservePublish(err)
+	tracing.End(span, err)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to publish transaction", "transaction_id", msg.ID, "error", err)
+		return err
+	}
+	slog.InfoContext(ctx, "transaction published", "transaction_id", msg.ID, "topic", p.topic, "partition", record.Partition)
+	return nil
+}
+
+// Ping checks that a broker answers
+func (p *KafkaPublisher) Ping(ctx context.Context) error {
+	return p.client.Ping(ctx)
+}
+
+func (p *KafkaPublisher) Close() {
+	p.client.Close()
+}
+
+// KafkaConsumer applies transactions from a Kafka topic as part of a consumer
+// group. Partitions are processed in parallel and each one in order. A record's
+// offset is committed once it is settled: processed, failed for good or
+// dead-lettered. Transient failures are retried in place, with the backoff of
+// the retry policy, so later records of the partition wait behind them.
+type KafkaConsumer struct {
+	client             *kgo.Client
+	topic              string
+	transactionService domain.TransactionService
+	retry              RetryPolicy
+	// Metrics, when set, records processing outcomes, durations and lag
+	Metrics *metrics.Metrics
+
+	stop context.CancelFunc
+	done chan struct{}
+}
+
+// StartKafkaConsumer joins group and starts consuming topic in the
+// background. Workers and Prefetch of opts do not apply: Kafka parallelism
+// follows the partitions assigned to the consumer.
+func StartKafkaConsumer(brokers []string, topic, group string, service domain.TransactionService, m *metrics.Metrics, opts ConsumerOptions) (*KafkaConsumer, error) {
+	client, err := kgo.NewClient(
+		kgo.SeedBrokers(brokers...),
+		kgo.ConsumerGroup(group),
+		kgo.ConsumeTopics(topic),
+		kgo.ConsumeResetOffset(kgo.NewOffset().AtStart()),
+		kgo.DisableAutoCommit(),
+		// Partitions are only reassigned between batches, once their offsets are committed
+		kgo.BlockRebalanceOnPoll(),
+		kgo.DefaultProduceTopic(KafkaDeadLetterTopic(topic)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &KafkaConsumer{
+		client:             client,
+		topic:              topic,
+		transactionService: service,
+		retry:              opts.withDefaults().Retry,
+		Metrics:            m,
+		stop:               cancel,
+		done:               make(chan struct{}),
+	}
+	go c.run(ctx)
+	slog.Info("kafka consumer started", "topic", topic, "group", group)
+	return c, nil
+}
+
+func (c *KafkaConsumer) run(ctx context.Context) {
+	defer close(c.done)
+	for {
+		fetches := c.client.PollFetches(ctx)
+		if fetches.IsClientClosed() || ctx.Err() != nil {
+			return
+		}
+		fetches.EachError(func(topic string, partition int32, err error) {
+			slog.Error("failed to fetch transactions", "topic", topic, "partition", partition, "error", err)
+		})
+
+		var (
+			mu      sync.Mutex
+			settled []*kgo.Record
+			wg      sync.WaitGroup
+		)
+		fetches.EachPartition(func(p kgo.FetchTopicPartition) {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				last := c.processPartition(ctx, p.Records)
+				if last != nil {
+					mu.Lock()
+					settled = append(settled, last)
+					mu.Unlock()
+				}
+			}()
+		})
+		wg.Wait()
+
+		if len(settled) > 0 {
+			// Not bound to ctx, so what was settled before a shutdown is still committed
+			if err := c.client.CommitRecords(context.Background(), settled...); err != nil {
+				slog.Error("failed to commit offsets", "topic", c.topic, "error", err)
+			}
+		}
+		c.client.AllowRebalance()
+	}
+}
+
+// processPartition settles records in order and returns the last one settled.
+// It stops early when ctx is cancelled, leaving the rest to be consumed again.
+func (c *KafkaConsumer) processPartition(ctx context.Context, records []*kgo.Record) *kgo.Record {
+	var last *kgo.Record
+	for _, r := range records {
+		if ctx.Err() != nil || !c.settle(ctx, r) {
+			break
+		}
+		last = r
+	}
+	return last
+}
+
+// settle processes r, retrying transient failures in place, and reports
+// whether r is done with
+func (c *KafkaConsumer) settle(ctx context.Context, r *kgo.Record) bool {
+	for attempts := 0; ; attempts++ {
+		v := c.handle(r, attempts)
+		switch {
+		case v.DeadLetter != "":
+			return c.deadLetter(ctx, r, v, attempts+1)
+		case v.RetryIn > 0:
+			select {
+			case <-ctx.Done():
+				return false
+			case <-time.After(v.RetryIn):
+			}
+			// Retry with the transaction ID assigned by the first attempt
+			r = &kgo.Record{Topic: r.Topic, Partition: r.Partition, Offset: r.Offset, LeaderEpoch: r.LeaderEpoch,
+				Key: r.Key, Value: v.Body, Headers: r.Headers, Timestamp: r.Timestamp}
+		default:
+			return true
+		}
+	}
+}
+
+func (c *KafkaConsumer) handle(r *kgo.Record, attempts int) verdict {
+	headers := r.Headers
+	carrier := kafkaHeaders{&headers}
+	correlationID := carrier.Get(CorrelationIDHeader)
+	if correlationID == "" {
+		correlationID = logging.NewCorrelationID()
+	}
+	ctx := logging.WithCorrelationID(context.Background(), correlationID)
+	ctx, span := startProcessSpan(ctx, systemKafka, c.topic, carrier)
+	start := time.Now()
+	var lag time.Duration
+	if ms, err := strconv.ParseInt(carrier.Get(PublishedAtHeader), 10, 64); err == nil && attempts == 0 {
+		lag = start.Sub(time.UnixMilli(ms))
+	}
+
+	v := processMessage(ctx, c.transactionService, c.retry, r.Value, attempts)
+	c.Metrics.ObserveConsume(v.Outcome, time.Since(start), lag)
+	tracing.End(span, v.Err)
+	return v
+}
+
+// deadLetter produces r to the dead-letter topic, retrying until it succeeds
+// or ctx is cancelled, since the offset must not be committed before
+func (c *KafkaConsumer) deadLetter(ctx context.Context, r *kgo.Record, v verdict, attempts int) bool {
+	headers := append([]kgo.RecordHeader(nil), r.Headers...)
+	carrier := kafkaHeaders{&headers}
+	carrier.Set(AttemptHeader, strconv.Itoa(attempts))
+	carrier.Set(LastErrorHeader, v.Err.Error())
+	carrier.Set(DeadLetterReasonHeader, v.DeadLetter)
+	record := &kgo.Record{Key: r.Key, Value: v.Body, Headers: headers}
+
+	for attempt := 1; ; attempt++ {
+		err := c.client.ProduceSync(ctx, record).FirstErr()
+		if err == nil {
+			return true
+		}
+		if errors.Is(err, context.Canceled) {
+			return false
+		}
+		slog.Error("failed to dead-letter transaction", "topic", KafkaDeadLetterTopic(c.topic), "error", err)
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(reconnectBackoff.Delay(attempt)):
+		}
+	}
+}
+
+// Ping checks that a broker answers
+func (c *KafkaConsumer) Ping(ctx context.Context) error {
+	if err := c.client.Ping(ctx); err != nil {
+		return fmt.Errorf("kafka consumer: %w", err)
+	}
+	return nil
+}
+
+// Shutdown stops polling, waits for the records in flight to be settled and
+// committed, and leaves the consumer group. Records still being retried when
+// ctx expires are left uncommitted and consumed again by the group.
+func (c *KafkaConsumer) Shutdown(ctx context.Context) error {
+	c.stop()
+	var err error
+	select {
+	case <-c.done:
+		slog.Info("kafka consumer drained", "topic", c.topic)
+	case <-ctx.Done():
+		err = fmt.Errorf("drain consumer: %w", ctx.Err())
+	}
+	// The last poll blocked rebalancing, which leaving the group needs
+	c.client.CloseAllowingRebalance()
+	return err
+}
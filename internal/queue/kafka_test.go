@@ -0,0 +1,220 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kfake"
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"ledger/internal/domain"
+	"ledger/internal/logging"
+)
+
+func newKafkaCluster(t *testing.T) []string {
+	t.Helper()
+	cluster, err := kfake.NewCluster(kfake.NumBrokers(1), kfake.SeedTopics(4, "tx", KafkaDeadLetterTopic("tx")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(cluster.Close)
+	return cluster.ListenAddrs()
+}
+
+func newKafkaPublisher(t *testing.T, brokers []string) *KafkaPublisher {
+	t.Helper()
+	p, err := NewKafkaPublisher(brokers, "tx", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(p.Close)
+	return p
+}
+
+func startKafkaConsumer(t *testing.T, brokers []string, svc domain.TransactionService) *KafkaConsumer {
+	t.Helper()
+	c, err := StartKafkaConsumer(brokers, "tx", "ledger-test", svc, nil, fastRetry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// readTopic returns every record of topic, waiting until there are want of them
+func readTopic(t *testing.T, brokers []string, topic string, want int) []*kgo.Record {
+	t.Helper()
+	client, err := kgo.NewClient(kgo.SeedBrokers(brokers...), kgo.ConsumeTopics(topic),
+		kgo.ConsumeResetOffset(kgo.NewOffset().AtStart()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var records []*kgo.Record
+	for len(records) < want {
+		fetches := client.PollFetches(ctx)
+		if ctx.Err() != nil {
+			t.Fatalf("read %d records from %s, want %d", len(records), topic, want)
+		}
+		records = append(records, fetches.Records()...)
+	}
+	return records
+}
+
+func header(r *kgo.Record, key string) string {
+	for _, h := range r.Headers {
+		if h.Key == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+func TestKafkaPublisherKeysBySourceAccount(t *testing.T) {
+	brokers := newKafkaCluster(t)
+	p := newKafkaPublisher(t, brokers)
+
+	ctx := logging.WithCorrelationID(context.Background(), "req-1")
+	for _, from := range []int64{7, 8, 7, 9, 7} {
+		if err := p.Publish(ctx, domain.Transaction{FromAccountID: from, ToAccountID: 1, Amount: 1}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	partitions := map[string]map[int32]bool{}
+	for _, r := range readTopic(t, brokers, "tx", 5) {
+		if partitions[string(r.Key)] == nil {
+			partitions[string(r.Key)] = map[int32]bool{}
+		}
+		partitions[string(r.Key)][r.Partition] = true
+		if id := header(r, CorrelationIDHeader); id != "req-1" {
+			t.Errorf("correlation ID header = %q, want req-1", id)
+		}
+	}
+	if len(partitions["7"]) != 1 {
+		t.Errorf("transfers from one account were spread over partitions %v", partitions["7"])
+	}
+}
+
+func TestKafkaConsumerCommitsProcessedRecords(t *testing.T) {
+	brokers := newKafkaCluster(t)
+	p := newKafkaPublisher(t, brokers)
+
+	first := &stubService{}
+	c := startKafkaConsumer(t, brokers, first)
+	p.Publish(context.Background(), domain.Transaction{FromAccountID: 1, ToAccountID: 2, Amount: 5})
+	eventually(t, func() bool { return first.processedCount() == 1 }, "the transfer to be processed")
+	if err := c.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// A new member of the group resumes after the committed offset
+	second := &stubService{}
+	c = startKafkaConsumer(t, brokers, second)
+	defer c.Shutdown(context.Background())
+	p.Publish(context.Background(), domain.Transaction{FromAccountID: 1, ToAccountID: 2, Amount: 6})
+	eventually(t, func() bool { return second.processedCount() == 1 }, "the second transfer to be processed")
+	time.Sleep(50 * time.Millisecond)
+	if n := second.processedCount(); n != 1 {
+		t.Errorf("committed transfers were processed again: %d processed", n)
+	}
+}
+
+// holdFirst holds the first transfer until release is closed
+type holdFirst struct {
+	*stubService
+	once    sync.Once
+	started chan struct{}
+	release chan struct{}
+}
+
+func (h *holdFirst) ProcessTransaction(ctx context.Context, tx *domain.Transaction) error {
+	h.once.Do(func() {
+		close(h.started)
+		<-h.release
+	})
+	return h.stubService.ProcessTransaction(ctx, tx)
+}
+
+func TestKafkaConsumerShutdownLeavesTheRestOfTheBatch(t *testing.T) {
+	brokers := newKafkaCluster(t)
+	p := newKafkaPublisher(t, brokers)
+	for amount := 1.0; amount <= 5; amount++ {
+		p.Publish(context.Background(), domain.Transaction{FromAccountID: 4, ToAccountID: 2, Amount: amount})
+	}
+
+	first := &holdFirst{stubService: &stubService{}, started: make(chan struct{}), release: make(chan struct{})}
+	c := startKafkaConsumer(t, brokers, first)
+	<-first.started
+	shutdownErr := make(chan error, 1)
+	go func() { shutdownErr <- c.Shutdown(context.Background()) }()
+	time.Sleep(20 * time.Millisecond)
+	close(first.release)
+	if err := <-shutdownErr; err != nil {
+		t.Fatal(err)
+	}
+	if n := first.processedCount(); n != 1 {
+		t.Fatalf("processed %d transfers after shutdown began, want only the one in flight", n)
+	}
+
+	// The rest of the batch was left uncommitted for the group
+	second := &stubService{}
+	c = startKafkaConsumer(t, brokers, second)
+	defer c.Shutdown(context.Background())
+	eventually(t, func() bool { return second.processedCount() == 4 }, "the rest of the batch to be processed")
+	time.Sleep(50 * time.Millisecond)
+	second.mu.Lock()
+	defer second.mu.Unlock()
+	for i, tx := range second.processed {
+		if tx.Amount != float64(i+2) {
+			t.Fatalf("the rest of the batch was not processed once and in order: %v", second.processed)
+		}
+	}
+}
+
+func TestKafkaConsumerRetriesInOrder(t *testing.T) {
+	brokers := newKafkaCluster(t)
+	p := newKafkaPublisher(t, brokers)
+	for amount := 1.0; amount <= 3; amount++ {
+		p.Publish(context.Background(), domain.Transaction{FromAccountID: 4, ToAccountID: 2, Amount: amount})
+	}
+
+	svc := &stubService{fail: []error{errors.New("connection reset")}}
+	c := startKafkaConsumer(t, brokers, svc)
+	defer c.Shutdown(context.Background())
+	eventually(t, func() bool { return svc.processedCount() == 3 }, "all transfers to be processed")
+
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+	for i, tx := range svc.processed {
+		if tx.Amount != float64(i+1) {
+			t.Fatalf("transfers of one account were reordered: %v", svc.processed)
+		}
+	}
+}
+
+func TestKafkaConsumerDeadLettersExhaustedRecords(t *testing.T) {
+	brokers := newKafkaCluster(t)
+	p := newKafkaPublisher(t, brokers)
+	p.Publish(context.Background(), domain.Transaction{FromAccountID: 1, ToAccountID: 2, Amount: 5})
+
+	transient := errors.New("connection reset")
+	svc := &stubService{fail: []error{transient, transient, transient}}
+	c := startKafkaConsumer(t, brokers, svc)
+	defer c.Shutdown(context.Background())
+
+	dead := readTopic(t, brokers, KafkaDeadLetterTopic("tx"), 1)[0]
+	if reason := header(dead, DeadLetterReasonHeader); reason != ReasonExhausted {
+		t.Errorf("dead-letter reason = %q, want %q", reason, ReasonExhausted)
+	}
+	var tx domain.Transaction
+	if err := json.Unmarshal(dead.Value, &tx); err != nil || tx.ID != "assigned" {
+		t.Errorf("dead letter should carry the assigned transaction ID, got %s", dead.Value)
+	}
+}
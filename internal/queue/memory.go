@@ -0,0 +1,330 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"ledger/internal/domain"
+	"ledger/internal/logging"
+	"ledger/internal/metrics"
+	"ledger/internal/tracing"
+)
+
+// MemoryBroker is an in-process broker for tests and local runs. Its queues
+// behave like the RabbitMQ ones: a message stays queued until a consumer acks
+// it, messages a consumer took but had not started on when it shut down are
+// redelivered, and failed transfers are retried and dead-lettered with the
+// consumer's RetryPolicy. Nothing survives a restart.
+type MemoryBroker struct {
+	mu     sync.Mutex
+	queues map[string]*memoryQueue
+}
+
+// Message is a message held by a MemoryBroker
+type Message struct {
+	Body    []byte
+	Headers map[string]any
+	// Attempts is how often processing the message has failed
+	Attempts int
+	// Redelivered is set once the message has been handed to a consumer that did not ack it
+	Redelivered bool
+}
+
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{queues: make(map[string]*memoryQueue)}
+}
+
+func (b *MemoryBroker) queue(name string) *memoryQueue {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	q, ok := b.queues[name]
+	if !ok {
+		q = &memoryQueue{arrived: make(chan struct{})}
+		b.queues[name] = q
+	}
+	return q
+}
+
+// Publisher returns a publisher for queueName. m, when set, counts published messages.
+func (b *MemoryBroker) Publisher(queueName string, m *metrics.Metrics) *MemoryPublisher {
+	return &MemoryPublisher{queue: b.queue(queueName), queueName: queueName, Metrics: m}
+}
+
+// StartConsumer starts applying the transactions queued on queueName
+func (b *MemoryBroker) StartConsumer(queueName string, service domain.TransactionService, m *metrics.Metrics, opts ConsumerOptions) *MemoryConsumer {
+	c := &MemoryConsumer{
+		queue:              b.queue(queueName),
+		queueName:          queueName,
+		transactionService: service,
+		options:            opts.withDefaults(),
+		Metrics:            m,
+		stop:               make(chan struct{}),
+		done:               make(chan struct{}),
+	}
+	c.inflight = make(chan struct{}, c.options.Prefetch)
+	go c.run()
+	return c
+}
+
+// Depth is the number of messages waiting on queueName, excluding those being processed
+func (b *MemoryBroker) Depth(queueName string) int {
+	q := b.queue(queueName)
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.ready)
+}
+
+// DeadLetters returns the messages dead-lettered from queueName, oldest first
+func (b *MemoryBroker) DeadLetters(queueName string) []Message {
+	q := b.queue(queueName)
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return append([]Message(nil), q.dead...)
+}
+
+type memoryQueue struct {
+	mu    sync.Mutex
+	ready []*Message
+	dead  []Message
+	// arrived is closed, and replaced, whenever a message becomes ready
+	arrived chan struct{}
+}
+
+// push queues m at the tail
+func (q *memoryQueue) push(m *Message) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.ready = append(q.ready, m)
+	close(q.arrived)
+	q.arrived = make(chan struct{})
+}
+
+// requeue puts ms back at the head, in the order they were taken
+func (q *memoryQueue) requeue(ms []*Message) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.ready = append(ms, q.ready...)
+	close(q.arrived)
+	q.arrived = make(chan struct{})
+}
+
+// take waits for the next message, returning false once stop is closed
+func (q *memoryQueue) take(stop <-chan struct{}) (*Message, bool) {
+	for {
+		q.mu.Lock()
+		if len(q.ready) > 0 {
+			m := q.ready[0]
+			q.ready = q.ready[1:]
+			q.mu.Unlock()
+			return m, true
+		}
+		arrived := q.arrived
+		q.mu.Unlock()
+
+		select {
+		case <-arrived:
+		case <-stop:
+			return nil, false
+		}
+	}
+}
+
+func (q *memoryQueue) deadLetter(m Message) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.dead = append(q.dead, m)
+}
+
+// MemoryPublisher queues transactions on a MemoryBroker
+type MemoryPublisher struct {
+	queue     *memoryQueue
+	queueName string
+	// Metrics, when set, counts published messages
+	Metrics *metrics.Metrics
+}
+
+func (p *MemoryPublisher) Publish(ctx context.Context, msg domain.Transaction) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	headers := map[string]any{PublishedAtHeader: time.Now().UnixMilli()}
+	if id := logging.CorrelationID(ctx); id != "" {
+		headers[CorrelationIDHeader] = id
+	}
+	ctx, span := startPublishSpan(ctx, systemMemory, p.queueName, amqpHeaders(headers))
+	p.queue.push(&Message{Body: body, Headers: headers})
+	p.Metrics.ObservePublish(nil)
+	tracing.End(span, nil)
+	slog.InfoContext(ctx, "transaction published", "transaction_id", msg.ID, "queue", p.queueName)
+	return nil
+}
+
+// Ping always succeeds; the broker lives in the same process
+func (p *MemoryPublisher) Ping(ctx context.Context) error { return nil }
+
+func (p *MemoryPublisher) Close() {}
+
+// MemoryConsumer applies the transactions queued on a MemoryBroker, with the
+// same worker pool and retry handling as TransactionConsumer
+type MemoryConsumer struct {
+	queue              *memoryQueue
+	queueName          string
+	transactionService domain.TransactionService
+	options            ConsumerOptions
+	// Metrics, when set, records processing outcomes, durations and queue lag
+	Metrics *metrics.Metrics
+
+	mu sync.Mutex
+	// unacked holds the messages taken from the queue, in the order they were taken
+	unacked []*unackedMessage
+	// inflight holds a slot per unacknowledged message, bounding them to the prefetch
+	inflight chan struct{}
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+type unackedMessage struct {
+	msg *Message
+	// started is set once a worker begins handling msg
+	started bool
+}
+
+// unackedIndex returns the position of m in unacked, or -1. Callers hold mu.
+func (c *MemoryConsumer) unackedIndex(m *Message) int {
+	return slices.IndexFunc(c.unacked, func(u *unackedMessage) bool { return u.msg == m })
+}
+
+func (c *MemoryConsumer) run() {
+	msgs := make(chan *Message)
+	drained := make(chan struct{})
+	go func() {
+		dispatch(msgs, c.options.Workers, c.options.Prefetch, messageBody, c.handle)
+		close(drained)
+	}()
+	defer func() {
+		close(msgs)
+		<-drained
+		close(c.done)
+	}()
+
+	for {
+		select {
+		case c.inflight <- struct{}{}:
+		case <-c.stop:
+			return
+		}
+		m, ok := c.queue.take(c.stop)
+		if !ok {
+			return
+		}
+		c.mu.Lock()
+		c.unacked = append(c.unacked, &unackedMessage{msg: m})
+		c.mu.Unlock()
+		msgs <- m
+	}
+}
+
+func (c *MemoryConsumer) handle(m *Message) {
+	c.mu.Lock()
+	i := c.unackedIndex(m)
+	if i >= 0 {
+		c.unacked[i].started = true
+	}
+	c.mu.Unlock()
+	if i < 0 {
+		// Shutdown gave it back to the queue before a worker got to it
+		return
+	}
+
+	ctx := logging.WithCorrelationID(context.Background(), messageCorrelationID(m))
+	ctx, span := startProcessSpan(ctx, systemMemory, c.queueName, amqpHeaders(m.Headers))
+	start := time.Now()
+	var lag time.Duration
+	if ms, ok := m.Headers[PublishedAtHeader].(int64); ok {
+		lag = start.Sub(time.UnixMilli(ms))
+	}
+
+	v := processMessage(ctx, c.transactionService, c.options.Retry, m.Body, m.Attempts)
+	if v.DeadLetter != "" || v.RetryIn > 0 {
+		next := Message{Body: v.Body, Headers: make(map[string]any, len(m.Headers)+2), Attempts: m.Attempts + 1}
+		for k, h := range m.Headers {
+			next.Headers[k] = h
+		}
+		next.Headers[LastErrorHeader] = v.Err.Error()
+		if v.DeadLetter != "" {
+			next.Headers[DeadLetterReasonHeader] = v.DeadLetter
+			c.queue.deadLetter(next)
+		} else {
+			time.AfterFunc(v.RetryIn, func() { c.queue.push(&next) })
+		}
+	}
+	c.ack(m)
+	c.Metrics.ObserveConsume(v.Outcome, time.Since(start), lag)
+	tracing.End(span, v.Err)
+}
+
+// ack settles m, freeing its prefetch slot
+func (c *MemoryConsumer) ack(m *Message) {
+	c.mu.Lock()
+	if i := c.unackedIndex(m); i >= 0 {
+		c.unacked = slices.Delete(c.unacked, i, i+1)
+	}
+	c.mu.Unlock()
+	<-c.inflight
+}
+
+// Ping reports an error once the consumer has been shut down
+func (c *MemoryConsumer) Ping(ctx context.Context) error {
+	select {
+	case <-c.stop:
+		return ErrUnavailable
+	default:
+		return nil
+	}
+}
+
+// Shutdown stops taking messages and waits for those in flight. When ctx
+// expires, messages no worker has started on go back to the queue to be
+// redelivered; those being handled are left to finish and ack, so a transfer
+// is never applied by two consumers at once.
+func (c *MemoryConsumer) Shutdown(ctx context.Context) error {
+	c.stopOnce.Do(func() { close(c.stop) })
+	select {
+	case <-c.done:
+		return nil
+	case <-ctx.Done():
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// Keep the order they were taken in, so transfers from one account stay in sequence
+	var redeliver []*Message
+	c.unacked = slices.DeleteFunc(c.unacked, func(u *unackedMessage) bool {
+		if u.started {
+			return false
+		}
+		u.msg.Redelivered = true
+		redeliver = append(redeliver, u.msg)
+		return true
+	})
+	c.queue.requeue(redeliver)
+	return ctx.Err()
+}
+
+func messageBody(m *Message) []byte { return m.Body }
+
+func messageCorrelationID(m *Message) string {
+	if id, ok := m.Headers[CorrelationIDHeader].(string); ok && id != "" {
+		return id
+	}
+	return logging.NewCorrelationID()
+}
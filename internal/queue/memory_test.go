@@ -0,0 +1,179 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"ledger/internal/domain"
+)
+
+// stubService fails each transfer with the errors in fail, in order, then succeeds
+type stubService struct {
+	domain.TransactionService
+	mu        sync.Mutex
+	fail      []error
+	processed []domain.Transaction
+	// block, when set, holds every transfer until it is closed
+	block chan struct{}
+}
+
+func (s *stubService) ProcessTransaction(ctx context.Context, tx *domain.Transaction) error {
+	if s.block != nil {
+		<-s.block
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if tx.ID == "" {
+		tx.ID = "assigned"
+	}
+	if len(s.fail) > 0 {
+		err := s.fail[0]
+		s.fail = s.fail[1:]
+		return err
+	}
+	s.processed = append(s.processed, *tx)
+	return nil
+}
+
+func (s *stubService) failuresLeft() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.fail)
+}
+
+func (s *stubService) processedCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.processed)
+}
+
+var fastRetry = ConsumerOptions{Retry: RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}}
+
+func eventually(t *testing.T, cond func() bool, what string) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func shutdown(t *testing.T, c *MemoryConsumer) {
+	t.Helper()
+	if err := c.Shutdown(context.Background()); err != nil {
+		t.Errorf("shutdown: %v", err)
+	}
+}
+
+func TestMemoryBrokerDeliversPublishedTransactions(t *testing.T) {
+	broker := NewMemoryBroker()
+	svc := &stubService{}
+	consumer := broker.StartConsumer("tx", svc, nil, fastRetry)
+	defer shutdown(t, consumer)
+
+	if err := broker.Publisher("tx", nil).Publish(context.Background(), domain.Transaction{FromAccountID: 1, ToAccountID: 2, Amount: 5}); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, func() bool { return svc.processedCount() == 1 }, "the transfer to be processed")
+	if broker.Depth("tx") != 0 || len(broker.DeadLetters("tx")) != 0 {
+		t.Error("a processed message was left behind")
+	}
+}
+
+func TestMemoryBrokerRetriesTransientFailures(t *testing.T) {
+	broker := NewMemoryBroker()
+	svc := &stubService{fail: []error{errors.New("connection reset")}}
+	consumer := broker.StartConsumer("tx", svc, nil, fastRetry)
+	defer shutdown(t, consumer)
+
+	broker.Publisher("tx", nil).Publish(context.Background(), domain.Transaction{FromAccountID: 1})
+	eventually(t, func() bool { return svc.processedCount() == 1 }, "the retry to succeed")
+	if id := svc.processed[0].ID; id != "assigned" {
+		t.Errorf("the retry should keep the transaction ID assigned by the first attempt, got %q", id)
+	}
+}
+
+func TestMemoryBrokerDeadLettersExhaustedAndMalformedMessages(t *testing.T) {
+	broker := NewMemoryBroker()
+	transient := errors.New("connection reset")
+	svc := &stubService{fail: []error{transient, transient, transient}}
+	consumer := broker.StartConsumer("tx", svc, nil, fastRetry)
+	defer shutdown(t, consumer)
+
+	broker.Publisher("tx", nil).Publish(context.Background(), domain.Transaction{FromAccountID: 1})
+	broker.queue("tx").push(&Message{Body: []byte("not json")})
+	eventually(t, func() bool { return len(broker.DeadLetters("tx")) == 2 }, "both messages to be dead-lettered")
+
+	reasons := map[any]int{}
+	for _, m := range broker.DeadLetters("tx") {
+		reasons[m.Headers[DeadLetterReasonHeader]] = m.Attempts
+	}
+	if reasons[ReasonExhausted] != 3 {
+		t.Errorf("exhausted message should carry 3 attempts, got %v", reasons)
+	}
+	if _, ok := reasons[ReasonMalformed]; !ok {
+		t.Errorf("malformed message was not dead-lettered: %v", reasons)
+	}
+}
+
+func TestMemoryBrokerAcksPermanentFailures(t *testing.T) {
+	broker := NewMemoryBroker()
+	svc := &stubService{fail: []error{domain.ErrInsufficientFunds}}
+	consumer := broker.StartConsumer("tx", svc, nil, fastRetry)
+
+	broker.Publisher("tx", nil).Publish(context.Background(), domain.Transaction{FromAccountID: 1})
+	eventually(t, func() bool { return svc.failuresLeft() == 0 }, "the transfer to be attempted")
+	shutdown(t, consumer)
+
+	if broker.Depth("tx") != 0 || len(broker.DeadLetters("tx")) != 0 || svc.processedCount() != 0 {
+		t.Error("a transfer failing with a domain error must be dropped, not retried")
+	}
+}
+
+func TestMemoryBrokerRedeliversUnstartedMessages(t *testing.T) {
+	broker := NewMemoryBroker()
+	stuck := &stubService{block: make(chan struct{})}
+	opts := fastRetry
+	opts.Prefetch = 8
+	consumer := broker.StartConsumer("tx", stuck, nil, opts)
+	publisher := broker.Publisher("tx", nil)
+	// All transfers leave account 1, so the others wait behind the first
+	for i := 1; i <= 5; i++ {
+		publisher.Publish(context.Background(), domain.Transaction{ID: fmt.Sprintf("tx-%d", i), FromAccountID: 1})
+	}
+	eventually(t, func() bool { return broker.Depth("tx") == 0 }, "the messages to be delivered")
+
+	// Give up on the transfer that never finishes
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := consumer.Shutdown(ctx); err == nil {
+		t.Fatal("shutdown should report the abandoned messages")
+	}
+	if depth := broker.Depth("tx"); depth != 4 {
+		t.Fatalf("%d messages requeued, want the 4 no worker started", depth)
+	}
+	close(stuck.block)
+	eventually(t, func() bool { return stuck.processedCount() == 1 }, "the started transfer to finish")
+
+	svc := &stubService{}
+	next := broker.StartConsumer("tx", svc, nil, fastRetry)
+	defer shutdown(t, next)
+	eventually(t, func() bool { return svc.processedCount() == 4 }, "the messages to be redelivered")
+	time.Sleep(20 * time.Millisecond)
+	if stuck.processedCount() != 1 || svc.processedCount() != 4 {
+		t.Errorf("processed %d and %d transfers, want each applied once", stuck.processedCount(), svc.processedCount())
+	}
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+	for i, tx := range svc.processed {
+		if want := fmt.Sprintf("tx-%d", i+2); tx.ID != want {
+			t.Errorf("redelivery %d is %s, want %s: transfers from one account must keep their order", i, tx.ID, want)
+		}
+	}
+}
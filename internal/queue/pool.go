@@ -0,0 +1,55 @@
+package queue
+
+import (
+	"encoding/json"
+	"hash/fnv"
+	"strconv"
+	"sync"
+)
+
+// dispatch hands every message of msgs to one of workers goroutines running
+// handle, and returns once msgs is closed and every message has been handled.
+// Messages are partitioned by the source account in their body, so transfers
+// out of the same account are handled one at a time and in queue order, while
+// transfers out of different accounts run in parallel. buffer is the backlog
+// each worker may hold; with buffer at least the prefetch, a busy worker never
+// stalls dispatching to the others.
+func dispatch[M any](msgs <-chan M, workers, buffer int, body func(M) []byte, handle func(M)) {
+	queues := make([]chan M, workers)
+	var wg sync.WaitGroup
+	for i := range queues {
+		queues[i] = make(chan M, buffer)
+		wg.Add(1)
+		go func(in <-chan M) {
+			defer wg.Done()
+			for d := range in {
+				handle(d)
+			}
+		}(queues[i])
+	}
+
+	for d := range msgs {
+		queues[partition(body(d), workers)] <- d
+	}
+	for _, q := range queues {
+		close(q)
+	}
+	wg.Wait()
+}
+
+// partition picks the worker for a message body by its source account.
+// Malformed bodies all go to the first worker; they are only dead-lettered.
+func partition(body []byte, workers int) int {
+	if workers == 1 {
+		return 0
+	}
+	var key struct {
+		FromAccountID int64 `json:"from_account_id"`
+	}
+	if err := json.Unmarshal(body, &key); err != nil {
+		return 0
+	}
+	h := fnv.New32a()
+	h.Write([]byte(strconv.FormatInt(key.FromAccountID, 10)))
+	return int(h.Sum32() % uint32(workers))
+}
@@ -0,0 +1,85 @@
+package queue
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+func transfer(from int64, seq int) amqp.Delivery {
+	return amqp.Delivery{
+		Body:    []byte(fmt.Sprintf(`{"from_account_id":%d,"to_account_id":99,"amount":1}`, from)),
+		Headers: amqp.Table{"seq": seq},
+	}
+}
+
+func TestDispatchKeepsPerAccountOrder(t *testing.T) {
+	msgs := make(chan amqp.Delivery, 100)
+	for seq := 0; seq < 20; seq++ {
+		for from := int64(1); from <= 5; from++ {
+			msgs <- transfer(from, seq)
+		}
+	}
+	close(msgs)
+
+	var mu sync.Mutex
+	seen := make(map[int64][]int)
+	dispatch(msgs, 4, 100, deliveryBody, func(d amqp.Delivery) {
+		var from int64
+		fmt.Sscanf(string(d.Body), `{"from_account_id":%d`, &from)
+		mu.Lock()
+		seen[from] = append(seen[from], d.Headers["seq"].(int))
+		mu.Unlock()
+	})
+
+	for from := int64(1); from <= 5; from++ {
+		if len(seen[from]) != 20 {
+			t.Fatalf("account %d: handled %d messages, want 20", from, len(seen[from]))
+		}
+		for i, seq := range seen[from] {
+			if seq != i {
+				t.Errorf("account %d: handled seq %d at position %d", from, seq, i)
+				break
+			}
+		}
+	}
+}
+
+func TestDispatchRunsAccountsInParallel(t *testing.T) {
+	// Find two accounts that land on different workers
+	a, b := int64(1), int64(2)
+	for partition(transfer(a, 0).Body, 2) == partition(transfer(b, 0).Body, 2) {
+		b++
+	}
+
+	msgs := make(chan amqp.Delivery, 2)
+	msgs <- transfer(a, 0)
+	msgs <- transfer(b, 0)
+	close(msgs)
+
+	// Each handler waits for the other, which only finishes if both run at once
+	var started sync.WaitGroup
+	started.Add(2)
+	finished := make(chan struct{})
+	go func() {
+		dispatch(msgs, 2, 1, deliveryBody, func(amqp.Delivery) {
+			started.Done()
+			started.Wait()
+		})
+		close(finished)
+	}()
+	select {
+	case <-finished:
+	case <-time.After(2 * time.Second):
+		t.Fatal("transfers from different accounts were not processed in parallel")
+	}
+}
+
+func TestPartitionOfMalformedBody(t *testing.T) {
+	if p := partition([]byte("not json"), 8); p != 0 {
+		t.Errorf("partition = %d, want 0", p)
+	}
+}
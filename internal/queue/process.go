@@ -0,0 +1,70 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"ledger/internal/domain"
+	"ledger/internal/metrics"
+)
+
+// verdict is what a consumer does with a message once it has been processed:
+// ack it, retry it after RetryIn or move it to the dead-letter queue
+type verdict struct {
+	// Outcome is the metrics outcome
+	Outcome string
+	// Err is the processing error, if any
+	Err error
+	// Body replaces the message body when it is retried or dead-lettered
+	Body []byte
+	// RetryIn is set when the message should be retried
+	RetryIn time.Duration
+	// DeadLetter is the reason the message is dead-lettered, if it is
+	DeadLetter string
+}
+
+// processMessage applies the transaction in body, which has already failed
+// attempts times, and decides how to settle the message. It is shared by every
+// transport so they retry and dead-letter alike.
+func processMessage(ctx context.Context, service domain.TransactionService, retry RetryPolicy, body []byte, attempts int) verdict {
+	var msg domain.Transaction
+	if err := json.Unmarshal(body, &msg); err != nil {
+		slog.WarnContext(ctx, "invalid transaction message", "error", err)
+		return verdict{Outcome: metrics.ConsumeInvalid, Err: err, Body: body, DeadLetter: ReasonMalformed}
+	}
+
+	slog.InfoContext(ctx, "processing transaction", "transaction_id", msg.ID,
+		"from_account_id", msg.FromAccountID, "to_account_id", msg.ToAccountID)
+
+	attempt := attempts + 1
+	if attempt < retry.MaxAttempts {
+		ctx = withRetries(ctx)
+	}
+	err := service.ProcessTransaction(ctx, &msg)
+	if err == nil {
+		slog.InfoContext(ctx, "transaction processed", "transaction_id", msg.ID)
+		return verdict{Outcome: metrics.ConsumeProcessed}
+	}
+	if !retryable(err) {
+		// The failure has been reported as a transaction.failed event
+		slog.ErrorContext(ctx, "failed to process transaction", "transaction_id", msg.ID, "error", err)
+		return verdict{Outcome: metrics.ConsumeFailed, Err: err}
+	}
+
+	// Requeue with the ID ProcessTransaction assigned, so every attempt
+	// refers to the same transaction
+	if updated, marshalErr := json.Marshal(msg); marshalErr == nil {
+		body = updated
+	}
+	if attempt >= retry.MaxAttempts {
+		// The last attempt reported its failure as a transaction.failed event
+		slog.ErrorContext(ctx, "giving up on transaction", "transaction_id", msg.ID, "attempts", attempt, "error", err)
+		return verdict{Outcome: metrics.ConsumeDeadLettered, Err: err, Body: body, DeadLetter: ReasonExhausted}
+	}
+	delay := retry.Delay(attempt)
+	slog.WarnContext(ctx, "transaction failed, retrying", "transaction_id", msg.ID,
+		"attempt", attempt, "retry_in", delay, "error", err)
+	return verdict{Outcome: metrics.ConsumeRetried, Err: err, Body: body, RetryIn: delay}
+}
@@ -3,62 +3,143 @@ package queue
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"ledger/internal/domain"
-	"log"
+	"ledger/internal/logging"
+	"ledger/internal/metrics"
+	"ledger/internal/tracing"
+	"log/slog"
+	"sync"
+	"time"
 
 	"github.com/streadway/amqp"
 )
 
+// AMQP headers set on every published transaction
+const (
+	// CorrelationIDHeader carries the correlation ID of the request that queued the message
+	CorrelationIDHeader = "x-correlation-id"
+	// PublishedAtHeader is the publish time in Unix milliseconds; the standard
+	// timestamp property only has second precision, too coarse for queue lag
+	PublishedAtHeader = "x-published-at"
+)
+
+// DefaultConfirmTimeout bounds how long Publish waits for the broker to confirm a message
+const DefaultConfirmTimeout = 5 * time.Second
+
+// TransactionPublisher queues transactions on a confirmed channel. It is safe
+// for concurrent use, and reconnects in the background, with backoff, when the
+// connection to the broker is lost; Publish fails with ErrUnavailable meanwhile.
 type TransactionPublisher struct {
-	conn      *amqp.Connection
-	channel   *amqp.Channel
+	amqpURL   string
 	queueName string
+	// Metrics, when set, counts published messages and publish errors
+	Metrics *metrics.Metrics
+	// ConfirmTimeout bounds how long Publish waits for the broker's confirmation
+	ConfirmTimeout time.Duration
+
+	mu sync.RWMutex
+	// current is nil while reconnecting
+	current  *confirmChannel
+	stop     chan struct{}
+	stopOnce sync.Once
+	stopped  chan struct{}
 }
 
-func NewTransactionPublisher(amqpURL, queueName string) (*TransactionPublisher, error) {
-	conn, err := amqp.Dial(amqpURL)
-	if err != nil {
+// NewTransactionPublisher connects to the broker and declares queueName. m, when
+// set, counts publishes and records the connection state.
+func NewTransactionPublisher(amqpURL, queueName string, m *metrics.Metrics) (*TransactionPublisher, error) {
+	p := &TransactionPublisher{
+		amqpURL:        amqpURL,
+		queueName:      queueName,
+		Metrics:        m,
+		ConfirmTimeout: DefaultConfirmTimeout,
+		stop:           make(chan struct{}),
+		stopped:        make(chan struct{}),
+	}
+	if err := p.connect(); err != nil {
 		return nil, err
 	}
+	go p.maintain()
+	return p, nil
+}
 
-	ch, err := conn.Channel()
+// connect opens a confirmed channel and (re)declares the queue
+func (p *TransactionPublisher) connect() error {
+	conn, ch, err := dial(p.amqpURL, func(ch *amqp.Channel) error {
+		_, err := ch.QueueDeclare(
+			p.queueName,
+			true,  // durable
+			false, // delete when unused
+			false, // exclusive
+			false, // no-wait
+			nil,   // arguments
+		)
+		return err
+	})
 	if err != nil {
-		err := conn.Close()
-		if err != nil {
-			return nil, err
-		}
-		return nil, err
+		return err
 	}
-
-	_, err = ch.QueueDeclare(
-		queueName,
-		true,  // durable
-		false, // delete when unused
-		false, // exclusive
-		false, // no-wait
-		nil,   // arguments
-	)
+	c, err := newConfirmChannel(conn, ch)
 	if err != nil {
-		err := ch.Close()
-		if err != nil {
-			return nil, err
+		ch.Close()
+		conn.Close()
+		return err
+	}
+	p.mu.Lock()
+	p.current = c
+	p.mu.Unlock()
+	p.Metrics.SetBrokerConnected(systemRabbitMQ, clientPublisher, true)
+	return nil
+}
+
+// maintain reconnects whenever the current channel closes, until Close is called
+func (p *TransactionPublisher) maintain() {
+	defer close(p.stopped)
+	for {
+		c := p.channel()
+		select {
+		case <-p.stop:
+			return
+		case <-c.closed:
 		}
-		err = conn.Close()
-		if err != nil {
-			return nil, err
+		slog.Warn("publisher lost its rabbitmq channel, reconnecting", "queue", p.queueName)
+		p.mu.Lock()
+		p.current = nil
+		p.mu.Unlock()
+		c.close()
+		p.Metrics.SetBrokerConnected(systemRabbitMQ, clientPublisher, false)
+		if !reconnect(p.stop, clientPublisher, p.Metrics, p.connect) {
+			return
 		}
-		return nil, err
 	}
+}
 
-	return &TransactionPublisher{
-		conn:      conn,
-		channel:   ch,
-		queueName: queueName,
-	}, nil
+func (p *TransactionPublisher) channel() *confirmChannel {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.current
 }
 
+// Ping checks that the publisher is connected and that the broker answers on
+// the connection by opening and closing a short-lived channel. AMQP calls are
+// not context-aware, so callers bound the round trip themselves.
+func (p *TransactionPublisher) Ping(ctx context.Context) error {
+	c := p.channel()
+	if c == nil || c.conn.IsClosed() {
+		return ErrUnavailable
+	}
+	ch, err := c.conn.Channel()
+	if err != nil {
+		return fmt.Errorf("open rabbitmq channel: %w", err)
+	}
+	return ch.Close()
+}
+
+// Publish queues msg and returns once the broker has confirmed it. A nil
+// publisher, as used by processes that never queue transfers, fails every publish.
 func (p *TransactionPublisher) Publish(ctx context.Context, msg domain.Transaction) error {
-	if p.channel == nil {
+	if p == nil {
 		return amqp.ErrClosed
 	}
 	// Ensure the context is not cancelled before publishing
@@ -73,36 +154,43 @@ func (p *TransactionPublisher) Publish(ctx context.Context, msg domain.Transacti
 		return err
 	}
 
-	err = p.channel.Publish(
-		"",          // exchange
-		p.queueName, // routing key
-		false,       // mandatory
-		false,       // immediate
-		amqp.Publishing{
-			ContentType: "application/json",
-			Body:        body,
-		},
-	)
+	now := time.Now()
+	publishing := amqp.Publishing{
+		ContentType: "application/json",
+		Body:        body,
+		Timestamp:   now,
+		Headers:     amqp.Table{PublishedAtHeader: now.UnixMilli()},
+	}
+	if id := logging.CorrelationID(ctx); id != "" {
+		publishing.Headers[CorrelationIDHeader] = id
+	}
+	ctx, span := startPublishSpan(ctx, systemRabbitMQ, p.queueName, amqpHeaders(publishing.Headers))
+
+	if c := p.channel(); c == nil {
+		err = ErrUnavailable
+	} else {
+		// Mandatory, so a message no queue takes is returned instead of dropped
+		err = c.publish(ctx, "", p.queueName, publishing, p.ConfirmTimeout)
+	}
+	p.Metrics.ObservePublish(err)
+	tracing.End(span, err)
 	if err != nil {
-		log.Printf("Failed to publish transaction: %v", err)
+		slog.ErrorContext(ctx, "failed to publish transaction", "transaction_id", msg.ID, "error", err)
 		return err
 	}
 
-	log.Printf("Transaction published: %+v", msg)
+	slog.InfoContext(ctx, "transaction published", "transaction_id", msg.ID, "queue", p.queueName)
 	return nil
 }
 
+// Close stops reconnecting and closes the connection. Calling it again has no effect.
 func (p *TransactionPublisher) Close() {
-	if p.channel != nil {
-		err := p.channel.Close()
-		if err != nil {
-			return
-		}
-	}
-	if p.conn != nil {
-		err := p.conn.Close()
-		if err != nil {
-			return
-		}
+	p.stopOnce.Do(func() { close(p.stop) })
+	<-p.stopped
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.current != nil {
+		p.current.close()
+		p.current = nil
 	}
 }
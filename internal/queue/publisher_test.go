@@ -0,0 +1,12 @@
+package queue
+
+import "testing"
+
+func TestPublisherCloseIsIdempotent(t *testing.T) {
+	p := &TransactionPublisher{stop: make(chan struct{}), stopped: make(chan struct{})}
+	// No reconnect loop runs in this test
+	close(p.stopped)
+
+	p.Close()
+	p.Close()
+}
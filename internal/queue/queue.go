@@ -0,0 +1,34 @@
+// Package queue carries transfers from the API to the consumers that apply
+// them. TransactionPublisher and TransactionConsumer use RabbitMQ; MemoryBroker
+// provides the same semantics in-process for tests and local runs.
+package queue
+
+import (
+	"context"
+
+	"ledger/internal/domain"
+)
+
+// Publisher queues transactions for asynchronous processing
+type Publisher interface {
+	// Publish returns once the broker has taken responsibility for msg
+	Publish(ctx context.Context, msg domain.Transaction) error
+	// Ping reports whether the broker can be reached
+	Ping(ctx context.Context) error
+	Close()
+}
+
+// Consumer applies queued transactions in the background
+type Consumer interface {
+	// Ping reports whether the consumer is receiving messages
+	Ping(ctx context.Context) error
+	// Shutdown stops taking messages and waits for those in flight
+	Shutdown(ctx context.Context) error
+}
+
+var (
+	_ Publisher = (*TransactionPublisher)(nil)
+	_ Consumer  = (*TransactionConsumer)(nil)
+	_ Publisher = (*MemoryPublisher)(nil)
+	_ Consumer  = (*MemoryConsumer)(nil)
+)
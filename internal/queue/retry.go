@@ -0,0 +1,158 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/streadway/amqp"
+
+	"ledger/internal/domain"
+	"ledger/internal/errmap"
+)
+
+// Headers set by the consumer when it retries or dead-letters a message
+const (
+	// AttemptHeader counts how often processing has already failed
+	AttemptHeader = "x-attempt"
+	// DeadLetterReasonHeader says why a message was dead-lettered: "malformed" or "exhausted"
+	DeadLetterReasonHeader = "x-dead-letter-reason"
+	// LastErrorHeader carries the error of the last failed attempt
+	LastErrorHeader = "x-last-error"
+)
+
+// Dead-letter reasons
+const (
+	ReasonMalformed = "malformed"
+	ReasonExhausted = "exhausted"
+)
+
+// RetryPolicy decides how often and how late a failed message is retried
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first, before
+	// a message is dead-lettered
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry; it doubles on every attempt up to MaxDelay
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+// Delay is how long to wait before retrying after attempt failed (1-based)
+func (p RetryPolicy) Delay(attempt int) time.Duration {
+	d := p.BaseDelay
+	for i := 1; i < attempt && d < p.MaxDelay; i++ {
+		d *= 2
+	}
+	if d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	return d
+}
+
+// delays returns every distinct retry delay, in increasing order
+func (p RetryPolicy) delays() []time.Duration {
+	var out []time.Duration
+	for attempt := 1; attempt < p.MaxAttempts; attempt++ {
+		d := p.Delay(attempt)
+		if len(out) == 0 || out[len(out)-1] != d {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+// DeadLetterExchange is the exchange exhausted and malformed messages of queueName are sent to
+func DeadLetterExchange(queueName string) string {
+	return queueName + ".dlx"
+}
+
+// DeadLetterQueue collects the messages sent to DeadLetterExchange(queueName)
+func DeadLetterQueue(queueName string) string {
+	return queueName + ".dlq"
+}
+
+// retryQueue holds messages for delay before they expire back into queueName.
+// There is one queue per delay because RabbitMQ only expires messages at the
+// head of a queue, so mixing delays would hold short ones behind long ones.
+func retryQueue(queueName string, delay time.Duration) string {
+	return fmt.Sprintf("%s.retry.%dms", queueName, delay.Milliseconds())
+}
+
+// declareTopology declares queueName with its dead-letter exchange and queue
+// and the delay queues used for retries
+func declareTopology(ch *amqp.Channel, queueName string, retry RetryPolicy) error {
+	if _, err := ch.QueueDeclare(queueName, true, false, false, false, nil); err != nil {
+		return err
+	}
+
+	dlx, dlq := DeadLetterExchange(queueName), DeadLetterQueue(queueName)
+	if err := ch.ExchangeDeclare(dlx, "direct", true, false, false, false, nil); err != nil {
+		return fmt.Errorf("declare dead-letter exchange: %w", err)
+	}
+	if _, err := ch.QueueDeclare(dlq, true, false, false, false, nil); err != nil {
+		return fmt.Errorf("declare dead-letter queue: %w", err)
+	}
+	if err := ch.QueueBind(dlq, queueName, dlx, false, nil); err != nil {
+		return fmt.Errorf("bind dead-letter queue: %w", err)
+	}
+
+	for _, delay := range retry.delays() {
+		_, err := ch.QueueDeclare(retryQueue(queueName, delay), true, false, false, false, amqp.Table{
+			"x-message-ttl":             delay.Milliseconds(),
+			"x-dead-letter-exchange":    "",
+			"x-dead-letter-routing-key": queueName,
+		})
+		if err != nil {
+			return fmt.Errorf("declare retry queue: %w", err)
+		}
+	}
+	return nil
+}
+
+// retryable reports whether processing may succeed on a later attempt. Domain
+// errors (unknown account, insufficient funds, ...) are final; anything else,
+// such as a database being unreachable, is worth retrying. A transfer that
+// failed after its debit is final too, since a retry would debit again.
+func retryable(err error) bool {
+	return errmap.Classify(err) == errmap.Internal && !errors.Is(err, domain.ErrTransferIncomplete)
+}
+
+type retriesKey struct{}
+
+// withRetries marks ctx as processing a message that is retried if it fails
+// with a retryable error
+func withRetries(ctx context.Context) context.Context {
+	return context.WithValue(ctx, retriesKey{}, true)
+}
+
+// WillRetry reports whether the consumer processing ctx retries the message
+// after it failed with err, in which case the failure is not final yet
+func WillRetry(ctx context.Context, err error) bool {
+	retries, _ := ctx.Value(retriesKey{}).(bool)
+	return retries && retryable(err)
+}
+
+// attempts is the number of failed attempts recorded on d
+func attempts(d amqp.Delivery) int {
+	switch n := d.Headers[AttemptHeader].(type) {
+	case int32:
+		return int(n)
+	case int64:
+		return int(n)
+	default:
+		return 0
+	}
+}
+
+// failureHeaders copies the headers of d for republishing, recording the
+// failed attempt and its error
+func failureHeaders(d amqp.Delivery, attempt int, cause error) amqp.Table {
+	headers := make(amqp.Table, len(d.Headers)+3)
+	for k, v := range d.Headers {
+		headers[k] = v
+	}
+	headers[AttemptHeader] = int64(attempt)
+	headers[LastErrorHeader] = cause.Error()
+	return headers
+}
@@ -0,0 +1,95 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/streadway/amqp"
+
+	"ledger/internal/domain"
+)
+
+func TestRetryPolicyDelay(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 8, BaseDelay: time.Second, MaxDelay: 5 * time.Second}
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for i, w := range want {
+		if got := p.Delay(i + 1); got != w {
+			t.Errorf("Delay(%d) = %s, want %s", i+1, got, w)
+		}
+	}
+}
+
+func TestRetryPolicyDelaysAreDistinct(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 6, BaseDelay: time.Second, MaxDelay: 3 * time.Second}
+	got := p.delays()
+	want := []time.Duration{time.Second, 2 * time.Second, 3 * time.Second}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("delays() = %v, want %v", got, want)
+	}
+	if d := (RetryPolicy{MaxAttempts: 1, BaseDelay: time.Second, MaxDelay: time.Second}).delays(); len(d) != 0 {
+		t.Errorf("a single attempt needs no retry queues, got %v", d)
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{errors.New("connection refused"), true},
+		{fmt.Errorf("debit source account: %w", errors.New("driver: bad connection")), true},
+		{domain.ErrInsufficientFunds, false},
+		{fmt.Errorf("fetch source account: %w", domain.ErrAccountNotFound), false},
+		{domain.ErrAccountFrozen, false},
+		{fmt.Errorf("%w: credit destination account: %w", domain.ErrTransferIncomplete, errors.New("driver: bad connection")), false},
+	}
+	for _, c := range cases {
+		if got := retryable(c.err); got != c.want {
+			t.Errorf("retryable(%v) = %v, want %v", c.err, got, c.want)
+		}
+	}
+}
+
+func TestWillRetry(t *testing.T) {
+	transient := errors.New("connection refused")
+	if WillRetry(context.Background(), transient) {
+		t.Error("failures outside a consumer are final")
+	}
+	ctx := withRetries(context.Background())
+	if !WillRetry(ctx, transient) {
+		t.Error("a transient failure with attempts left is retried")
+	}
+	if WillRetry(ctx, domain.ErrInsufficientFunds) {
+		t.Error("a domain error is never retried")
+	}
+}
+
+func TestAttempts(t *testing.T) {
+	for _, h := range []amqp.Table{nil, {AttemptHeader: "2"}} {
+		if n := attempts(amqp.Delivery{Headers: h}); n != 0 {
+			t.Errorf("attempts(%v) = %d, want 0", h, n)
+		}
+	}
+	// The broker hands back small integers as int32
+	if n := attempts(amqp.Delivery{Headers: amqp.Table{AttemptHeader: int32(3)}}); n != 3 {
+		t.Errorf("attempts = %d, want 3", n)
+	}
+	if n := attempts(amqp.Delivery{Headers: amqp.Table{AttemptHeader: int64(4)}}); n != 4 {
+		t.Errorf("attempts = %d, want 4", n)
+	}
+}
+
+func TestFailureHeadersKeepOriginal(t *testing.T) {
+	d := amqp.Delivery{Headers: amqp.Table{CorrelationIDHeader: "abc", AttemptHeader: int64(1)}}
+	h := failureHeaders(d, 2, errors.New("timeout"))
+
+	if h[CorrelationIDHeader] != "abc" || h[AttemptHeader] != int64(2) || h[LastErrorHeader] != "timeout" {
+		t.Errorf("unexpected headers %v", h)
+	}
+	if d.Headers[AttemptHeader] != int64(1) {
+		t.Error("the delivery's own headers were modified")
+	}
+}
@@ -0,0 +1,99 @@
+package queue
+
+import (
+	"context"
+
+	"github.com/streadway/amqp"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Values of the messaging.system span attribute
+const (
+	systemRabbitMQ = "rabbitmq"
+	systemKafka    = "kafka"
+	systemMemory   = "memory"
+)
+
+// amqpHeaders adapts message headers so the W3C trace context (traceparent,
+// tracestate) travels with every published transaction
+type amqpHeaders amqp.Table
+
+func (h amqpHeaders) Get(key string) string {
+	v, _ := h[key].(string)
+	return v
+}
+
+func (h amqpHeaders) Set(key, value string) {
+	h[key] = value
+}
+
+func (h amqpHeaders) Keys() []string {
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// kafkaHeaders does the same for Kafka record headers
+type kafkaHeaders struct {
+	headers *[]kgo.RecordHeader
+}
+
+func (h kafkaHeaders) Get(key string) string {
+	for _, header := range *h.headers {
+		if header.Key == key {
+			return string(header.Value)
+		}
+	}
+	return ""
+}
+
+func (h kafkaHeaders) Set(key, value string) {
+	for i, header := range *h.headers {
+		if header.Key == key {
+			(*h.headers)[i].Value = []byte(value)
+			return
+		}
+	}
+	*h.headers = append(*h.headers, kgo.RecordHeader{Key: key, Value: []byte(value)})
+}
+
+func (h kafkaHeaders) Keys() []string {
+	keys := make([]string, 0, len(*h.headers))
+	for _, header := range *h.headers {
+		keys = append(keys, header.Key)
+	}
+	return keys
+}
+
+// startPublishSpan opens a producer span for a message to destination and
+// injects its trace context into headers
+func startPublishSpan(ctx context.Context, system, destination string, headers propagation.TextMapCarrier) (context.Context, trace.Span) {
+	ctx, span := otel.Tracer("ledger/queue").Start(ctx, destination+" publish",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(messagingAttributes(system, destination)...),
+	)
+	otel.GetTextMapPropagator().Inject(ctx, headers)
+	return ctx, span
+}
+
+// startProcessSpan continues the trace carried in headers with a consumer span
+func startProcessSpan(ctx context.Context, system, destination string, headers propagation.TextMapCarrier) (context.Context, trace.Span) {
+	ctx = otel.GetTextMapPropagator().Extract(ctx, headers)
+	return otel.Tracer("ledger/queue").Start(ctx, destination+" process",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(messagingAttributes(system, destination)...),
+	)
+}
+
+func messagingAttributes(system, destination string) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("messaging.system", system),
+		attribute.String("messaging.destination.name", destination),
+	}
+}
@@ -0,0 +1,83 @@
+package queue
+
+import (
+	"fmt"
+	"time"
+
+	"ledger/internal/domain"
+	"ledger/internal/metrics"
+)
+
+// Brokers a Transport can use
+const (
+	BrokerRabbitMQ = "rabbitmq"
+	BrokerKafka    = "kafka"
+	BrokerMemory   = "memory"
+)
+
+// Settings selects the broker and configures its publishers and consumers
+type Settings struct {
+	Broker string
+	// Queue is the RabbitMQ queue, the Kafka topic or the in-memory queue
+	Queue       string
+	RabbitMQURL string
+	// ConfirmTimeout bounds how long a RabbitMQ publish waits for its confirmation
+	ConfirmTimeout     time.Duration
+	KafkaBrokers       []string
+	KafkaConsumerGroup string
+	Consumer           ConsumerOptions
+}
+
+// Transport opens publishers and consumers on the broker chosen in its Settings
+type Transport struct {
+	settings Settings
+	metrics  *metrics.Metrics
+	// memory is shared by the publisher and consumer of an in-memory transport
+	memory *MemoryBroker
+}
+
+// NewTransport returns a transport for s. m, when set, instruments everything it opens.
+func NewTransport(s Settings, m *metrics.Metrics) (*Transport, error) {
+	t := &Transport{settings: s, metrics: m}
+	switch s.Broker {
+	case BrokerRabbitMQ, BrokerKafka:
+	case BrokerMemory:
+		t.memory = NewMemoryBroker()
+	default:
+		return nil, fmt.Errorf("unknown queue broker %q", s.Broker)
+	}
+	return t, nil
+}
+
+// Publisher connects a publisher
+func (t *Transport) Publisher() (Publisher, error) {
+	s := t.settings
+	switch s.Broker {
+	case BrokerKafka:
+		return NewKafkaPublisher(s.KafkaBrokers, s.Queue, t.metrics)
+	case BrokerMemory:
+		return t.memory.Publisher(s.Queue, t.metrics), nil
+	default:
+		p, err := NewTransactionPublisher(s.RabbitMQURL, s.Queue, t.metrics)
+		if err != nil {
+			return nil, err
+		}
+		if s.ConfirmTimeout > 0 {
+			p.ConfirmTimeout = s.ConfirmTimeout
+		}
+		return p, nil
+	}
+}
+
+// StartConsumer starts applying queued transactions with service
+func (t *Transport) StartConsumer(service domain.TransactionService) (Consumer, error) {
+	s := t.settings
+	switch s.Broker {
+	case BrokerKafka:
+		return StartKafkaConsumer(s.KafkaBrokers, s.Queue, s.KafkaConsumerGroup, service, t.metrics, s.Consumer)
+	case BrokerMemory:
+		return t.memory.StartConsumer(s.Queue, service, t.metrics, s.Consumer), nil
+	default:
+		return StartTransactionConsumer(s.RabbitMQURL, s.Queue, service, t.metrics, s.Consumer)
+	}
+}
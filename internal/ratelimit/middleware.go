@@ -0,0 +1,159 @@
+package ratelimit
+
+import (
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+
+	"ledger/internal/auth"
+	"ledger/internal/domain"
+	"ledger/internal/handler"
+)
+
+// Route classes with separate quotas
+const (
+	ClassRead     = "read"
+	ClassWrite    = "write"
+	ClassTransfer = "transfer"
+	// ClassAddress is the quota of a client address, drawn from by every request before authentication
+	ClassAddress = "address"
+)
+
+// Limiter applies a token bucket per client and route class
+type Limiter struct {
+	Store Store
+	// Limits maps route classes to their quota; classes without a limit are not throttled
+	Limits map[string]Limit
+	// Classify assigns a request to a route class; DefaultClassify when nil
+	Classify func(r *http.Request) string
+	// Key identifies the client; its principal, or its address when unauthenticated, when nil
+	Key func(r *http.Request) string
+	// TrustedProxies are the load balancers and reverse proxies in front of the
+	// service. Requests from them are keyed by the client address they report in
+	// X-Forwarded-For; the header is ignored from any other peer.
+	TrustedProxies []netip.Prefix
+}
+
+// ByAddress returns a limiter that charges every request to the ClassAddress
+// quota of its remote address. It runs ahead of authentication, so requests
+// with bad credentials are throttled like any other.
+func ByAddress(store Store, limits map[string]Limit) *Limiter {
+	l := &Limiter{
+		Store:    store,
+		Limits:   limits,
+		Classify: func(*http.Request) string { return ClassAddress },
+	}
+	l.Key = l.addressKey
+	return l
+}
+
+// DefaultClassify puts transfers in their own class, other mutations in write and everything else in read
+func DefaultClassify(r *http.Request) string {
+	switch {
+	case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/transactions"):
+		return ClassTransfer
+	case r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions:
+		return ClassRead
+	default:
+		return ClassWrite
+	}
+}
+
+// Middleware throttles requests and reports the caller's quota in RateLimit-* headers.
+// Unless Key is set it must run after authentication so clients are identified by principal rather than address.
+// If the store fails the request is let through rather than turning an outage into 429s.
+func (l *Limiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		classify := l.Classify
+		if classify == nil {
+			classify = DefaultClassify
+		}
+		class := classify(r)
+		limit, ok := l.Limits[class]
+		if !ok || limit.Rate <= 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		key := l.Key
+		if key == nil {
+			key = l.clientKey
+		}
+		res, err := l.Store.Take(r.Context(), class+":"+key(r), limit)
+		if err != nil {
+			slog.WarnContext(r.Context(), "rate limit store failed, allowing request", "error", err)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		h := w.Header()
+		h.Set("RateLimit-Limit", strconv.Itoa(limit.Burst))
+		h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		h.Set("RateLimit-Reset", ceilSeconds(res.Reset))
+		if !res.Allowed {
+			h.Set("Retry-After", ceilSeconds(res.RetryAfter))
+			handler.WriteError(w, r, domain.ErrRateLimited)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// clientKey identifies the caller by principal, falling back to the remote address
+func (l *Limiter) clientKey(r *http.Request) string {
+	if p, ok := auth.FromContext(r.Context()); ok {
+		return "principal:" + p.Subject
+	}
+	return l.addressKey(r)
+}
+
+// addressKey identifies the caller by its remote address, or by the address
+// trusted proxies forwarded the request for
+func (l *Limiter) addressKey(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil || !l.trusted(addr) {
+		return "ip:" + host
+	}
+
+	// Each proxy appends the peer it received the request from, so the
+	// rightmost address not added by a trusted proxy is the client. Anything
+	// to its left was sent by the client and may be forged.
+	var hops []string
+	for _, h := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(h, ",")...)
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			break
+		}
+		addr = hop.Unmap()
+		if !l.trusted(addr) {
+			break
+		}
+	}
+	return "ip:" + addr.String()
+}
+
+func (l *Limiter) trusted(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, p := range l.TrustedProxies {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
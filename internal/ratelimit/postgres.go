@@ -0,0 +1,70 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// PostgresStore keeps buckets in the rate_limit_buckets table so every API replica
+// draws from the same quota. Refills use the database clock to avoid skew between replicas.
+type PostgresStore struct {
+	db *sql.DB
+
+	mu        sync.Mutex
+	lastPrune time.Time
+}
+
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+// takeQuery refills the bucket for the time since its last update and takes a token
+// when one is available, all in one atomic upsert
+const takeQuery = `
+	INSERT INTO rate_limit_buckets AS b (key, tokens, allowed, updated_at)
+	VALUES ($1, $2 - 1, TRUE, now())
+	ON CONFLICT (key) DO UPDATE SET
+		tokens = CASE
+			WHEN LEAST($2, b.tokens + EXTRACT(EPOCH FROM now() - b.updated_at) * $3) >= 1
+			THEN LEAST($2, b.tokens + EXTRACT(EPOCH FROM now() - b.updated_at) * $3) - 1
+			ELSE LEAST($2, b.tokens + EXTRACT(EPOCH FROM now() - b.updated_at) * $3)
+		END,
+		allowed = LEAST($2, b.tokens + EXTRACT(EPOCH FROM now() - b.updated_at) * $3) >= 1,
+		updated_at = now()
+	RETURNING tokens, allowed
+`
+
+func (s *PostgresStore) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	s.maybePrune()
+
+	var tokens float64
+	var allowed bool
+	err := s.db.QueryRowContext(ctx, takeQuery, key, float64(limit.Burst), limit.Rate).Scan(&tokens, &allowed)
+	if err != nil {
+		return Result{}, err
+	}
+	return result(limit, tokens, allowed), nil
+}
+
+// maybePrune deletes buckets idle for an hour, at most once every ten minutes per replica
+func (s *PostgresStore) maybePrune() {
+	s.mu.Lock()
+	if time.Since(s.lastPrune) < 10*time.Minute {
+		s.mu.Unlock()
+		return
+	}
+	s.lastPrune = time.Now()
+	s.mu.Unlock()
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		_, err := s.db.ExecContext(ctx, `DELETE FROM rate_limit_buckets WHERE updated_at < now() - INTERVAL '1 hour'`)
+		if err != nil {
+			slog.Warn("failed to prune rate limit buckets", "error", err)
+		}
+	}()
+}
@@ -0,0 +1,72 @@
+// Package ratelimit enforces per-client token buckets on the HTTP API. Buckets
+// live in memory by default or in Postgres so several replicas share one quota.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limit is a token bucket refilled at Rate tokens per second up to Burst tokens.
+// A zero Rate means unlimited.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// ParseLimit reads a limit written as "<rate per second>:<burst>", e.g. "5:10"
+func ParseLimit(s string) (Limit, error) {
+	rate, burst, ok := strings.Cut(s, ":")
+	if !ok {
+		return Limit{}, fmt.Errorf("rate limit %q must look like <rate>:<burst>", s)
+	}
+	r, err := strconv.ParseFloat(rate, 64)
+	if err != nil || r < 0 {
+		return Limit{}, fmt.Errorf("invalid rate in %q", s)
+	}
+	b, err := strconv.Atoi(burst)
+	if err != nil || b < 1 {
+		return Limit{}, fmt.Errorf("invalid burst in %q", s)
+	}
+	return Limit{Rate: r, Burst: b}, nil
+}
+
+// Result describes the bucket after a request was counted
+type Result struct {
+	Allowed bool
+	// Remaining is the number of whole tokens left
+	Remaining int
+	// RetryAfter is how long until the next token is available; zero when allowed
+	RetryAfter time.Duration
+	// Reset is how long until the bucket is full again
+	Reset time.Duration
+}
+
+// Store takes one token from the bucket identified by key
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// result derives the response metadata from the tokens left after a request
+func result(limit Limit, tokens float64, allowed bool) Result {
+	res := Result{
+		Allowed:   allowed,
+		Remaining: int(math.Max(0, math.Floor(tokens))),
+		Reset:     seconds((float64(limit.Burst) - tokens) / limit.Rate),
+	}
+	if !allowed {
+		res.RetryAfter = seconds((1 - tokens) / limit.Rate)
+	}
+	return res
+}
+
+func seconds(s float64) time.Duration {
+	if s <= 0 {
+		return 0
+	}
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,259 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"ledger/internal/auth"
+)
+
+func TestParseLimit(t *testing.T) {
+	l, err := ParseLimit("2.5:10")
+	if err != nil || l.Rate != 2.5 || l.Burst != 10 {
+		t.Errorf("got %+v, %v", l, err)
+	}
+	for _, bad := range []string{"10", "x:1", "1:0", "-1:5", "1:y"} {
+		if _, err := ParseLimit(bad); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestMemoryStore_Refill(t *testing.T) {
+	now := time.Unix(0, 0)
+	s := NewMemoryStore()
+	s.now = func() time.Time { return now }
+	limit := Limit{Rate: 1, Burst: 2}
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		res, _ := s.Take(ctx, "k", limit)
+		if !res.Allowed {
+			t.Fatalf("request %d should be allowed", i)
+		}
+	}
+	res, _ := s.Take(ctx, "k", limit)
+	if res.Allowed || res.Remaining != 0 {
+		t.Fatalf("third request should be denied, got %+v", res)
+	}
+	if res.RetryAfter != time.Second {
+		t.Errorf("expected retry after 1s, got %v", res.RetryAfter)
+	}
+
+	// Other keys have their own bucket
+	if res, _ := s.Take(ctx, "other", limit); !res.Allowed {
+		t.Error("separate key should be allowed")
+	}
+
+	now = now.Add(1500 * time.Millisecond)
+	res, _ = s.Take(ctx, "k", limit)
+	if !res.Allowed {
+		t.Fatal("bucket should have refilled one token")
+	}
+	if res.Reset != 1500*time.Millisecond {
+		t.Errorf("expected reset in 1.5s, got %v", res.Reset)
+	}
+}
+
+func TestMemoryStore_PrunesFullBuckets(t *testing.T) {
+	now := time.Unix(0, 0)
+	s := NewMemoryStore()
+	s.now = func() time.Time { return now }
+	s.Take(context.Background(), "k", Limit{Rate: 1, Burst: 1})
+
+	now = now.Add(2 * time.Minute)
+	s.Take(context.Background(), "other", Limit{Rate: 1, Burst: 1})
+	if _, ok := s.buckets["k"]; ok {
+		t.Error("refilled bucket should have been pruned")
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	l := &Limiter{
+		Store:  NewMemoryStore(),
+		Limits: map[string]Limit{ClassTransfer: {Rate: 0.5, Burst: 1}},
+	}
+	h := l.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	send := func(method, path, subject string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		if subject != "" {
+			req = req.WithContext(auth.WithPrincipal(req.Context(), &auth.Principal{Subject: subject}))
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := send(http.MethodPost, "/api/v1/transactions", "key-1")
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("first transfer: got %d", rec.Code)
+	}
+	if rec.Header().Get("RateLimit-Limit") != "1" || rec.Header().Get("RateLimit-Remaining") != "0" {
+		t.Errorf("unexpected headers %v", rec.Header())
+	}
+
+	rec = send(http.MethodPost, "/api/v1/transactions", "key-1")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("second transfer: got %d", rec.Code)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "2" {
+		t.Errorf("expected Retry-After 2, got %q", got)
+	}
+
+	if rec := send(http.MethodPost, "/api/v1/transactions", "key-2"); rec.Code != http.StatusNoContent {
+		t.Errorf("another client should have its own quota, got %d", rec.Code)
+	}
+	if rec := send(http.MethodPost, "/api/v1/transactions", ""); rec.Code != http.StatusNoContent {
+		t.Errorf("anonymous client should be keyed by address, got %d", rec.Code)
+	}
+
+	// Reads have no configured limit
+	rec = send(http.MethodGet, "/api/v1/accounts", "key-1")
+	if rec.Code != http.StatusNoContent || rec.Header().Get("RateLimit-Limit") != "" {
+		t.Errorf("unlimited class should pass without headers, got %d %v", rec.Code, rec.Header())
+	}
+}
+
+func TestByAddress(t *testing.T) {
+	l := ByAddress(NewMemoryStore(), map[string]Limit{ClassAddress: {Rate: 0.5, Burst: 2}})
+	// The authenticator would reject these requests, but only after the limiter charged them
+	h := l.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+
+	send := func(addr string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/accounts", nil)
+		req.RemoteAddr = addr
+		req.Header.Set("Authorization", "Bearer guess")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	for i := range 2 {
+		if code := send("192.0.2.1:1000"); code != http.StatusUnauthorized {
+			t.Fatalf("attempt %d: got %d", i+1, code)
+		}
+	}
+	if code := send("192.0.2.1:2000"); code != http.StatusTooManyRequests {
+		t.Errorf("the address should be throttled whatever its port, got %d", code)
+	}
+	if code := send("192.0.2.2:1000"); code != http.StatusUnauthorized {
+		t.Errorf("another address should have its own quota, got %d", code)
+	}
+}
+
+func TestByAddress_TrustedProxies(t *testing.T) {
+	l := ByAddress(NewMemoryStore(), map[string]Limit{ClassAddress: {Rate: 0.5, Burst: 1}})
+	l.TrustedProxies = []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+	h := l.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	send := func(addr string, forwardedFor ...string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/accounts", nil)
+		req.RemoteAddr = addr
+		for _, f := range forwardedFor {
+			req.Header.Add("X-Forwarded-For", f)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// Clients behind the load balancer get their own quota
+	if code := send("10.0.0.1:1000", "192.0.2.1"); code != http.StatusOK {
+		t.Fatalf("first client: got %d", code)
+	}
+	if code := send("10.0.0.1:1000", "192.0.2.2"); code != http.StatusOK {
+		t.Errorf("a second client behind the proxy should have its own quota, got %d", code)
+	}
+	if code := send("10.0.0.2:1000", "192.0.2.1"); code != http.StatusTooManyRequests {
+		t.Errorf("the client should be throttled through any trusted proxy, got %d", code)
+	}
+
+	// Addresses the client put in front of the proxy's own are ignored
+	if code := send("10.0.0.1:1000", "198.51.100.7, 192.0.2.1"); code != http.StatusTooManyRequests {
+		t.Errorf("a forged hop should not escape the quota, got %d", code)
+	}
+	if code := send("10.0.0.1:1000", "198.51.100.7", "192.0.2.1, 10.0.0.3"); code != http.StatusTooManyRequests {
+		t.Errorf("the client should be found behind a chain of trusted proxies, got %d", code)
+	}
+
+	// The header is ignored from untrusted peers
+	if code := send("203.0.113.1:1000", "192.0.2.3"); code != http.StatusOK {
+		t.Fatalf("untrusted peer: got %d", code)
+	}
+	if code := send("203.0.113.1:1000", "192.0.2.4"); code != http.StatusTooManyRequests {
+		t.Errorf("an untrusted peer should not pick its key through X-Forwarded-For, got %d", code)
+	}
+}
+
+type failingStore struct{}
+
+func (failingStore) Take(context.Context, string, Limit) (Result, error) {
+	return Result{}, errors.New("db down")
+}
+
+func TestMiddleware_FailsOpen(t *testing.T) {
+	l := &Limiter{Store: failingStore{}, Limits: map[string]Limit{ClassRead: {Rate: 1, Burst: 1}}}
+	h := l.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/accounts", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected request to pass when the store fails, got %d", rec.Code)
+	}
+}
+
+func TestDefaultClassify(t *testing.T) {
+	cases := []struct {
+		method, path, class string
+	}{
+		{http.MethodGet, "/api/v1/accounts/1/transactions", ClassRead},
+		{http.MethodPost, "/api/v1/transactions", ClassTransfer},
+		{http.MethodPost, "/api/v1/accounts", ClassWrite},
+		{http.MethodDelete, "/api/v1/accounts/1", ClassWrite},
+	}
+	for _, c := range cases {
+		if got := DefaultClassify(httptest.NewRequest(c.method, c.path, nil)); got != c.class {
+			t.Errorf("%s %s: got %s, want %s", c.method, c.path, got, c.class)
+		}
+	}
+}
+
+func TestPostgresStore_Take(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	s := NewPostgresStore(db)
+	s.lastPrune = time.Now()
+	limit := Limit{Rate: 2, Burst: 4}
+
+	mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO rate_limit_buckets")).
+		WithArgs("write:principal:key-1", 4.0, 2.0).
+		WillReturnRows(sqlmock.NewRows([]string{"tokens", "allowed"}).AddRow(0.5, false))
+
+	res, err := s.Take(context.Background(), "write:principal:key-1", limit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Allowed || res.RetryAfter != 250*time.Millisecond || res.Reset != 1750*time.Millisecond {
+		t.Errorf("unexpected result %+v", res)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,245 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"ledger/internal/domain"
+)
+
+type accountRow struct {
+	account domain.Account
+	created stamp
+}
+
+type AccountRepository struct {
+	db *DB
+}
+
+func NewAccountRepository(db *DB) *AccountRepository {
+	return &AccountRepository{db: db}
+}
+
+func (r *AccountRepository) Create(ctx context.Context, account *domain.Account) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if account.ID == "" {
+		account.ID = r.db.nextAccountID()
+	}
+	if _, ok := r.db.accounts[account.ID]; ok {
+		return fmt.Errorf("%w: account %s already exists", domain.ErrConflict, account.ID)
+	}
+	if account.CustomerID != "" {
+		if _, ok := r.db.customers[account.CustomerID]; !ok {
+			return fmt.Errorf("%w: customer %s does not exist", domain.ErrConflict, account.CustomerID)
+		}
+	}
+	row := &accountRow{account: *account, created: r.db.created()}
+	// Like the Postgres repository, reads do not return the creation time
+	row.account.CreatedAt = ""
+	r.db.accounts[account.ID] = row
+	return nil
+}
+
+func (r *AccountRepository) GetByID(ctx context.Context, id string) (*domain.Account, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	row, ok := r.db.accounts[id]
+	if !ok {
+		return &domain.Account{}, domain.ErrAccountNotFound
+	}
+	account := row.account
+	return &account, nil
+}
+
+func (r *AccountRepository) GetAll(ctx context.Context) ([]*domain.Account, error) {
+	return r.filter(func(*domain.Account) bool { return true }), nil
+}
+
+func (r *AccountRepository) GetByCustomerID(ctx context.Context, customerID string) ([]*domain.Account, error) {
+	return r.filter(func(a *domain.Account) bool { return a.CustomerID == customerID }), nil
+}
+
+// filter returns copies of the accounts matching keep, oldest first
+func (r *AccountRepository) filter(keep func(*domain.Account) bool) []*domain.Account {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	var accounts []*domain.Account
+	for _, row := range r.sortedAccounts() {
+		if keep(&row.account) {
+			account := row.account
+			accounts = append(accounts, &account)
+		}
+	}
+	return accounts
+}
+
+// nextAccountID returns the lowest unused account number above the last one
+// handed out. Callers hold mu.
+func (db *DB) nextAccountID() string {
+	for {
+		db.accountSeq++
+		id := strconv.FormatInt(db.accountSeq, 10)
+		if _, ok := db.accounts[id]; !ok {
+			return id
+		}
+	}
+}
+
+// sortedAccounts returns the rows in creation order. Callers hold mu.
+func (r *AccountRepository) sortedAccounts() []*accountRow {
+	rows := make([]*accountRow, 0, len(r.db.accounts))
+	for _, row := range r.db.accounts {
+		rows = append(rows, row)
+	}
+	slices.SortFunc(rows, func(a, b *accountRow) int { return a.created.compare(b.created) })
+	return rows
+}
+
+// accountSortKeys renders the value accounts are ordered by for each sort field,
+// as it appears in cursors
+var accountSortKeys = map[string]func(*accountRow) string{
+	domain.SortByID:        func(r *accountRow) string { return r.account.ID },
+	domain.SortByOwnerName: func(r *accountRow) string { return r.account.OwnerName },
+	domain.SortByBalance:   func(r *accountRow) string { return strconv.FormatFloat(r.account.Balance, 'f', -1, 64) },
+	domain.SortByCreatedAt: func(r *accountRow) string { return r.created.String() },
+}
+
+// compareSortKeys orders two sort keys of field, numerically for balances and IDs
+func compareSortKeys(field, a, b string) int {
+	switch field {
+	case domain.SortByBalance:
+		x, _ := strconv.ParseFloat(a, 64)
+		y, _ := strconv.ParseFloat(b, 64)
+		return cmp.Compare(x, y)
+	case domain.SortByID:
+		return compareIDs(a, b)
+	}
+	return strings.Compare(a, b)
+}
+
+// compareIDs orders account numbers numerically, like the Postgres SERIAL
+// column, ahead of any IDs that are not numbers
+func compareIDs(a, b string) int {
+	x, errX := strconv.ParseInt(a, 10, 64)
+	y, errY := strconv.ParseInt(b, 10, 64)
+	switch {
+	case errX == nil && errY == nil:
+		return cmp.Compare(x, y)
+	case errX == nil:
+		return -1
+	case errY == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+// List returns one page of accounts using keyset pagination on (sort key, id)
+func (r *AccountRepository) List(ctx context.Context, opts domain.AccountListOptions) (*domain.AccountPage, error) {
+	sortBy := opts.SortBy
+	if sortBy == "" {
+		sortBy = domain.SortByID
+	}
+	sortKey, ok := accountSortKeys[sortBy]
+	if !ok {
+		return nil, fmt.Errorf("unsupported sort field %q", opts.SortBy)
+	}
+	cursor, err := domain.DecodeCursor(opts.Cursor)
+	if err != nil {
+		return nil, err
+	}
+	if cursor != nil && cursor.Sort != sortBy {
+		return nil, domain.ErrInvalidCursor
+	}
+	if cursor != nil && sortBy == domain.SortByBalance {
+		if _, err := strconv.ParseFloat(cursor.Key, 64); err != nil {
+			return nil, domain.ErrInvalidCursor
+		}
+	}
+	limit := domain.ClampPageSize(opts.Limit)
+	dir := 1
+	if opts.Desc {
+		dir = -1
+	}
+
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	// compare orders rows by (sort key, id) in the requested direction
+	compare := func(key, id string, other *accountRow) int {
+		c := compareSortKeys(sortBy, key, sortKey(other))
+		if c == 0 {
+			c = compareIDs(id, other.account.ID)
+		}
+		return c * dir
+	}
+
+	var rows []*accountRow
+	for _, row := range r.db.accounts {
+		if opts.CustomerID != "" && row.account.CustomerID != opts.CustomerID {
+			continue
+		}
+		// Keep only rows after the cursor
+		if cursor != nil && compare(cursor.Key, cursor.ID, row) >= 0 {
+			continue
+		}
+		rows = append(rows, row)
+	}
+	slices.SortFunc(rows, func(a, b *accountRow) int {
+		return compare(sortKey(a), a.account.ID, b)
+	})
+
+	page := &domain.AccountPage{Accounts: []*domain.Account{}}
+	for i, row := range rows {
+		if i == limit {
+			last := rows[limit-1]
+			page.NextCursor = domain.EncodeCursor(domain.Cursor{Sort: sortBy, Key: sortKey(last), ID: last.account.ID})
+			break
+		}
+		account := row.account
+		page.Accounts = append(page.Accounts, &account)
+	}
+	return page, nil
+}
+
+func (r *AccountRepository) UpdateBalance(ctx context.Context, id string, amount float64) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	row, ok := r.db.accounts[id]
+	if !ok {
+		return domain.ErrAccountNotFound
+	}
+	row.account.Balance += amount
+	return nil
+}
+
+func (r *AccountRepository) UpdateStatus(ctx context.Context, id string, status string) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	row, ok := r.db.accounts[id]
+	if !ok {
+		return domain.ErrAccountNotFound
+	}
+	row.account.Status = status
+	return nil
+}
+
+func (r *AccountRepository) Delete(ctx context.Context, id string) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.accounts[id]; !ok {
+		return domain.ErrAccountNotFound
+	}
+	delete(r.db.accounts, id)
+	return nil
+}
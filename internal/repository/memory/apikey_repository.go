@@ -0,0 +1,92 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"ledger/internal/domain"
+)
+
+type apiKeyRow struct {
+	key     domain.APIKey
+	created stamp
+}
+
+type APIKeyRepository struct {
+	db *DB
+}
+
+func NewAPIKeyRepository(db *DB) *APIKeyRepository {
+	return &APIKeyRepository{db: db}
+}
+
+func (r *APIKeyRepository) Create(ctx context.Context, key *domain.APIKey) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if domain.RoleRank(key.Role) < 0 {
+		return fmt.Errorf("%w: unknown role %q", domain.ErrValidation, key.Role)
+	}
+	if _, ok := r.db.apiKeys[key.ID]; ok {
+		return fmt.Errorf("%w: api key %s already exists", domain.ErrConflict, key.ID)
+	}
+	for _, row := range r.db.apiKeys {
+		if row.key.KeyHash == key.KeyHash {
+			return fmt.Errorf("%w: api key hash is already in use", domain.ErrConflict)
+		}
+	}
+	if key.CustomerID != "" {
+		if _, ok := r.db.customers[key.CustomerID]; !ok {
+			return fmt.Errorf("%w: customer %s does not exist", domain.ErrConflict, key.CustomerID)
+		}
+	}
+	row := &apiKeyRow{key: *key, created: r.db.created()}
+	row.key.Revoked = false
+	row.key.CreatedAt = row.created.String()
+	r.db.apiKeys[key.ID] = row
+	return nil
+}
+
+func (r *APIKeyRepository) GetByHash(ctx context.Context, hash string) (*domain.APIKey, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	for _, row := range r.db.apiKeys {
+		if row.key.KeyHash == hash && !row.key.Revoked {
+			key := row.key
+			return &key, nil
+		}
+	}
+	return nil, domain.ErrAPIKeyNotFound
+}
+
+func (r *APIKeyRepository) List(ctx context.Context) ([]*domain.APIKey, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	rows := make([]*apiKeyRow, 0, len(r.db.apiKeys))
+	for _, row := range r.db.apiKeys {
+		rows = append(rows, row)
+	}
+	slices.SortFunc(rows, func(a, b *apiKeyRow) int { return a.created.compare(b.created) })
+
+	keys := []*domain.APIKey{}
+	for _, row := range rows {
+		key := row.key
+		keys = append(keys, &key)
+	}
+	return keys, nil
+}
+
+func (r *APIKeyRepository) Revoke(ctx context.Context, id string) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	row, ok := r.db.apiKeys[id]
+	if !ok || row.key.Revoked {
+		return domain.ErrAPIKeyNotFound
+	}
+	row.key.Revoked = true
+	return nil
+}
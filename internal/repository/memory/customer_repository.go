@@ -0,0 +1,101 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"ledger/internal/domain"
+)
+
+type customerRow struct {
+	customer domain.Customer
+	created  stamp
+}
+
+type CustomerRepository struct {
+	db *DB
+}
+
+func NewCustomerRepository(db *DB) *CustomerRepository {
+	return &CustomerRepository{db: db}
+}
+
+func (r *CustomerRepository) Create(ctx context.Context, customer *domain.Customer) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.customers[customer.ID]; ok {
+		return fmt.Errorf("%w: customer %s already exists", domain.ErrConflict, customer.ID)
+	}
+	row := &customerRow{customer: *customer, created: r.db.created()}
+	row.customer.CreatedAt = row.created.String()
+	r.db.customers[customer.ID] = row
+	return nil
+}
+
+func (r *CustomerRepository) GetByID(ctx context.Context, id string) (*domain.Customer, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	row, ok := r.db.customers[id]
+	if !ok {
+		return nil, domain.ErrCustomerNotFound
+	}
+	customer := row.customer
+	return &customer, nil
+}
+
+func (r *CustomerRepository) GetAll(ctx context.Context) ([]*domain.Customer, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	rows := make([]*customerRow, 0, len(r.db.customers))
+	for _, row := range r.db.customers {
+		rows = append(rows, row)
+	}
+	slices.SortFunc(rows, func(a, b *customerRow) int { return a.created.compare(b.created) })
+
+	var customers []*domain.Customer
+	for _, row := range rows {
+		customer := row.customer
+		customers = append(customers, &customer)
+	}
+	return customers, nil
+}
+
+func (r *CustomerRepository) Update(ctx context.Context, customer *domain.Customer) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	row, ok := r.db.customers[customer.ID]
+	if !ok {
+		return domain.ErrCustomerNotFound
+	}
+	row.customer.Name = customer.Name
+	row.customer.Email = customer.Email
+	return nil
+}
+
+// Delete removes the customer and its API keys. Customers that still own
+// accounts cannot be deleted.
+func (r *CustomerRepository) Delete(ctx context.Context, id string) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.customers[id]; !ok {
+		return domain.ErrCustomerNotFound
+	}
+	for _, row := range r.db.accounts {
+		if row.account.CustomerID == id {
+			return fmt.Errorf("%w: customer %s still owns accounts", domain.ErrConflict, id)
+		}
+	}
+	for keyID, row := range r.db.apiKeys {
+		if row.key.CustomerID == id {
+			delete(r.db.apiKeys, keyID)
+		}
+	}
+	delete(r.db.customers, id)
+	return nil
+}
@@ -0,0 +1,68 @@
+// Package memory implements the repositories in process memory, for running
+// the API and its tests without Postgres or MongoDB. The repositories keep the
+// error semantics of the real ones: missing rows are reported with the same
+// domain errors, and broken constraints as domain.ErrConflict or
+// domain.ErrValidation. Every call is atomic and works on copies, so callers
+// never share state with the store.
+package memory
+
+import (
+	"cmp"
+	"sync"
+	"time"
+)
+
+// timestampLayout renders timestamps the way Postgres casts them to text, so
+// they sort in time order
+const timestampLayout = "2006-01-02 15:04:05.000000"
+
+// DB holds the tables of the relational repositories. Repositories built on
+// one DB see each other's rows, so references between accounts, customers and
+// API keys are enforced as the Postgres foreign keys do.
+type DB struct {
+	mu            sync.RWMutex
+	accounts      map[string]*accountRow
+	customers     map[string]*customerRow
+	apiKeys       map[string]*apiKeyRow
+	subscriptions map[string]*subscriptionRow
+	deliveries    map[string]*deliveryRow
+	// seq orders rows created within the same clock tick
+	seq int64
+	// accountSeq numbers accounts created without an ID, like a SERIAL column
+	accountSeq int64
+	now        func() time.Time
+}
+
+func NewDB() *DB {
+	return &DB{
+		accounts:      make(map[string]*accountRow),
+		customers:     make(map[string]*customerRow),
+		apiKeys:       make(map[string]*apiKeyRow),
+		subscriptions: make(map[string]*subscriptionRow),
+		deliveries:    make(map[string]*deliveryRow),
+		now:           time.Now,
+	}
+}
+
+// created stamps a new row. Callers hold mu.
+func (db *DB) created() stamp {
+	db.seq++
+	return stamp{at: db.now().UTC(), seq: db.seq}
+}
+
+// stamp is when a row was created
+type stamp struct {
+	at  time.Time
+	seq int64
+}
+
+func (s stamp) String() string {
+	return s.at.Format(timestampLayout)
+}
+
+func (s stamp) compare(o stamp) int {
+	if c := s.at.Compare(o.at); c != 0 {
+		return c
+	}
+	return cmp.Compare(s.seq, o.seq)
+}
@@ -0,0 +1,146 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"ledger/internal/domain"
+)
+
+// LedgerRepository keeps ledger entries the way the MongoDB collection does,
+// with transaction IDs unique and entries checked against its validator
+type LedgerRepository struct {
+	mu      sync.RWMutex
+	entries []domain.LedgerEntry
+	// recorded holds the transaction IDs already saved
+	recorded map[string]bool
+}
+
+func NewLedgerRepository() *LedgerRepository {
+	return &LedgerRepository{recorded: make(map[string]bool)}
+}
+
+func (r *LedgerRepository) SaveEntry(ctx context.Context, entry *domain.LedgerEntry) error {
+	if entry.TransactionID == "" || entry.Amount < 0 {
+		return fmt.Errorf("%w: ledger entry needs a transaction ID and a non-negative amount", domain.ErrValidation)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.recorded[entry.TransactionID] {
+		return fmt.Errorf("%w: transaction %s is already recorded", domain.ErrConflict, entry.TransactionID)
+	}
+	r.recorded[entry.TransactionID] = true
+	r.entries = append(r.entries, *entry)
+	return nil
+}
+
+func (r *LedgerRepository) GetEntryByTransactionID(ctx context.Context, transactionID string) (*domain.LedgerEntry, error) {
+	entries := r.find(func(e *domain.LedgerEntry) bool { return e.TransactionID == transactionID })
+	if len(entries) == 0 {
+		return nil, domain.ErrTransactionNotFound
+	}
+	return entries[0], nil
+}
+
+func (r *LedgerRepository) GetEntriesByAccountID(ctx context.Context, accountID int64) ([]*domain.LedgerEntry, error) {
+	entries := r.find(func(e *domain.LedgerEntry) bool {
+		return e.FromAccountID == accountID || e.ToAccountID == accountID
+	})
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	return entries, nil
+}
+
+// ListEntriesByAccountID returns one page of an account's ledger entries, newest first,
+// using keyset pagination on (timestamp, transaction_id)
+func (r *LedgerRepository) ListEntriesByAccountID(ctx context.Context, accountID int64, filter domain.HistoryFilter) (*domain.LedgerPage, error) {
+	cursor, err := domain.DecodeCursor(filter.Cursor)
+	if err != nil {
+		return nil, err
+	}
+	limit := domain.ClampPageSize(filter.Limit)
+
+	entries := r.find(func(e *domain.LedgerEntry) bool {
+		return matchesHistory(e, accountID, filter, cursor)
+	})
+	page := &domain.LedgerPage{Entries: []*domain.LedgerEntry{}}
+	for i, entry := range entries {
+		if i == limit {
+			last := entries[limit-1]
+			page.NextCursor = domain.EncodeCursor(domain.Cursor{Key: last.Timestamp, ID: last.TransactionID})
+			break
+		}
+		page.Entries = append(page.Entries, entry)
+	}
+	return page, nil
+}
+
+// find returns copies of the entries matching keep, newest first
+func (r *LedgerRepository) find(keep func(*domain.LedgerEntry) bool) []*domain.LedgerEntry {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var entries []*domain.LedgerEntry
+	for i := range r.entries {
+		if keep(&r.entries[i]) {
+			entry := r.entries[i]
+			entries = append(entries, &entry)
+		}
+	}
+	slices.SortStableFunc(entries, func(a, b *domain.LedgerEntry) int {
+		if c := cmp.Compare(b.Timestamp, a.Timestamp); c != 0 {
+			return c
+		}
+		return cmp.Compare(b.TransactionID, a.TransactionID)
+	})
+	return entries
+}
+
+// matchesHistory applies the same conditions as the MongoDB history query
+func matchesHistory(e *domain.LedgerEntry, accountID int64, filter domain.HistoryFilter, cursor *domain.Cursor) bool {
+	incoming, outgoing := e.ToAccountID == accountID, e.FromAccountID == accountID
+	if filter.CounterpartyID != 0 {
+		incoming = incoming && e.FromAccountID == filter.CounterpartyID
+		outgoing = outgoing && e.ToAccountID == filter.CounterpartyID
+	}
+	switch filter.Direction {
+	case domain.DirectionIncoming:
+		outgoing = false
+	case domain.DirectionOutgoing:
+		incoming = false
+	}
+	if !incoming && !outgoing {
+		return false
+	}
+
+	if !filter.From.IsZero() && e.Timestamp < filter.From.UTC().Format(time.RFC3339) {
+		return false
+	}
+	if !filter.To.IsZero() && e.Timestamp > filter.To.UTC().Format(time.RFC3339) {
+		return false
+	}
+	if filter.MinAmount > 0 && e.Amount < filter.MinAmount {
+		return false
+	}
+	if filter.MaxAmount > 0 && e.Amount > filter.MaxAmount {
+		return false
+	}
+	if filter.Currency != "" && e.Currency != filter.Currency {
+		return false
+	}
+	if filter.Status != "" && e.Status != filter.Status {
+		return false
+	}
+
+	if cursor != nil {
+		return e.Timestamp < cursor.Key || (e.Timestamp == cursor.Key && e.TransactionID < cursor.ID)
+	}
+	return true
+}
@@ -0,0 +1,52 @@
+package memory_test
+
+import (
+	"context"
+	"testing"
+
+	"ledger/internal/domain"
+	"ledger/internal/repository/memory"
+	"ledger/internal/repository/repotest"
+)
+
+func TestAccountRepository(t *testing.T) {
+	repotest.TestAccountRepository(t, func(t *testing.T) repotest.AccountStore {
+		db := memory.NewDB()
+		customers := memory.NewCustomerRepository(db)
+		return repotest.AccountStore{
+			Accounts: memory.NewAccountRepository(db),
+			AddCustomer: func(ctx context.Context, id string) error {
+				return customers.Create(ctx, &domain.Customer{ID: id, Name: id})
+			},
+		}
+	})
+}
+
+func TestLedgerRepository(t *testing.T) {
+	repotest.TestLedgerRepository(t, func(t *testing.T) domain.LedgerRepository {
+		return memory.NewLedgerRepository()
+	})
+}
+
+func TestCustomerRepository(t *testing.T) {
+	repotest.TestCustomerRepository(t, func(t *testing.T) repotest.CustomerStore {
+		db := memory.NewDB()
+		return repotest.CustomerStore{
+			Customers: memory.NewCustomerRepository(db),
+			Accounts:  memory.NewAccountRepository(db),
+			APIKeys:   memory.NewAPIKeyRepository(db),
+		}
+	})
+}
+
+func TestAPIKeyRepository(t *testing.T) {
+	repotest.TestAPIKeyRepository(t, func(t *testing.T) domain.APIKeyRepository {
+		return memory.NewAPIKeyRepository(memory.NewDB())
+	})
+}
+
+func TestWebhookRepository(t *testing.T) {
+	repotest.TestWebhookRepository(t, func(t *testing.T) domain.WebhookRepository {
+		return memory.NewWebhookRepository(memory.NewDB())
+	})
+}
@@ -0,0 +1,216 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"ledger/internal/domain"
+)
+
+type subscriptionRow struct {
+	sub     domain.WebhookSubscription
+	created stamp
+}
+
+type deliveryRow struct {
+	delivery domain.WebhookDelivery
+	created  stamp
+}
+
+type WebhookRepository struct {
+	db *DB
+}
+
+func NewWebhookRepository(db *DB) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+func (r *WebhookRepository) CreateSubscription(ctx context.Context, sub *domain.WebhookSubscription) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.subscriptions[sub.ID]; ok {
+		return fmt.Errorf("%w: subscription %s already exists", domain.ErrConflict, sub.ID)
+	}
+	row := &subscriptionRow{sub: *sub, created: r.db.created()}
+	row.sub.EventTypes = slices.Clone(sub.EventTypes)
+	row.sub.CreatedAt = row.created.String()
+	r.db.subscriptions[sub.ID] = row
+	return nil
+}
+
+func (r *WebhookRepository) GetSubscription(ctx context.Context, id string) (*domain.WebhookSubscription, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	row, ok := r.db.subscriptions[id]
+	if !ok {
+		return nil, domain.ErrSubscriptionNotFound
+	}
+	return row.copy(), nil
+}
+
+func (r *WebhookRepository) ListSubscriptions(ctx context.Context) ([]*domain.WebhookSubscription, error) {
+	return r.subscriptionsWhere(func(*subscriptionRow) bool { return true }), nil
+}
+
+func (r *WebhookRepository) ListSubscriptionsForEvent(ctx context.Context, eventType string) ([]*domain.WebhookSubscription, error) {
+	return r.subscriptionsWhere(func(row *subscriptionRow) bool {
+		return row.sub.Active && slices.Contains(row.sub.EventTypes, eventType)
+	}), nil
+}
+
+// subscriptionsWhere returns copies of the subscriptions matching keep, oldest first
+func (r *WebhookRepository) subscriptionsWhere(keep func(*subscriptionRow) bool) []*domain.WebhookSubscription {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	var rows []*subscriptionRow
+	for _, row := range r.db.subscriptions {
+		if keep(row) {
+			rows = append(rows, row)
+		}
+	}
+	slices.SortFunc(rows, func(a, b *subscriptionRow) int { return a.created.compare(b.created) })
+
+	subs := []*domain.WebhookSubscription{}
+	for _, row := range rows {
+		subs = append(subs, row.copy())
+	}
+	return subs
+}
+
+// DeleteSubscription removes the subscription along with its deliveries
+func (r *WebhookRepository) DeleteSubscription(ctx context.Context, id string) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.subscriptions[id]; !ok {
+		return domain.ErrSubscriptionNotFound
+	}
+	for deliveryID, row := range r.db.deliveries {
+		if row.delivery.SubscriptionID == id {
+			delete(r.db.deliveries, deliveryID)
+		}
+	}
+	delete(r.db.subscriptions, id)
+	return nil
+}
+
+func (r *WebhookRepository) CreateDelivery(ctx context.Context, d *domain.WebhookDelivery) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.deliveries[d.ID]; ok {
+		return fmt.Errorf("%w: delivery %s already exists", domain.ErrConflict, d.ID)
+	}
+	if _, ok := r.db.subscriptions[d.SubscriptionID]; !ok {
+		return fmt.Errorf("%w: subscription %s does not exist", domain.ErrConflict, d.SubscriptionID)
+	}
+	row := &deliveryRow{created: r.db.created()}
+	row.delivery = domain.WebhookDelivery{
+		ID:             d.ID,
+		SubscriptionID: d.SubscriptionID,
+		EventID:        d.EventID,
+		EventType:      d.EventType,
+		Payload:        slices.Clone(d.Payload),
+		Status:         d.Status,
+		Attempts:       d.Attempts,
+		CreatedAt:      row.created.String(),
+		UpdatedAt:      row.created.String(),
+		NextAttemptAt:  d.NextAttemptAt,
+	}
+	r.db.deliveries[d.ID] = row
+	return nil
+}
+
+func (r *WebhookRepository) UpdateDelivery(ctx context.Context, d *domain.WebhookDelivery) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	row, ok := r.db.deliveries[d.ID]
+	if !ok {
+		return domain.ErrDeliveryNotFound
+	}
+	row.delivery.Status = d.Status
+	row.delivery.Attempts = d.Attempts
+	row.delivery.ResponseCode = d.ResponseCode
+	row.delivery.LastError = d.LastError
+	row.delivery.NextAttemptAt = d.NextAttemptAt
+	row.delivery.UpdatedAt = r.db.now().UTC().Format(timestampLayout)
+	return nil
+}
+
+func (r *WebhookRepository) GetDelivery(ctx context.Context, id string) (*domain.WebhookDelivery, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	row, ok := r.db.deliveries[id]
+	if !ok {
+		return nil, domain.ErrDeliveryNotFound
+	}
+	return row.copy(), nil
+}
+
+// ListDeliveries returns the latest deliveries of a subscription, newest first
+func (r *WebhookRepository) ListDeliveries(ctx context.Context, subscriptionID string) ([]*domain.WebhookDelivery, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	var rows []*deliveryRow
+	for _, row := range r.db.deliveries {
+		if row.delivery.SubscriptionID == subscriptionID {
+			rows = append(rows, row)
+		}
+	}
+	slices.SortFunc(rows, func(a, b *deliveryRow) int { return b.created.compare(a.created) })
+	if len(rows) > domain.MaxPageSize {
+		rows = rows[:domain.MaxPageSize]
+	}
+
+	deliveries := []*domain.WebhookDelivery{}
+	for _, row := range rows {
+		deliveries = append(deliveries, row.copy())
+	}
+	return deliveries, nil
+}
+
+// ClaimDueDeliveries returns the pending deliveries due longest ago first
+func (r *WebhookRepository) ClaimDueDeliveries(ctx context.Context, now, until time.Time, limit int) ([]*domain.WebhookDelivery, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	var rows []*deliveryRow
+	for _, row := range r.db.deliveries {
+		if row.delivery.Status == domain.DeliveryPending && !row.delivery.NextAttemptAt.After(now) {
+			rows = append(rows, row)
+		}
+	}
+	slices.SortFunc(rows, func(a, b *deliveryRow) int {
+		return a.delivery.NextAttemptAt.Compare(b.delivery.NextAttemptAt)
+	})
+	if len(rows) > limit {
+		rows = rows[:limit]
+	}
+
+	deliveries := []*domain.WebhookDelivery{}
+	for _, row := range rows {
+		row.delivery.NextAttemptAt = until
+		deliveries = append(deliveries, row.copy())
+	}
+	return deliveries, nil
+}
+
+func (row *subscriptionRow) copy() *domain.WebhookSubscription {
+	sub := row.sub
+	sub.EventTypes = slices.Clone(row.sub.EventTypes)
+	return &sub
+}
+
+func (row *deliveryRow) copy() *domain.WebhookDelivery {
+	d := row.delivery
+	d.Payload = slices.Clone(row.delivery.Payload)
+	return &d
+}
@@ -0,0 +1,84 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Bootstrap creates the ledger collection with its validator and indexes, or
+// brings an existing collection up to date. It is idempotent and safe to run
+// on every start.
+func (r *LedgerRepository) Bootstrap(ctx context.Context) error {
+	db := r.collection.Database()
+	name := r.collection.Name()
+
+	validator := bson.M{"$jsonSchema": ledgerSchema()}
+	err := db.CreateCollection(ctx, name, options.CreateCollection().
+		SetValidator(validator).
+		SetValidationLevel("moderate").
+		SetValidationAction("error"))
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) && cmdErr.Name == "NamespaceExists" {
+		// moderate keeps updates to documents written before the validator working
+		err = db.RunCommand(ctx, bson.D{
+			{Key: "collMod", Value: name},
+			{Key: "validator", Value: validator},
+			{Key: "validationLevel", Value: "moderate"},
+			{Key: "validationAction", Value: "error"},
+		}).Err()
+	}
+	if err != nil {
+		return fmt.Errorf("apply ledger validator: %w", err)
+	}
+
+	if _, err := r.collection.Indexes().CreateMany(ctx, ledgerIndexes()); err != nil {
+		return fmt.Errorf("create ledger indexes: %w", err)
+	}
+	return nil
+}
+
+// ledgerIndexes serve the history queries: each side of the from/to $or uses
+// its own index, already ordered for the (timestamp, transaction_id) keyset
+// sort. transaction_id is unique so a transfer is never recorded twice.
+func ledgerIndexes() []mongo.IndexModel {
+	return []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "from_account_id", Value: 1}, {Key: "timestamp", Value: -1}, {Key: "transaction_id", Value: -1}},
+			Options: options.Index().SetName("from_account_id_timestamp"),
+		},
+		{
+			Keys:    bson.D{{Key: "to_account_id", Value: 1}, {Key: "timestamp", Value: -1}, {Key: "transaction_id", Value: -1}},
+			Options: options.Index().SetName("to_account_id_timestamp"),
+		},
+		{
+			Keys:    bson.D{{Key: "transaction_id", Value: 1}},
+			Options: options.Index().SetName("transaction_id_unique").SetUnique(true),
+		},
+	}
+}
+
+// ledgerSchema is the $jsonSchema of domain.LedgerEntry
+func ledgerSchema() bson.M {
+	str := bson.M{"bsonType": "string"}
+	accountID := bson.M{"bsonType": bson.A{"int", "long"}}
+	return bson.M{
+		"bsonType": "object",
+		"required": bson.A{"id", "transaction_id", "from_account_id", "to_account_id", "amount", "currency", "status", "timestamp"},
+		"properties": bson.M{
+			"id":              str,
+			"transaction_id":  bson.M{"bsonType": "string", "minLength": 1},
+			"from_account_id": accountID,
+			"to_account_id":   accountID,
+			"amount":          bson.M{"bsonType": bson.A{"double", "int", "long", "decimal"}, "minimum": 0},
+			"currency":        str,
+			"status":          str,
+			"timestamp":       str,
+			"correlation_id":  str,
+		},
+	}
+}
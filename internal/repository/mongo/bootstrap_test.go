@@ -0,0 +1,45 @@
+package mongo
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"ledger/internal/domain"
+)
+
+// The validator must accept every document SaveEntry writes
+func TestLedgerSchema_MatchesLedgerEntry(t *testing.T) {
+	schema := ledgerSchema()
+	required := schema["required"].(bson.A)
+	properties := schema["properties"].(bson.M)
+
+	typ := reflect.TypeOf(domain.LedgerEntry{})
+	for i := 0; i < typ.NumField(); i++ {
+		name, opts, _ := strings.Cut(typ.Field(i).Tag.Get("bson"), ",")
+		assert.Contains(t, properties, name, "field %s has no schema", name)
+		if opts == "omitempty" {
+			assert.NotContains(t, required, name, "optional field %s must not be required", name)
+		} else {
+			assert.Contains(t, required, name, "field %s should be required", name)
+		}
+	}
+	assert.Len(t, properties, typ.NumField(), "schema describes fields LedgerEntry does not have")
+}
+
+func TestLedgerIndexes(t *testing.T) {
+	indexes := ledgerIndexes()
+
+	var unique []string
+	for _, idx := range indexes {
+		if idx.Options.Unique != nil && *idx.Options.Unique {
+			unique = append(unique, *idx.Options.Name)
+		}
+	}
+	assert.Equal(t, []string{"transaction_id_unique"}, unique)
+	assert.Equal(t, bson.D{{Key: "from_account_id", Value: 1}, {Key: "timestamp", Value: -1}, {Key: "transaction_id", Value: -1}}, indexes[0].Keys)
+	assert.Equal(t, bson.D{{Key: "to_account_id", Value: 1}, {Key: "timestamp", Value: -1}, {Key: "transaction_id", Value: -1}}, indexes[1].Keys)
+}
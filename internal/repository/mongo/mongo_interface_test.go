@@ -17,7 +17,7 @@ type LedgerRepositoryt struct {
 	collection MongoCollection
 }
 
-func (r LedgerRepositoryt) SaveEntry(todo context.Context, entry *domain.LedgerEntry) interface{} {
+func (r LedgerRepositoryt) SaveEntry(todo context.Context, entry *domain.LedgerEntry) error {
 	_, err := r.collection.InsertOne(todo, entry)
 	if err != nil {
 		return err
@@ -0,0 +1,54 @@
+package mongo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"ledger/internal/domain"
+)
+
+func TestHistoryQuery_AccountOnly(t *testing.T) {
+	got := historyQuery(1, domain.HistoryFilter{}, nil)
+
+	assert.Equal(t, bson.M{"$or": []bson.M{
+		{"from_account_id": int64(1)},
+		{"to_account_id": int64(1)},
+	}}, got)
+}
+
+func TestHistoryQuery_AllFilters(t *testing.T) {
+	filter := domain.HistoryFilter{
+		From:           time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		To:             time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+		MinAmount:      5,
+		MaxAmount:      500,
+		Currency:       "EUR",
+		Status:         "SUCCESS",
+		CounterpartyID: 7,
+		Direction:      domain.DirectionOutgoing,
+	}
+	cursor := &domain.Cursor{Key: "2024-01-15T00:00:00Z", ID: "tx9"}
+
+	got := historyQuery(1, filter, cursor)
+
+	assert.Equal(t, bson.M{"$and": []bson.M{
+		{"from_account_id": int64(1), "to_account_id": int64(7)},
+		{"timestamp": bson.M{"$gte": "2024-01-01T00:00:00Z", "$lte": "2024-02-01T00:00:00Z"}},
+		{"amount": bson.M{"$gte": 5.0, "$lte": 500.0}},
+		{"currency": "EUR"},
+		{"status": "SUCCESS"},
+		{"$or": []bson.M{
+			{"timestamp": bson.M{"$lt": "2024-01-15T00:00:00Z"}},
+			{"timestamp": "2024-01-15T00:00:00Z", "transaction_id": bson.M{"$lt": "tx9"}},
+		}},
+	}}, got)
+}
+
+func TestHistoryQuery_Incoming(t *testing.T) {
+	got := historyQuery(1, domain.HistoryFilter{Direction: domain.DirectionIncoming}, nil)
+
+	assert.Equal(t, bson.M{"to_account_id": int64(1)}, got)
+}
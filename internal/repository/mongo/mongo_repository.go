@@ -2,7 +2,10 @@ package mongo
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
+
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -21,12 +24,27 @@ func NewLedgerRepository(client *mongo.Client, dbName, collectionName string) *L
 
 func (r *LedgerRepository) SaveEntry(ctx context.Context, entry *domain.LedgerEntry) error {
 	_, err := r.collection.InsertOne(ctx, entry)
+	if mongo.IsDuplicateKeyError(err) {
+		return fmt.Errorf("%w: transaction %s is already recorded", domain.ErrConflict, entry.TransactionID)
+	}
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
+func (r *LedgerRepository) GetEntryByTransactionID(ctx context.Context, transactionID string) (*domain.LedgerEntry, error) {
+	var entry domain.LedgerEntry
+	err := r.collection.FindOne(ctx, bson.M{"transaction_id": transactionID}).Decode(&entry)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, domain.ErrTransactionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
 func (r *LedgerRepository) GetEntriesByAccountID(ctx context.Context, accountID int64) ([]*domain.LedgerEntry, error) {
 	filter := bson.M{
 		"$or": []bson.M{
@@ -59,3 +77,111 @@ func (r *LedgerRepository) GetEntriesByAccountID(ctx context.Context, accountID
 
 	return entries, cursor.Err()
 }
+
+// ListEntriesByAccountID returns one page of an account's ledger entries, newest first,
+// using keyset pagination on (timestamp, transaction_id)
+func (r *LedgerRepository) ListEntriesByAccountID(ctx context.Context, accountID int64, filter domain.HistoryFilter) (*domain.LedgerPage, error) {
+	cursor, err := domain.DecodeCursor(filter.Cursor)
+	if err != nil {
+		return nil, err
+	}
+	limit := domain.ClampPageSize(filter.Limit)
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "timestamp", Value: -1}, {Key: "transaction_id", Value: -1}}).
+		SetLimit(int64(limit + 1))
+
+	mc, err := r.collection.Find(ctx, historyQuery(accountID, filter, cursor), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer func(mc *mongo.Cursor, ctx context.Context) {
+		err := mc.Close(ctx)
+		if err != nil {
+			fmt.Printf("Error closing cursor: %v\n", err)
+		}
+	}(mc, ctx)
+
+	page := &domain.LedgerPage{Entries: []*domain.LedgerEntry{}}
+	for mc.Next(ctx) {
+		if len(page.Entries) == limit {
+			last := page.Entries[limit-1]
+			page.NextCursor = domain.EncodeCursor(domain.Cursor{Key: last.Timestamp, ID: last.TransactionID})
+			break
+		}
+		var entry domain.LedgerEntry
+		if err := mc.Decode(&entry); err != nil {
+			return nil, err
+		}
+		page.Entries = append(page.Entries, &entry)
+	}
+
+	return page, mc.Err()
+}
+
+// historyQuery builds the Mongo filter for an account's history page
+func historyQuery(accountID int64, filter domain.HistoryFilter, cursor *domain.Cursor) bson.M {
+	var clauses []bson.M
+
+	switch {
+	case filter.CounterpartyID != 0 && filter.Direction == domain.DirectionIncoming:
+		clauses = append(clauses, bson.M{"from_account_id": filter.CounterpartyID, "to_account_id": accountID})
+	case filter.CounterpartyID != 0 && filter.Direction == domain.DirectionOutgoing:
+		clauses = append(clauses, bson.M{"from_account_id": accountID, "to_account_id": filter.CounterpartyID})
+	case filter.CounterpartyID != 0:
+		clauses = append(clauses, bson.M{"$or": []bson.M{
+			{"from_account_id": accountID, "to_account_id": filter.CounterpartyID},
+			{"from_account_id": filter.CounterpartyID, "to_account_id": accountID},
+		}})
+	case filter.Direction == domain.DirectionIncoming:
+		clauses = append(clauses, bson.M{"to_account_id": accountID})
+	case filter.Direction == domain.DirectionOutgoing:
+		clauses = append(clauses, bson.M{"from_account_id": accountID})
+	default:
+		clauses = append(clauses, bson.M{"$or": []bson.M{
+			{"from_account_id": accountID},
+			{"to_account_id": accountID},
+		}})
+	}
+
+	timestamp := bson.M{}
+	if !filter.From.IsZero() {
+		timestamp["$gte"] = filter.From.UTC().Format(time.RFC3339)
+	}
+	if !filter.To.IsZero() {
+		timestamp["$lte"] = filter.To.UTC().Format(time.RFC3339)
+	}
+	if len(timestamp) > 0 {
+		clauses = append(clauses, bson.M{"timestamp": timestamp})
+	}
+
+	amount := bson.M{}
+	if filter.MinAmount > 0 {
+		amount["$gte"] = filter.MinAmount
+	}
+	if filter.MaxAmount > 0 {
+		amount["$lte"] = filter.MaxAmount
+	}
+	if len(amount) > 0 {
+		clauses = append(clauses, bson.M{"amount": amount})
+	}
+
+	if filter.Currency != "" {
+		clauses = append(clauses, bson.M{"currency": filter.Currency})
+	}
+	if filter.Status != "" {
+		clauses = append(clauses, bson.M{"status": filter.Status})
+	}
+
+	if cursor != nil {
+		clauses = append(clauses, bson.M{"$or": []bson.M{
+			{"timestamp": bson.M{"$lt": cursor.Key}},
+			{"timestamp": cursor.Key, "transaction_id": bson.M{"$lt": cursor.ID}},
+		}})
+	}
+
+	if len(clauses) == 1 {
+		return clauses[0]
+	}
+	return bson.M{"$and": clauses}
+}
@@ -84,7 +84,7 @@ func TestSaveEntry_Success(t *testing.T) {
 	mockCol.On("InsertOne", mock.Anything, entry).Return(nil, nil)
 
 	err := repo.SaveEntry(context.TODO(), entry)
-	assert.NoError(t, err.(error))
+	assert.NoError(t, err)
 	mockCol.AssertExpectations(t)
 }
 
@@ -0,0 +1,37 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"ledger/internal/domain"
+	"ledger/internal/repository/repotest"
+)
+
+// TestLedgerRepository runs the ledger suite against the server in
+// TEST_MONGO_URI, each subtest in a database of its own, and is skipped without it
+func TestLedgerRepository(t *testing.T) {
+	uri := os.Getenv("TEST_MONGO_URI")
+	if uri == "" {
+		t.Skip("TEST_MONGO_URI is not set")
+	}
+	ctx := context.Background()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Disconnect(ctx) })
+
+	repotest.TestLedgerRepository(t, func(t *testing.T) domain.LedgerRepository {
+		db := fmt.Sprintf("ledger_test_%d", time.Now().UnixNano())
+		t.Cleanup(func() { client.Database(db).Drop(ctx) })
+		repo := NewLedgerRepository(client, db, "ledger")
+		require.NoError(t, repo.Bootstrap(ctx))
+		return repo
+	})
+}
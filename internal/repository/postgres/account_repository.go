@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
 
 	"ledger/internal/domain"
 )
@@ -19,7 +20,7 @@ func NewAccountRepository(db *sql.DB) *AccountRepository {
 
 func (r *AccountRepository) GetAll(ctx context.Context) ([]*domain.Account, error) {
 	rows, err := r.db.QueryContext(ctx, `
-		SELECT id, owner_name, balance
+		SELECT id, owner_name, balance, currency, customer_id, status
 		FROM accounts
 	`)
 	if err != nil {
@@ -27,67 +28,215 @@ func (r *AccountRepository) GetAll(ctx context.Context) ([]*domain.Account, erro
 	}
 	defer rows.Close()
 
-	var accounts []*domain.Account
+	return scanAccounts(rows)
+}
+
+// accountSortColumns whitelists the columns accounts may be ordered by
+var accountSortColumns = map[string]string{
+	domain.SortByID:        "id",
+	domain.SortByOwnerName: "owner_name",
+	domain.SortByBalance:   "balance",
+	domain.SortByCreatedAt: "created_at",
+}
+
+// List returns one page of accounts using keyset pagination on (sort column, id)
+func (r *AccountRepository) List(ctx context.Context, opts domain.AccountListOptions) (*domain.AccountPage, error) {
+	sortBy := opts.SortBy
+	if sortBy == "" {
+		sortBy = domain.SortByID
+	}
+	col, ok := accountSortColumns[sortBy]
+	if !ok {
+		return nil, fmt.Errorf("unsupported sort field %q", opts.SortBy)
+	}
+	cursor, err := domain.DecodeCursor(opts.Cursor)
+	if err != nil {
+		return nil, err
+	}
+	if cursor != nil && cursor.Sort != sortBy {
+		return nil, domain.ErrInvalidCursor
+	}
+	limit := domain.ClampPageSize(opts.Limit)
+
+	dir, op := "ASC", ">"
+	if opts.Desc {
+		dir, op = "DESC", "<"
+	}
+
+	query := fmt.Sprintf(`
+		SELECT id, owner_name, balance, currency, customer_id, status, %s::text
+		FROM accounts`, col)
+	var args []any
+	var conds []string
+	if opts.CustomerID != "" {
+		args = append(args, opts.CustomerID)
+		conds = append(conds, fmt.Sprintf("customer_id = $%d", len(args)))
+	}
+	if cursor != nil {
+		args = append(args, cursor.Key, cursor.ID)
+		conds = append(conds, fmt.Sprintf("(%s, id) %s ($%d, $%d)", col, op, len(args)-1, len(args)))
+	}
+	if len(conds) > 0 {
+		query += `
+		WHERE ` + strings.Join(conds, " AND ")
+	}
+	query += fmt.Sprintf(`
+		ORDER BY %s %s, id %s
+		LIMIT $%d`, col, dir, dir, len(args)+1)
+	// Fetch one extra row to find out whether another page exists
+	args = append(args, limit+1)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	page := &domain.AccountPage{Accounts: []*domain.Account{}}
+	var lastKey string
 	for rows.Next() {
 		var account domain.Account
-		if err := rows.Scan(&account.ID, &account.OwnerName, &account.Balance); err != nil {
+		var currency, customerID, status sql.NullString
+		var key string
+		if err := rows.Scan(&account.ID, &account.OwnerName, &account.Balance, &currency, &customerID, &status, &key); err != nil {
 			return nil, err
 		}
-		accounts = append(accounts, &account)
+		if len(page.Accounts) == limit {
+			page.NextCursor = domain.EncodeCursor(domain.Cursor{Sort: sortBy, Key: lastKey, ID: page.Accounts[limit-1].ID})
+			break
+		}
+		account.Currency = currency.String
+		account.CustomerID = customerID.String
+		account.Status = status.String
+		page.Accounts = append(page.Accounts, &account)
+		lastKey = key
 	}
 
 	if err := rows.Err(); err != nil {
 		return nil, err
 	}
 
-	return accounts, nil
+	return page, nil
+}
+
+func (r *AccountRepository) GetByCustomerID(ctx context.Context, customerID string) ([]*domain.Account, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, owner_name, balance, currency, customer_id, status
+		FROM accounts
+		WHERE customer_id = $1
+	`, customerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanAccounts(rows)
 }
 
 func (r *AccountRepository) Delete(ctx context.Context, id string) error {
-	_, err := r.db.ExecContext(ctx, `
+	res, err := r.db.ExecContext(ctx, `
 		DELETE FROM accounts
 		WHERE id = $1
 	`, id)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return fmt.Errorf(domain.ErrAccountNotFound)
-		}
-		return err
+		return mapError(err)
 	}
-	return nil
+	return expectAffected(res, domain.ErrAccountNotFound)
 }
 
 func (r *AccountRepository) Create(ctx context.Context, account *domain.Account) error {
+	if account.ID == "" {
+		// The id column is a SERIAL
+		err := r.db.QueryRowContext(ctx, `
+			INSERT INTO accounts (owner_name, balance, currency, customer_id, status)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING id
+		`, account.OwnerName, account.Balance, account.Currency, nullString(account.CustomerID), account.Status).Scan(&account.ID)
+		return mapError(err)
+	}
 	_, err := r.db.ExecContext(ctx, `
-		INSERT INTO accounts (id, owner_name, balance)
-		VALUES ($1, $2, $3)
-	`, account.ID, account.OwnerName, account.Balance)
-	return err
+		INSERT INTO accounts (id, owner_name, balance, currency, customer_id, status)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, account.ID, account.OwnerName, account.Balance, account.Currency, nullString(account.CustomerID), account.Status)
+	return mapError(err)
 }
 
 func (r *AccountRepository) GetByID(ctx context.Context, id string) (*domain.Account, error) {
 	row := r.db.QueryRowContext(ctx, `
-		SELECT id, owner_name, balance
+		SELECT id, owner_name, balance, currency, customer_id, status
 		FROM accounts
 		WHERE id = $1
 	`, id)
 
-	var account domain.Account
-	err := row.Scan(&account.ID, &account.OwnerName, &account.Balance)
+	account, err := scanAccount(row)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return &domain.Account{}, fmt.Errorf(domain.ErrAccountNotFound)
+			return &domain.Account{}, domain.ErrAccountNotFound
 		}
 		return &domain.Account{}, err
 	}
-	return &account, nil
+	return account, nil
 }
 
 func (r *AccountRepository) UpdateBalance(ctx context.Context, id string, amount float64) error {
-	_, err := r.db.ExecContext(ctx, `
+	res, err := r.db.ExecContext(ctx, `
 		UPDATE accounts
 		SET balance = balance + $1
 		WHERE id = $2
 	`, amount, id)
-	return err
+	if err != nil {
+		return mapError(err)
+	}
+	return expectAffected(res, domain.ErrAccountNotFound)
+}
+
+func (r *AccountRepository) UpdateStatus(ctx context.Context, id string, status string) error {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE accounts
+		SET status = $1
+		WHERE id = $2
+	`, status, id)
+	if err != nil {
+		return err
+	}
+	return expectAffected(res, domain.ErrAccountNotFound)
+}
+
+// scanner is satisfied by both *sql.Row and *sql.Rows
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanAccount(s scanner) (*domain.Account, error) {
+	var account domain.Account
+	var currency, customerID, status sql.NullString
+	if err := s.Scan(&account.ID, &account.OwnerName, &account.Balance, &currency, &customerID, &status); err != nil {
+		return nil, err
+	}
+	account.Currency = currency.String
+	account.CustomerID = customerID.String
+	account.Status = status.String
+	return &account, nil
+}
+
+func scanAccounts(rows *sql.Rows) ([]*domain.Account, error) {
+	var accounts []*domain.Account
+	for rows.Next() {
+		account, err := scanAccount(rows)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, account)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return accounts, nil
+}
+
+// nullString stores empty strings as NULL so optional foreign keys stay valid
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
 }
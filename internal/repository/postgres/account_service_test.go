@@ -22,11 +22,11 @@ func TestGetAll(t *testing.T) {
 	db, mock, cleanup := setupMockDB(t)
 	defer cleanup()
 
-	rows := sqlmock.NewRows([]string{"id", "owner_name", "balance"}).
-		AddRow("acc1", "Alice", 100.0).
-		AddRow("acc2", "Bob", 200.0)
+	rows := sqlmock.NewRows([]string{"id", "owner_name", "balance", "currency", "customer_id", "status"}).
+		AddRow("acc1", "Alice", 100.0, "USD", "cust1", "ACTIVE").
+		AddRow("acc2", "Bob", 200.0, "EUR", nil, "ACTIVE")
 
-	mock.ExpectQuery(`SELECT id, owner_name, balance FROM accounts`).
+	mock.ExpectQuery(`SELECT id, owner_name, balance, currency, customer_id, status FROM accounts`).
 		WillReturnRows(rows)
 
 	repo := postgres.NewAccountRepository(db)
@@ -35,6 +35,27 @@ func TestGetAll(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Len(t, accounts, 2)
 	assert.Equal(t, "Alice", accounts[0].OwnerName)
+	assert.Equal(t, "cust1", accounts[0].CustomerID)
+	assert.Empty(t, accounts[1].CustomerID)
+}
+
+func TestGetByCustomerID(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	rows := sqlmock.NewRows([]string{"id", "owner_name", "balance", "currency", "customer_id", "status"}).
+		AddRow("acc1", "Alice", 100.0, "USD", "cust1", "ACTIVE")
+
+	mock.ExpectQuery(`SELECT id, owner_name, balance, currency, customer_id, status FROM accounts WHERE customer_id = \$1`).
+		WithArgs("cust1").
+		WillReturnRows(rows)
+
+	repo := postgres.NewAccountRepository(db)
+	accounts, err := repo.GetByCustomerID(context.Background(), "cust1")
+
+	assert.NoError(t, err)
+	assert.Len(t, accounts, 1)
+	assert.Equal(t, "USD", accounts[0].Currency)
 }
 
 func TestDelete(t *testing.T) {
@@ -51,14 +72,29 @@ func TestDelete(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestDelete_NotFound(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	mock.ExpectExec(`DELETE FROM accounts WHERE id = \$1`).
+		WithArgs("missing").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	repo := postgres.NewAccountRepository(db)
+	err := repo.Delete(context.Background(), "missing")
+
+	assert.ErrorIs(t, err, domain.ErrAccountNotFound)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+}
+
 func TestCreate(t *testing.T) {
 	db, mock, cleanup := setupMockDB(t)
 	defer cleanup()
 
-	account := &domain.Account{ID: "acc1", OwnerName: "Alice", Balance: 100}
+	account := &domain.Account{ID: "acc1", OwnerName: "Alice", Balance: 100, Currency: "USD", CustomerID: "cust1", Status: domain.AccountStatusActive}
 
-	mock.ExpectExec(`INSERT INTO accounts \(id, owner_name, balance\) VALUES \(\$1, \$2, \$3\)`).
-		WithArgs(account.ID, account.OwnerName, account.Balance).
+	mock.ExpectExec(`INSERT INTO accounts \(id, owner_name, balance, currency, customer_id, status\) VALUES \(\$1, \$2, \$3, \$4, \$5, \$6\)`).
+		WithArgs(account.ID, account.OwnerName, account.Balance, account.Currency, account.CustomerID, account.Status).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
 	repo := postgres.NewAccountRepository(db)
@@ -67,14 +103,31 @@ func TestCreate(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestCreate_AssignsSerialID(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	account := &domain.Account{OwnerName: "Alice", Balance: 100, Currency: "USD", Status: domain.AccountStatusActive}
+
+	mock.ExpectQuery(`INSERT INTO accounts \(owner_name, balance, currency, customer_id, status\) VALUES \(\$1, \$2, \$3, \$4, \$5\) RETURNING id`).
+		WithArgs(account.OwnerName, account.Balance, account.Currency, nil, account.Status).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(42))
+
+	repo := postgres.NewAccountRepository(db)
+	err := repo.Create(context.Background(), account)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "42", account.ID)
+}
+
 func TestGetByID(t *testing.T) {
 	db, mock, cleanup := setupMockDB(t)
 	defer cleanup()
 
-	row := sqlmock.NewRows([]string{"id", "owner_name", "balance"}).
-		AddRow("acc1", "Alice", 100.0)
+	row := sqlmock.NewRows([]string{"id", "owner_name", "balance", "currency", "customer_id", "status"}).
+		AddRow("acc1", "Alice", 100.0, "USD", nil, "ACTIVE")
 
-	mock.ExpectQuery(`SELECT id, owner_name, balance FROM accounts WHERE id = \$1`).
+	mock.ExpectQuery(`SELECT id, owner_name, balance, currency, customer_id, status FROM accounts WHERE id = \$1`).
 		WithArgs("acc1").
 		WillReturnRows(row)
 
@@ -99,3 +152,107 @@ func TestUpdateBalance(t *testing.T) {
 
 	assert.NoError(t, err)
 }
+
+func TestList_FirstPage(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	rows := sqlmock.NewRows([]string{"id", "owner_name", "balance", "currency", "customer_id", "status", "balance"}).
+		AddRow("acc1", "Alice", 300.0, "USD", nil, "ACTIVE", "300").
+		AddRow("acc2", "Bob", 200.0, "USD", nil, "ACTIVE", "200").
+		AddRow("acc3", "Carol", 100.0, "USD", nil, "ACTIVE", "100")
+
+	mock.ExpectQuery(`SELECT id, owner_name, balance, currency, customer_id, status, balance::text FROM accounts ORDER BY balance DESC, id DESC LIMIT \$1`).
+		WithArgs(3).
+		WillReturnRows(rows)
+
+	repo := postgres.NewAccountRepository(db)
+	page, err := repo.List(context.Background(), domain.AccountListOptions{Limit: 2, SortBy: domain.SortByBalance, Desc: true})
+
+	assert.NoError(t, err)
+	assert.Len(t, page.Accounts, 2)
+	assert.NotEmpty(t, page.NextCursor)
+
+	cursor, err := domain.DecodeCursor(page.NextCursor)
+	assert.NoError(t, err)
+	assert.Equal(t, &domain.Cursor{Sort: domain.SortByBalance, Key: "200", ID: "acc2"}, cursor)
+}
+
+func TestList_NextPage(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	rows := sqlmock.NewRows([]string{"id", "owner_name", "balance", "currency", "customer_id", "status", "owner_name"}).
+		AddRow("acc3", "Carol", 100.0, "USD", nil, "ACTIVE", "Carol")
+
+	mock.ExpectQuery(`SELECT id, owner_name, balance, currency, customer_id, status, owner_name::text FROM accounts WHERE \(owner_name, id\) > \(\$1, \$2\) ORDER BY owner_name ASC, id ASC LIMIT \$3`).
+		WithArgs("Bob", "acc2", domain.DefaultPageSize+1).
+		WillReturnRows(rows)
+
+	repo := postgres.NewAccountRepository(db)
+	cursor := domain.EncodeCursor(domain.Cursor{Sort: domain.SortByOwnerName, Key: "Bob", ID: "acc2"})
+	page, err := repo.List(context.Background(), domain.AccountListOptions{Cursor: cursor, SortBy: domain.SortByOwnerName})
+
+	assert.NoError(t, err)
+	assert.Len(t, page.Accounts, 1)
+	assert.Empty(t, page.NextCursor)
+}
+
+func TestList_ByCustomer(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	rows := sqlmock.NewRows([]string{"id", "owner_name", "balance", "currency", "customer_id", "status", "id"}).
+		AddRow("acc3", "Carol", 100.0, "USD", "cust1", "ACTIVE", "acc3")
+
+	mock.ExpectQuery(`SELECT (.+) FROM accounts WHERE customer_id = \$1 AND \(id, id\) > \(\$2, \$3\) ORDER BY id ASC, id ASC LIMIT \$4`).
+		WithArgs("cust1", "acc2", "acc2", domain.DefaultPageSize+1).
+		WillReturnRows(rows)
+
+	repo := postgres.NewAccountRepository(db)
+	cursor := domain.EncodeCursor(domain.Cursor{Sort: domain.SortByID, Key: "acc2", ID: "acc2"})
+	page, err := repo.List(context.Background(), domain.AccountListOptions{Cursor: cursor, CustomerID: "cust1"})
+
+	assert.NoError(t, err)
+	assert.Len(t, page.Accounts, 1)
+	assert.Equal(t, "cust1", page.Accounts[0].CustomerID)
+}
+
+func TestList_CursorForDifferentSort(t *testing.T) {
+	db, _, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := postgres.NewAccountRepository(db)
+	cursor := domain.EncodeCursor(domain.Cursor{Sort: domain.SortByBalance, Key: "200", ID: "acc2"})
+	_, err := repo.List(context.Background(), domain.AccountListOptions{Cursor: cursor})
+
+	assert.ErrorIs(t, err, domain.ErrInvalidCursor)
+}
+
+func TestUpdateStatus(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	mock.ExpectExec(`UPDATE accounts SET status = \$1 WHERE id = \$2`).
+		WithArgs(domain.AccountStatusFrozen, "acc1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	repo := postgres.NewAccountRepository(db)
+	err := repo.UpdateStatus(context.Background(), "acc1", domain.AccountStatusFrozen)
+
+	assert.NoError(t, err)
+}
+
+func TestUpdateStatus_NotFound(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	mock.ExpectExec(`UPDATE accounts SET status = \$1 WHERE id = \$2`).
+		WithArgs(domain.AccountStatusFrozen, "missing").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	repo := postgres.NewAccountRepository(db)
+	err := repo.UpdateStatus(context.Background(), "missing", domain.AccountStatusFrozen)
+
+	assert.ErrorIs(t, err, domain.ErrAccountNotFound)
+}
@@ -0,0 +1,74 @@
+package postgres_test
+
+import (
+	"context"
+	"ledger/internal/domain"
+	"ledger/internal/repository/postgres"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIKeyCreate(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	key := &domain.APIKey{ID: "key1", Name: "ci", Prefix: "lk_abcdefgh", KeyHash: "hash", Role: domain.RoleViewer}
+
+	mock.ExpectExec(`INSERT INTO api_keys \(id, name, prefix, key_hash, role, customer_id\)`).
+		WithArgs("key1", "ci", "lk_abcdefgh", "hash", domain.RoleViewer, nil).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	repo := postgres.NewAPIKeyRepository(db)
+	err := repo.Create(context.Background(), key)
+
+	assert.NoError(t, err)
+}
+
+func TestAPIKeyGetByHash(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	row := sqlmock.NewRows([]string{"id", "name", "prefix", "key_hash", "role", "customer_id", "revoked", "created_at"}).
+		AddRow("key1", "portal", "lk_abcdefgh", "hash", domain.RoleOperator, "cust1", false, "2024-01-01T00:00:00Z")
+
+	mock.ExpectQuery(`SELECT (.+) FROM api_keys WHERE key_hash = \$1 AND revoked_at IS NULL`).
+		WithArgs("hash").
+		WillReturnRows(row)
+
+	repo := postgres.NewAPIKeyRepository(db)
+	key, err := repo.GetByHash(context.Background(), "hash")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "cust1", key.CustomerID)
+	assert.Equal(t, domain.RoleOperator, key.Role)
+}
+
+func TestAPIKeyGetByHash_NotFound(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	mock.ExpectQuery(`SELECT (.+) FROM api_keys WHERE key_hash = \$1`).
+		WithArgs("unknown").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	repo := postgres.NewAPIKeyRepository(db)
+	_, err := repo.GetByHash(context.Background(), "unknown")
+
+	assert.ErrorIs(t, err, domain.ErrAPIKeyNotFound)
+}
+
+func TestAPIKeyRevoke_AlreadyRevoked(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	mock.ExpectExec(`UPDATE api_keys SET revoked_at = CURRENT_TIMESTAMP WHERE id = \$1 AND revoked_at IS NULL`).
+		WithArgs("key1").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	repo := postgres.NewAPIKeyRepository(db)
+	err := repo.Revoke(context.Background(), "key1")
+
+	assert.ErrorIs(t, err, domain.ErrAPIKeyNotFound)
+}
@@ -0,0 +1,105 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"ledger/internal/domain"
+)
+
+type CustomerRepository struct {
+	db *sql.DB
+}
+
+func NewCustomerRepository(db *sql.DB) *CustomerRepository {
+	return &CustomerRepository{db: db}
+}
+
+func (r *CustomerRepository) Create(ctx context.Context, customer *domain.Customer) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO customers (id, name, email)
+		VALUES ($1, $2, $3)
+	`, customer.ID, customer.Name, customer.Email)
+	return mapError(err)
+}
+
+func (r *CustomerRepository) GetByID(ctx context.Context, id string) (*domain.Customer, error) {
+	row := r.db.QueryRowContext(ctx, `
+		SELECT id, name, email, created_at
+		FROM customers
+		WHERE id = $1
+	`, id)
+
+	var customer domain.Customer
+	err := row.Scan(&customer.ID, &customer.Name, &customer.Email, &customer.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrCustomerNotFound
+		}
+		return nil, err
+	}
+	return &customer, nil
+}
+
+func (r *CustomerRepository) GetAll(ctx context.Context) ([]*domain.Customer, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, name, email, created_at
+		FROM customers
+		ORDER BY created_at
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var customers []*domain.Customer
+	for rows.Next() {
+		var customer domain.Customer
+		if err := rows.Scan(&customer.ID, &customer.Name, &customer.Email, &customer.CreatedAt); err != nil {
+			return nil, err
+		}
+		customers = append(customers, &customer)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return customers, nil
+}
+
+func (r *CustomerRepository) Update(ctx context.Context, customer *domain.Customer) error {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE customers
+		SET name = $1, email = $2
+		WHERE id = $3
+	`, customer.Name, customer.Email, customer.ID)
+	if err != nil {
+		return mapError(err)
+	}
+	return expectAffected(res, domain.ErrCustomerNotFound)
+}
+
+func (r *CustomerRepository) Delete(ctx context.Context, id string) error {
+	res, err := r.db.ExecContext(ctx, `
+		DELETE FROM customers
+		WHERE id = $1
+	`, id)
+	if err != nil {
+		return mapError(err)
+	}
+	return expectAffected(res, domain.ErrCustomerNotFound)
+}
+
+// expectAffected turns an UPDATE/DELETE that matched no rows into a not-found error
+func expectAffected(res sql.Result, notFound error) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return notFound
+	}
+	return nil
+}
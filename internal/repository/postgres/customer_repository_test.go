@@ -0,0 +1,88 @@
+package postgres_test
+
+import (
+	"context"
+	"ledger/internal/domain"
+	"ledger/internal/repository/postgres"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCustomerCreate(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	customer := &domain.Customer{ID: "cust1", Name: "Alice", Email: "alice@example.com"}
+
+	mock.ExpectExec(`INSERT INTO customers \(id, name, email\) VALUES \(\$1, \$2, \$3\)`).
+		WithArgs(customer.ID, customer.Name, customer.Email).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	repo := postgres.NewCustomerRepository(db)
+	err := repo.Create(context.Background(), customer)
+
+	assert.NoError(t, err)
+}
+
+func TestCustomerGetByID(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	row := sqlmock.NewRows([]string{"id", "name", "email", "created_at"}).
+		AddRow("cust1", "Alice", "alice@example.com", "2024-01-01T00:00:00Z")
+
+	mock.ExpectQuery(`SELECT id, name, email, created_at FROM customers WHERE id = \$1`).
+		WithArgs("cust1").
+		WillReturnRows(row)
+
+	repo := postgres.NewCustomerRepository(db)
+	customer, err := repo.GetByID(context.Background(), "cust1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Alice", customer.Name)
+}
+
+func TestCustomerGetByID_NotFound(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	mock.ExpectQuery(`SELECT id, name, email, created_at FROM customers WHERE id = \$1`).
+		WithArgs("missing").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "email", "created_at"}))
+
+	repo := postgres.NewCustomerRepository(db)
+	_, err := repo.GetByID(context.Background(), "missing")
+
+	assert.ErrorIs(t, err, domain.ErrCustomerNotFound)
+}
+
+func TestCustomerDelete_NotFound(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	mock.ExpectExec(`DELETE FROM customers WHERE id = \$1`).
+		WithArgs("missing").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	repo := postgres.NewCustomerRepository(db)
+	err := repo.Delete(context.Background(), "missing")
+
+	assert.ErrorIs(t, err, domain.ErrCustomerNotFound)
+}
+
+func TestCustomerDelete_StillReferenced(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	mock.ExpectExec(`DELETE FROM customers WHERE id = \$1`).
+		WithArgs("cust1").
+		WillReturnError(&pq.Error{Code: "23503", Detail: `Key (id)=(cust1) is still referenced from table "accounts".`})
+
+	repo := postgres.NewCustomerRepository(db)
+	err := repo.Delete(context.Background(), "cust1")
+
+	assert.ErrorIs(t, err, domain.ErrConflict)
+}
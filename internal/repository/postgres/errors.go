@@ -0,0 +1,25 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+
+	"ledger/internal/domain"
+)
+
+// mapError turns constraint violations into domain errors and passes anything else through
+func mapError(err error) error {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return err
+	}
+	switch pqErr.Code.Name() {
+	case "unique_violation", "foreign_key_violation":
+		return fmt.Errorf("%w: %s", domain.ErrConflict, pqErr.Detail)
+	case "check_violation", "not_null_violation":
+		return fmt.Errorf("%w: %s", domain.ErrValidation, pqErr.Message)
+	}
+	return err
+}
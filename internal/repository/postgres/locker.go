@@ -0,0 +1,44 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"log/slog"
+)
+
+// transferLockClass keeps the advisory locks taken on transaction IDs apart
+// from other advisory locks, such as the migration lock
+const transferLockClass int32 = 0x747846 // "txF"
+
+// Locker takes Postgres session advisory locks, so every process using the
+// database serializes on the same keys
+type Locker struct {
+	db *sql.DB
+}
+
+func NewLocker(db *sql.DB) *Locker {
+	return &Locker{db: db}
+}
+
+// Lock holds a connection of the pool until unlock is called
+func (l *Locker) Lock(ctx context.Context, key string) (func(), error) {
+	conn, err := l.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1, hashtext($2))`, transferLockClass, key); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return func() {
+		// Unlock even when ctx is done, or the session keeps the lock
+		_, err := conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1, hashtext($2))`, transferLockClass, key)
+		if err != nil {
+			slog.Warn("failed to release advisory lock, closing its connection", "key", key, "error", err)
+			// Ending the session releases the lock
+			conn.Raw(func(any) error { return driver.ErrBadConn })
+		}
+		conn.Close()
+	}, nil
+}
@@ -0,0 +1,45 @@
+package postgres_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"ledger/internal/repository/postgres"
+)
+
+func TestLocker(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	mock.ExpectExec(`SELECT pg_advisory_lock\(\$1, hashtext\(\$2\)\)`).
+		WithArgs(sqlmock.AnyArg(), "tx-1").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`SELECT pg_advisory_unlock\(\$1, hashtext\(\$2\)\)`).
+		WithArgs(sqlmock.AnyArg(), "tx-1").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	unlock, err := postgres.NewLocker(db).Lock(context.Background(), "tx-1")
+	require.NoError(t, err)
+	unlock()
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLocker_LockFails(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	mock.ExpectExec(`SELECT pg_advisory_lock`).
+		WithArgs(sqlmock.AnyArg(), "tx-1").
+		WillReturnError(errors.New("canceling statement due to user request"))
+
+	_, err := postgres.NewLocker(db).Lock(context.Background(), "tx-1")
+
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
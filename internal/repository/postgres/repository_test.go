@@ -0,0 +1,82 @@
+package postgres_test
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"ledger/internal/domain"
+	"ledger/internal/migration"
+	"ledger/internal/repository/postgres"
+	"ledger/internal/repository/repotest"
+)
+
+// The conformance suites need a real database. They run against the one in
+// TEST_POSTGRES_DSN, which they migrate and empty, and are skipped without it.
+
+func postgresDSN(t *testing.T) string {
+	dsn := os.Getenv("TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("TEST_POSTGRES_DSN is not set")
+	}
+	return dsn
+}
+
+// openDB returns the migrated database with every table emptied
+func openDB(t *testing.T, dsn string) *sql.DB {
+	ctx := context.Background()
+	db, err := sql.Open("postgres", dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := migration.New(db)
+	require.NoError(t, err)
+	_, err = migrator.Up(ctx)
+	require.NoError(t, err)
+	_, err = db.ExecContext(ctx, `TRUNCATE accounts, customers, api_keys, webhook_subscriptions, webhook_deliveries RESTART IDENTITY CASCADE`)
+	require.NoError(t, err)
+	return db
+}
+
+func TestAccountRepository(t *testing.T) {
+	dsn := postgresDSN(t)
+	repotest.TestAccountRepository(t, func(t *testing.T) repotest.AccountStore {
+		db := openDB(t, dsn)
+		customers := postgres.NewCustomerRepository(db)
+		return repotest.AccountStore{
+			Accounts: postgres.NewAccountRepository(db),
+			AddCustomer: func(ctx context.Context, id string) error {
+				return customers.Create(ctx, &domain.Customer{ID: id, Name: id})
+			},
+		}
+	})
+}
+
+func TestCustomerRepository(t *testing.T) {
+	dsn := postgresDSN(t)
+	repotest.TestCustomerRepository(t, func(t *testing.T) repotest.CustomerStore {
+		db := openDB(t, dsn)
+		return repotest.CustomerStore{
+			Customers: postgres.NewCustomerRepository(db),
+			Accounts:  postgres.NewAccountRepository(db),
+			APIKeys:   postgres.NewAPIKeyRepository(db),
+		}
+	})
+}
+
+func TestAPIKeyRepository(t *testing.T) {
+	dsn := postgresDSN(t)
+	repotest.TestAPIKeyRepository(t, func(t *testing.T) domain.APIKeyRepository {
+		return postgres.NewAPIKeyRepository(openDB(t, dsn))
+	})
+}
+
+func TestWebhookRepository(t *testing.T) {
+	dsn := postgresDSN(t)
+	repotest.TestWebhookRepository(t, func(t *testing.T) domain.WebhookRepository {
+		return postgres.NewWebhookRepository(openDB(t, dsn))
+	})
+}
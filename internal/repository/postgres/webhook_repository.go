@@ -0,0 +1,226 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+
+	"ledger/internal/domain"
+)
+
+type WebhookRepository struct {
+	db *sql.DB
+}
+
+func NewWebhookRepository(db *sql.DB) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+func (r *WebhookRepository) CreateSubscription(ctx context.Context, sub *domain.WebhookSubscription) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO webhook_subscriptions (id, url, secret, event_types, active)
+		VALUES ($1, $2, $3, $4, $5)
+	`, sub.ID, sub.URL, sub.Secret, pq.Array(sub.EventTypes), sub.Active)
+	return err
+}
+
+func (r *WebhookRepository) GetSubscription(ctx context.Context, id string) (*domain.WebhookSubscription, error) {
+	row := r.db.QueryRowContext(ctx, `
+		SELECT id, url, secret, event_types, active, created_at
+		FROM webhook_subscriptions
+		WHERE id = $1
+	`, id)
+
+	sub, err := scanSubscription(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrSubscriptionNotFound
+		}
+		return nil, err
+	}
+	return sub, nil
+}
+
+func (r *WebhookRepository) ListSubscriptions(ctx context.Context) ([]*domain.WebhookSubscription, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, url, secret, event_types, active, created_at
+		FROM webhook_subscriptions
+		ORDER BY created_at
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanSubscriptions(rows)
+}
+
+func (r *WebhookRepository) ListSubscriptionsForEvent(ctx context.Context, eventType string) ([]*domain.WebhookSubscription, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, url, secret, event_types, active, created_at
+		FROM webhook_subscriptions
+		WHERE active AND $1 = ANY(event_types)
+	`, eventType)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanSubscriptions(rows)
+}
+
+func (r *WebhookRepository) DeleteSubscription(ctx context.Context, id string) error {
+	res, err := r.db.ExecContext(ctx, `
+		DELETE FROM webhook_subscriptions
+		WHERE id = $1
+	`, id)
+	if err != nil {
+		return err
+	}
+	return expectAffected(res, domain.ErrSubscriptionNotFound)
+}
+
+func (r *WebhookRepository) CreateDelivery(ctx context.Context, d *domain.WebhookDelivery) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO webhook_deliveries (id, subscription_id, event_id, event_type, payload, status, attempts, next_attempt_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, d.ID, d.SubscriptionID, d.EventID, d.EventType, []byte(d.Payload), d.Status, d.Attempts, d.NextAttemptAt)
+	return err
+}
+
+func (r *WebhookRepository) UpdateDelivery(ctx context.Context, d *domain.WebhookDelivery) error {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE webhook_deliveries
+		SET status = $1, attempts = $2, response_code = $3, last_error = $4, next_attempt_at = $5,
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE id = $6
+	`, d.Status, d.Attempts, d.ResponseCode, d.LastError, d.NextAttemptAt, d.ID)
+	if err != nil {
+		return err
+	}
+	return expectAffected(res, domain.ErrDeliveryNotFound)
+}
+
+func (r *WebhookRepository) GetDelivery(ctx context.Context, id string) (*domain.WebhookDelivery, error) {
+	row := r.db.QueryRowContext(ctx, `
+		SELECT id, subscription_id, event_id, event_type, payload, status, attempts, response_code, last_error, created_at, updated_at, next_attempt_at
+		FROM webhook_deliveries
+		WHERE id = $1
+	`, id)
+
+	d, err := scanDelivery(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrDeliveryNotFound
+		}
+		return nil, err
+	}
+	return d, nil
+}
+
+func (r *WebhookRepository) ListDeliveries(ctx context.Context, subscriptionID string) ([]*domain.WebhookDelivery, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, subscription_id, event_id, event_type, payload, status, attempts, response_code, last_error, created_at, updated_at, next_attempt_at
+		FROM webhook_deliveries
+		WHERE subscription_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2
+	`, subscriptionID, domain.MaxPageSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []*domain.WebhookDelivery{}
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+// ClaimDueDeliveries claims the pending deliveries that have been due longest.
+// Rows another replica is claiming at the same time are skipped.
+func (r *WebhookRepository) ClaimDueDeliveries(ctx context.Context, now, until time.Time, limit int) ([]*domain.WebhookDelivery, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		UPDATE webhook_deliveries
+		SET next_attempt_at = $1
+		WHERE id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE status = $2 AND next_attempt_at <= $3
+			ORDER BY next_attempt_at
+			LIMIT $4
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, subscription_id, event_id, event_type, payload, status, attempts, response_code, last_error, created_at, updated_at, next_attempt_at
+	`, until, domain.DeliveryPending, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []*domain.WebhookDelivery{}
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+func scanSubscription(s scanner) (*domain.WebhookSubscription, error) {
+	var sub domain.WebhookSubscription
+	var eventTypes pq.StringArray
+	if err := s.Scan(&sub.ID, &sub.URL, &sub.Secret, &eventTypes, &sub.Active, &sub.CreatedAt); err != nil {
+		return nil, err
+	}
+	sub.EventTypes = eventTypes
+	return &sub, nil
+}
+
+func scanSubscriptions(rows *sql.Rows) ([]*domain.WebhookSubscription, error) {
+	subs := []*domain.WebhookSubscription{}
+	for rows.Next() {
+		sub, err := scanSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		subs = append(subs, sub)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return subs, nil
+}
+
+func scanDelivery(s scanner) (*domain.WebhookDelivery, error) {
+	var d domain.WebhookDelivery
+	var payload []byte
+	err := s.Scan(&d.ID, &d.SubscriptionID, &d.EventID, &d.EventType, &payload, &d.Status,
+		&d.Attempts, &d.ResponseCode, &d.LastError, &d.CreatedAt, &d.UpdatedAt, &d.NextAttemptAt)
+	if err != nil {
+		return nil, err
+	}
+	d.Payload = payload
+	return &d, nil
+}
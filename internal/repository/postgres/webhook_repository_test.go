@@ -0,0 +1,143 @@
+package postgres_test
+
+import (
+	"context"
+	"ledger/internal/domain"
+	"ledger/internal/repository/postgres"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+var deliveryColumns = []string{"id", "subscription_id", "event_id", "event_type", "payload", "status", "attempts", "response_code", "last_error", "created_at", "updated_at", "next_attempt_at"}
+
+func TestWebhookCreateSubscription(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	sub := &domain.WebhookSubscription{
+		ID:         "sub1",
+		URL:        "https://example.com/hook",
+		Secret:     "s3cret",
+		EventTypes: []string{domain.EventTransactionSucceeded},
+		Active:     true,
+	}
+
+	mock.ExpectExec(`INSERT INTO webhook_subscriptions \(id, url, secret, event_types, active\)`).
+		WithArgs(sub.ID, sub.URL, sub.Secret, pq.Array(sub.EventTypes), true).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	repo := postgres.NewWebhookRepository(db)
+	err := repo.CreateSubscription(context.Background(), sub)
+
+	assert.NoError(t, err)
+}
+
+func TestWebhookListSubscriptionsForEvent(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	rows := sqlmock.NewRows([]string{"id", "url", "secret", "event_types", "active", "created_at"}).
+		AddRow("sub1", "https://example.com/hook", "s3cret", "{transaction.succeeded,balance.low}", true, "2024-01-01T00:00:00Z")
+
+	mock.ExpectQuery(`SELECT id, url, secret, event_types, active, created_at FROM webhook_subscriptions WHERE active AND \$1 = ANY\(event_types\)`).
+		WithArgs(domain.EventBalanceLow).
+		WillReturnRows(rows)
+
+	repo := postgres.NewWebhookRepository(db)
+	subs, err := repo.ListSubscriptionsForEvent(context.Background(), domain.EventBalanceLow)
+
+	assert.NoError(t, err)
+	assert.Len(t, subs, 1)
+	assert.Equal(t, []string{domain.EventTransactionSucceeded, domain.EventBalanceLow}, subs[0].EventTypes)
+}
+
+func TestWebhookDeleteSubscription_NotFound(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	mock.ExpectExec(`DELETE FROM webhook_subscriptions WHERE id = \$1`).
+		WithArgs("missing").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	repo := postgres.NewWebhookRepository(db)
+	err := repo.DeleteSubscription(context.Background(), "missing")
+
+	assert.ErrorIs(t, err, domain.ErrSubscriptionNotFound)
+}
+
+func TestWebhookUpdateDelivery(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	d := &domain.WebhookDelivery{ID: "del1", Status: domain.DeliveryPending, Attempts: 3, ResponseCode: 503, LastError: "receiver responded with 503", NextAttemptAt: time.Now()}
+
+	mock.ExpectExec(`UPDATE webhook_deliveries SET status = \$1, attempts = \$2, response_code = \$3, last_error = \$4, next_attempt_at = \$5`).
+		WithArgs(d.Status, d.Attempts, d.ResponseCode, d.LastError, d.NextAttemptAt, d.ID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	repo := postgres.NewWebhookRepository(db)
+	err := repo.UpdateDelivery(context.Background(), d)
+
+	assert.NoError(t, err)
+}
+
+func TestWebhookGetDelivery_NotFound(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	mock.ExpectQuery(`SELECT (.+) FROM webhook_deliveries WHERE id = \$1`).
+		WithArgs("missing").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	repo := postgres.NewWebhookRepository(db)
+	_, err := repo.GetDelivery(context.Background(), "missing")
+
+	assert.ErrorIs(t, err, domain.ErrDeliveryNotFound)
+}
+
+func TestWebhookListDeliveries(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	rows := sqlmock.NewRows(deliveryColumns).
+		AddRow("del1", "sub1", "evt1", domain.EventTransactionFailed, []byte(`{"id":"evt1"}`), domain.DeliverySucceeded, 2, 200, "", "2024-01-01T00:00:00Z", "2024-01-01T00:00:05Z", time.Time{})
+
+	mock.ExpectQuery(`SELECT (.+) FROM webhook_deliveries WHERE subscription_id = \$1 ORDER BY created_at DESC LIMIT \$2`).
+		WithArgs("sub1", domain.MaxPageSize).
+		WillReturnRows(rows)
+
+	repo := postgres.NewWebhookRepository(db)
+	deliveries, err := repo.ListDeliveries(context.Background(), "sub1")
+
+	assert.NoError(t, err)
+	assert.Len(t, deliveries, 1)
+	assert.Equal(t, 200, deliveries[0].ResponseCode)
+	assert.JSONEq(t, `{"id":"evt1"}`, string(deliveries[0].Payload))
+}
+
+func TestWebhookClaimDueDeliveries(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	until := now.Add(time.Minute)
+	rows := sqlmock.NewRows(deliveryColumns).
+		AddRow("del1", "sub1", "evt1", domain.EventBalanceLow, []byte(`{"id":"evt1"}`), domain.DeliveryPending, 1, 503, "receiver responded with 503", "2024-01-01T00:00:00Z", "2024-01-01T00:00:05Z", until)
+
+	mock.ExpectQuery(`UPDATE webhook_deliveries SET next_attempt_at = \$1 WHERE id IN \( SELECT id FROM webhook_deliveries WHERE status = \$2 AND next_attempt_at <= \$3 ORDER BY next_attempt_at LIMIT \$4 FOR UPDATE SKIP LOCKED \) RETURNING`).
+		WithArgs(until, domain.DeliveryPending, now, 10).
+		WillReturnRows(rows)
+
+	repo := postgres.NewWebhookRepository(db)
+	deliveries, err := repo.ClaimDueDeliveries(context.Background(), now, until, 10)
+
+	assert.NoError(t, err)
+	if assert.Len(t, deliveries, 1) {
+		assert.Equal(t, "del1", deliveries[0].ID)
+		assert.Equal(t, until, deliveries[0].NextAttemptAt)
+	}
+}
@@ -0,0 +1,258 @@
+// Package repotest holds conformance suites that every implementation of the
+// domain repositories must pass, whatever it stores its data in.
+package repotest
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"ledger/internal/domain"
+)
+
+// AccountStore is an empty store under test
+type AccountStore struct {
+	Accounts domain.AccountRepository
+	// AddCustomer creates a customer that accounts can then belong to
+	AddCustomer func(ctx context.Context, id string) error
+}
+
+// TestAccountRepository runs the account suite, calling open for a fresh store in every subtest
+func TestAccountRepository(t *testing.T, open func(t *testing.T) AccountStore) {
+	ctx := context.Background()
+	account := func(id string, balance float64) *domain.Account {
+		return &domain.Account{ID: id, OwnerName: "owner " + id, Balance: balance, Currency: "USD", Status: domain.AccountStatusActive}
+	}
+
+	t.Run("CreateAndGet", func(t *testing.T) {
+		s := open(t)
+		require.NoError(t, s.AddCustomer(ctx, "cust-1"))
+		want := &domain.Account{ID: "1", CustomerID: "cust-1", OwnerName: "Alice", Balance: 100, Currency: "EUR", Status: domain.AccountStatusActive}
+		require.NoError(t, s.Accounts.Create(ctx, want))
+
+		got, err := s.Accounts.GetByID(ctx, "1")
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+
+		// Callers get copies
+		got.Balance = 0
+		again, err := s.Accounts.GetByID(ctx, "1")
+		require.NoError(t, err)
+		assert.Equal(t, 100.0, again.Balance)
+	})
+
+	t.Run("CreateNumbersAccounts", func(t *testing.T) {
+		s := open(t)
+		first, second := account("", 10), account("", 20)
+		require.NoError(t, s.Accounts.Create(ctx, first))
+		require.NoError(t, s.Accounts.Create(ctx, second))
+
+		// Transfers address accounts by number
+		a, err := strconv.ParseInt(first.ID, 10, 64)
+		require.NoError(t, err)
+		b, err := strconv.ParseInt(second.ID, 10, 64)
+		require.NoError(t, err)
+		assert.Greater(t, b, a)
+
+		got, err := s.Accounts.GetByID(ctx, second.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 20.0, got.Balance)
+	})
+
+	t.Run("GetMissing", func(t *testing.T) {
+		s := open(t)
+		_, err := s.Accounts.GetByID(ctx, "999")
+		assert.ErrorIs(t, err, domain.ErrAccountNotFound)
+	})
+
+	t.Run("CreateDuplicate", func(t *testing.T) {
+		s := open(t)
+		require.NoError(t, s.Accounts.Create(ctx, account("1", 0)))
+		err := s.Accounts.Create(ctx, account("1", 0))
+		assert.ErrorIs(t, err, domain.ErrConflict)
+	})
+
+	t.Run("CreateForUnknownCustomer", func(t *testing.T) {
+		s := open(t)
+		a := account("1", 0)
+		a.CustomerID = "missing"
+		err := s.Accounts.Create(ctx, a)
+		assert.ErrorIs(t, err, domain.ErrConflict)
+	})
+
+	t.Run("UpdateBalanceAddsAmount", func(t *testing.T) {
+		s := open(t)
+		require.NoError(t, s.Accounts.Create(ctx, account("1", 100)))
+		require.NoError(t, s.Accounts.UpdateBalance(ctx, "1", 50))
+		require.NoError(t, s.Accounts.UpdateBalance(ctx, "1", -20))
+
+		got, err := s.Accounts.GetByID(ctx, "1")
+		require.NoError(t, err)
+		assert.Equal(t, 130.0, got.Balance)
+		assert.ErrorIs(t, s.Accounts.UpdateBalance(ctx, "999", 1), domain.ErrAccountNotFound)
+	})
+
+	t.Run("ConcurrentBalanceUpdates", func(t *testing.T) {
+		s := open(t)
+		require.NoError(t, s.Accounts.Create(ctx, account("1", 0)))
+
+		var wg sync.WaitGroup
+		for range 50 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				assert.NoError(t, s.Accounts.UpdateBalance(ctx, "1", 2))
+			}()
+		}
+		wg.Wait()
+
+		got, err := s.Accounts.GetByID(ctx, "1")
+		require.NoError(t, err)
+		assert.Equal(t, 100.0, got.Balance, "no update may be lost")
+	})
+
+	t.Run("UpdateStatus", func(t *testing.T) {
+		s := open(t)
+		require.NoError(t, s.Accounts.Create(ctx, account("1", 0)))
+		require.NoError(t, s.Accounts.UpdateStatus(ctx, "1", domain.AccountStatusFrozen))
+
+		got, err := s.Accounts.GetByID(ctx, "1")
+		require.NoError(t, err)
+		assert.Equal(t, domain.AccountStatusFrozen, got.Status)
+		assert.ErrorIs(t, s.Accounts.UpdateStatus(ctx, "999", domain.AccountStatusFrozen), domain.ErrAccountNotFound)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		s := open(t)
+		require.NoError(t, s.Accounts.Create(ctx, account("1", 0)))
+		require.NoError(t, s.Accounts.Delete(ctx, "1"))
+
+		_, err := s.Accounts.GetByID(ctx, "1")
+		assert.ErrorIs(t, err, domain.ErrAccountNotFound)
+		assert.ErrorIs(t, s.Accounts.Delete(ctx, "1"), domain.ErrAccountNotFound)
+	})
+
+	t.Run("GetAllAndByCustomer", func(t *testing.T) {
+		s := open(t)
+		require.NoError(t, s.AddCustomer(ctx, "cust-1"))
+		for i, customerID := range []string{"cust-1", "", "cust-1"} {
+			a := account(fmt.Sprintf("%d", i), 0)
+			a.CustomerID = customerID
+			require.NoError(t, s.Accounts.Create(ctx, a))
+		}
+
+		all, err := s.Accounts.GetAll(ctx)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"0", "1", "2"}, accountIDs(all))
+
+		owned, err := s.Accounts.GetByCustomerID(ctx, "cust-1")
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"0", "2"}, accountIDs(owned))
+
+		none, err := s.Accounts.GetByCustomerID(ctx, "cust-2")
+		require.NoError(t, err)
+		assert.Empty(t, none)
+	})
+
+	t.Run("ListPages", func(t *testing.T) {
+		s := open(t)
+		// Account numbers past 9 catch IDs ordered as text
+		for i, balance := range []float64{30, 5, 100, 5, 42, 7, 8, 9, 11, 5, 12, 13} {
+			require.NoError(t, s.Accounts.Create(ctx, account(fmt.Sprintf("%d", i+1), balance)))
+		}
+
+		list := func(opts domain.AccountListOptions) []string {
+			var ids []string
+			for pages := 0; ; pages++ {
+				require.Less(t, pages, 10, "listing never ended")
+				page, err := s.Accounts.List(ctx, opts)
+				require.NoError(t, err)
+				ids = append(ids, accountIDs(page.Accounts)...)
+				if page.NextCursor == "" {
+					return ids
+				}
+				opts.Cursor = page.NextCursor
+			}
+		}
+
+		assert.Equal(t, []string{"1", "2", "3", "4", "5", "6", "7", "8", "9", "10", "11", "12"},
+			list(domain.AccountListOptions{Limit: 5}), "IDs order as numbers")
+		// Ties on the sort key are ordered by ID
+		assert.Equal(t, []string{"3", "5", "1", "12", "11", "9", "8", "7", "6", "10", "4", "2"},
+			list(domain.AccountListOptions{Limit: 2, SortBy: domain.SortByBalance, Desc: true}))
+	})
+
+	t.Run("ListCursorsPastSingleDigitIDs", func(t *testing.T) {
+		s := open(t)
+		for i := 1; i <= 12; i++ {
+			require.NoError(t, s.Accounts.Create(ctx, account(fmt.Sprintf("%d", i), 0)))
+		}
+
+		// Every page ends on an ID the next page must compare as a number
+		for _, opts := range []domain.AccountListOptions{
+			{Limit: 3, Desc: true},
+			{Limit: 4, SortBy: domain.SortByBalance},
+		} {
+			var ids []string
+			for pages := 0; ; pages++ {
+				require.Less(t, pages, 10, "listing never ended")
+				page, err := s.Accounts.List(ctx, opts)
+				require.NoError(t, err)
+				ids = append(ids, accountIDs(page.Accounts)...)
+				if page.NextCursor == "" {
+					break
+				}
+				opts.Cursor = page.NextCursor
+			}
+			want := []string{"1", "2", "3", "4", "5", "6", "7", "8", "9", "10", "11", "12"}
+			if opts.Desc {
+				slices.Reverse(want)
+			}
+			assert.Equal(t, want, ids, "sorted by %s", opts.SortBy)
+		}
+	})
+
+	t.Run("ListByCustomer", func(t *testing.T) {
+		s := open(t)
+		require.NoError(t, s.AddCustomer(ctx, "cust-1"))
+		for i, customerID := range []string{"cust-1", "", "cust-1"} {
+			a := account(fmt.Sprintf("%d", i), 0)
+			a.CustomerID = customerID
+			require.NoError(t, s.Accounts.Create(ctx, a))
+		}
+
+		page, err := s.Accounts.List(ctx, domain.AccountListOptions{CustomerID: "cust-1"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"0", "2"}, accountIDs(page.Accounts))
+		assert.Empty(t, page.NextCursor)
+	})
+
+	t.Run("ListRejectsBadOptions", func(t *testing.T) {
+		s := open(t)
+		require.NoError(t, s.Accounts.Create(ctx, account("1", 0)))
+		require.NoError(t, s.Accounts.Create(ctx, account("2", 0)))
+
+		_, err := s.Accounts.List(ctx, domain.AccountListOptions{SortBy: "color"})
+		assert.Error(t, err)
+
+		page, err := s.Accounts.List(ctx, domain.AccountListOptions{Limit: 1})
+		require.NoError(t, err)
+		require.NotEmpty(t, page.NextCursor)
+		_, err = s.Accounts.List(ctx, domain.AccountListOptions{Cursor: page.NextCursor, SortBy: domain.SortByBalance})
+		assert.ErrorIs(t, err, domain.ErrInvalidCursor, "a cursor only continues the sort it came from")
+	})
+}
+
+func accountIDs(accounts []*domain.Account) []string {
+	ids := []string{}
+	for _, a := range accounts {
+		ids = append(ids, a.ID)
+	}
+	return ids
+}
@@ -0,0 +1,44 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"ledger/internal/domain"
+)
+
+// TestAPIKeyRepository runs the API key suite, calling open for a fresh store in every subtest
+func TestAPIKeyRepository(t *testing.T, open func(t *testing.T) domain.APIKeyRepository) {
+	ctx := context.Background()
+
+	t.Run("Create", func(t *testing.T) {
+		keys := open(t)
+		assert.ErrorIs(t, keys.Create(ctx, &domain.APIKey{ID: "key-0", KeyHash: "h0", Role: "root"}), domain.ErrValidation)
+		require.NoError(t, keys.Create(ctx, &domain.APIKey{ID: "key-1", KeyHash: "h1", Role: domain.RoleAdmin}))
+		assert.ErrorIs(t, keys.Create(ctx, &domain.APIKey{ID: "key-2", KeyHash: "h1", Role: domain.RoleAdmin}), domain.ErrConflict)
+
+		key, err := keys.GetByHash(ctx, "h1")
+		require.NoError(t, err)
+		assert.Equal(t, "key-1", key.ID)
+		assert.Equal(t, domain.RoleAdmin, key.Role)
+	})
+
+	t.Run("Revoke", func(t *testing.T) {
+		keys := open(t)
+		require.NoError(t, keys.Create(ctx, &domain.APIKey{ID: "key-1", KeyHash: "h1", Role: domain.RoleAdmin}))
+
+		require.NoError(t, keys.Revoke(ctx, "key-1"))
+		_, err := keys.GetByHash(ctx, "h1")
+		assert.ErrorIs(t, err, domain.ErrAPIKeyNotFound)
+		assert.ErrorIs(t, keys.Revoke(ctx, "key-1"), domain.ErrAPIKeyNotFound, "a key is revoked once")
+
+		listed, err := keys.List(ctx)
+		require.NoError(t, err)
+		if assert.Len(t, listed, 1) {
+			assert.True(t, listed[0].Revoked)
+		}
+	})
+}
@@ -0,0 +1,53 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"ledger/internal/domain"
+)
+
+// CustomerStore is an empty store under test. Its repositories share one
+// database so references to customers are enforced.
+type CustomerStore struct {
+	Customers domain.CustomerRepository
+	Accounts  domain.AccountRepository
+	APIKeys   domain.APIKeyRepository
+}
+
+// TestCustomerRepository runs the customer suite, calling open for a fresh store in every subtest
+func TestCustomerRepository(t *testing.T, open func(t *testing.T) CustomerStore) {
+	ctx := context.Background()
+
+	t.Run("CreateAndGet", func(t *testing.T) {
+		s := open(t)
+		require.NoError(t, s.Customers.Create(ctx, &domain.Customer{ID: "cust-1", Name: "Acme", Email: "ops@acme.test"}))
+		assert.ErrorIs(t, s.Customers.Create(ctx, &domain.Customer{ID: "cust-1", Name: "Acme"}), domain.ErrConflict)
+
+		got, err := s.Customers.GetByID(ctx, "cust-1")
+		require.NoError(t, err)
+		assert.Equal(t, "Acme", got.Name)
+		assert.Equal(t, "ops@acme.test", got.Email)
+
+		_, err = s.Customers.GetByID(ctx, "missing")
+		assert.ErrorIs(t, err, domain.ErrCustomerNotFound)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		s := open(t)
+		require.NoError(t, s.Customers.Create(ctx, &domain.Customer{ID: "cust-1", Name: "Acme"}))
+		require.NoError(t, s.Accounts.Create(ctx, &domain.Account{ID: "1", CustomerID: "cust-1", Currency: "USD", Status: domain.AccountStatusActive}))
+		require.NoError(t, s.APIKeys.Create(ctx, &domain.APIKey{ID: "key-1", KeyHash: "hash", Role: domain.RoleViewer, CustomerID: "cust-1"}))
+
+		assert.ErrorIs(t, s.Customers.Delete(ctx, "cust-1"), domain.ErrConflict, "customers owning accounts stay")
+
+		require.NoError(t, s.Accounts.Delete(ctx, "1"))
+		require.NoError(t, s.Customers.Delete(ctx, "cust-1"))
+		_, err := s.APIKeys.GetByHash(ctx, "hash")
+		assert.ErrorIs(t, err, domain.ErrAPIKeyNotFound, "the customer's keys go with it")
+		assert.ErrorIs(t, s.Customers.Delete(ctx, "cust-1"), domain.ErrCustomerNotFound)
+	})
+}
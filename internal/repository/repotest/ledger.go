@@ -0,0 +1,139 @@
+package repotest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"ledger/internal/domain"
+)
+
+// TestLedgerRepository runs the ledger suite, calling open for a fresh, empty repository in every subtest
+func TestLedgerRepository(t *testing.T, open func(t *testing.T) domain.LedgerRepository) {
+	ctx := context.Background()
+	base := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	entry := func(txID string, from, to int64, amount float64, minutes int) *domain.LedgerEntry {
+		return &domain.LedgerEntry{
+			ID:            "entry-" + txID,
+			TransactionID: txID,
+			FromAccountID: from,
+			ToAccountID:   to,
+			Amount:        amount,
+			Currency:      "USD",
+			Status:        "SUCCESS",
+			Timestamp:     base.Add(time.Duration(minutes) * time.Minute).Format(time.RFC3339),
+		}
+	}
+
+	t.Run("SaveAndGet", func(t *testing.T) {
+		r := open(t)
+		require.NoError(t, r.SaveEntry(ctx, entry("tx-1", 1, 2, 10, 0)))
+		require.NoError(t, r.SaveEntry(ctx, entry("tx-2", 2, 1, 5, 1)))
+		require.NoError(t, r.SaveEntry(ctx, entry("tx-3", 3, 4, 7, 2)))
+
+		entries, err := r.GetEntriesByAccountID(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"tx-2", "tx-1"}, transactionIDs(entries), "both directions, newest first")
+		assert.Equal(t, entry("tx-2", 2, 1, 5, 1), entries[0])
+
+		entries, err = r.GetEntriesByAccountID(ctx, 99)
+		require.NoError(t, err)
+		assert.Empty(t, entries)
+	})
+
+	t.Run("GetByTransactionID", func(t *testing.T) {
+		r := open(t)
+		require.NoError(t, r.SaveEntry(ctx, entry("tx-1", 1, 2, 10, 0)))
+
+		got, err := r.GetEntryByTransactionID(ctx, "tx-1")
+		require.NoError(t, err)
+		assert.Equal(t, entry("tx-1", 1, 2, 10, 0), got)
+
+		_, err = r.GetEntryByTransactionID(ctx, "tx-2")
+		assert.ErrorIs(t, err, domain.ErrTransactionNotFound)
+	})
+
+	t.Run("DuplicateTransaction", func(t *testing.T) {
+		r := open(t)
+		require.NoError(t, r.SaveEntry(ctx, entry("tx-1", 1, 2, 10, 0)))
+		err := r.SaveEntry(ctx, entry("tx-1", 1, 2, 10, 0))
+		assert.ErrorIs(t, err, domain.ErrConflict, "a transfer is recorded once")
+	})
+
+	t.Run("ListFilters", func(t *testing.T) {
+		r := open(t)
+		for _, e := range []*domain.LedgerEntry{
+			entry("tx-1", 1, 2, 10, 0),
+			entry("tx-2", 2, 1, 50, 10),
+			entry("tx-3", 1, 3, 100, 20),
+			entry("tx-4", 3, 1, 5, 30),
+		} {
+			require.NoError(t, r.SaveEntry(ctx, e))
+		}
+		eur := entry("tx-5", 1, 2, 20, 40)
+		eur.Currency = "EUR"
+		require.NoError(t, r.SaveEntry(ctx, eur))
+
+		for name, tc := range map[string]struct {
+			filter domain.HistoryFilter
+			want   []string
+		}{
+			"none":                  {domain.HistoryFilter{}, []string{"tx-5", "tx-4", "tx-3", "tx-2", "tx-1"}},
+			"incoming":              {domain.HistoryFilter{Direction: domain.DirectionIncoming}, []string{"tx-4", "tx-2"}},
+			"outgoing":              {domain.HistoryFilter{Direction: domain.DirectionOutgoing}, []string{"tx-5", "tx-3", "tx-1"}},
+			"counterparty":          {domain.HistoryFilter{CounterpartyID: 3}, []string{"tx-4", "tx-3"}},
+			"counterparty incoming": {domain.HistoryFilter{CounterpartyID: 3, Direction: domain.DirectionIncoming}, []string{"tx-4"}},
+			"amount range":          {domain.HistoryFilter{MinAmount: 10, MaxAmount: 50}, []string{"tx-5", "tx-2", "tx-1"}},
+			"currency":              {domain.HistoryFilter{Currency: "EUR"}, []string{"tx-5"}},
+			"status":                {domain.HistoryFilter{Status: "FAILED"}, []string{}},
+			"time range":            {domain.HistoryFilter{From: base.Add(10 * time.Minute), To: base.Add(30 * time.Minute)}, []string{"tx-4", "tx-3", "tx-2"}},
+		} {
+			t.Run(name, func(t *testing.T) {
+				page, err := r.ListEntriesByAccountID(ctx, 1, tc.filter)
+				require.NoError(t, err)
+				assert.Equal(t, tc.want, transactionIDs(page.Entries))
+				assert.Empty(t, page.NextCursor)
+			})
+		}
+	})
+
+	t.Run("ListPages", func(t *testing.T) {
+		r := open(t)
+		// Entries sharing a timestamp are ordered by transaction ID
+		for i := range 5 {
+			require.NoError(t, r.SaveEntry(ctx, entry(fmt.Sprintf("tx-%d", i), 1, 2, 1, i/2)))
+		}
+
+		var ids []string
+		filter := domain.HistoryFilter{Limit: 2}
+		for pages := 0; ; pages++ {
+			require.Less(t, pages, 5, "listing never ended")
+			page, err := r.ListEntriesByAccountID(ctx, 2, filter)
+			require.NoError(t, err)
+			ids = append(ids, transactionIDs(page.Entries)...)
+			if page.NextCursor == "" {
+				break
+			}
+			filter.Cursor = page.NextCursor
+		}
+		assert.Equal(t, []string{"tx-4", "tx-3", "tx-2", "tx-1", "tx-0"}, ids)
+	})
+
+	t.Run("ListRejectsBadCursor", func(t *testing.T) {
+		r := open(t)
+		_, err := r.ListEntriesByAccountID(ctx, 1, domain.HistoryFilter{Cursor: "not a cursor"})
+		assert.ErrorIs(t, err, domain.ErrInvalidCursor)
+	})
+}
+
+func transactionIDs(entries []*domain.LedgerEntry) []string {
+	ids := []string{}
+	for _, e := range entries {
+		ids = append(ids, e.TransactionID)
+	}
+	return ids
+}
@@ -0,0 +1,107 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"ledger/internal/domain"
+)
+
+// TestWebhookRepository runs the webhook suite, calling open for a fresh store in every subtest
+func TestWebhookRepository(t *testing.T, open func(t *testing.T) domain.WebhookRepository) {
+	ctx := context.Background()
+	subscription := func(id string, active bool) *domain.WebhookSubscription {
+		return &domain.WebhookSubscription{ID: id, URL: "https://example.test/" + id, Secret: "secret", EventTypes: []string{domain.EventTransactionSucceeded}, Active: active}
+	}
+
+	t.Run("ListSubscriptionsForEvent", func(t *testing.T) {
+		webhooks := open(t)
+		require.NoError(t, webhooks.CreateSubscription(ctx, subscription("sub-1", true)))
+		require.NoError(t, webhooks.CreateSubscription(ctx, subscription("sub-2", false)))
+
+		subs, err := webhooks.ListSubscriptionsForEvent(ctx, domain.EventTransactionSucceeded)
+		require.NoError(t, err)
+		if assert.Len(t, subs, 1, "only active subscriptions receive events") {
+			assert.Equal(t, "sub-1", subs[0].ID)
+			assert.Equal(t, []string{domain.EventTransactionSucceeded}, subs[0].EventTypes)
+		}
+
+		subs, err = webhooks.ListSubscriptionsForEvent(ctx, domain.EventAccountFrozen)
+		require.NoError(t, err)
+		assert.Empty(t, subs)
+	})
+
+	t.Run("Deliveries", func(t *testing.T) {
+		webhooks := open(t)
+		require.NoError(t, webhooks.CreateSubscription(ctx, subscription("sub-1", true)))
+
+		delivery := func(id, subscriptionID string) *domain.WebhookDelivery {
+			return &domain.WebhookDelivery{ID: id, SubscriptionID: subscriptionID, EventID: "evt-1", EventType: domain.EventTransactionSucceeded, Payload: []byte(`{"id":"evt-1"}`), Status: domain.DeliveryPending}
+		}
+		require.NoError(t, webhooks.CreateDelivery(ctx, delivery("d-1", "sub-1")))
+		assert.ErrorIs(t, webhooks.CreateDelivery(ctx, delivery("d-2", "missing")), domain.ErrConflict)
+		require.NoError(t, webhooks.UpdateDelivery(ctx, &domain.WebhookDelivery{ID: "d-1", Status: domain.DeliverySucceeded, Attempts: 1, ResponseCode: 200}))
+		assert.ErrorIs(t, webhooks.UpdateDelivery(ctx, &domain.WebhookDelivery{ID: "missing"}), domain.ErrDeliveryNotFound)
+
+		d, err := webhooks.GetDelivery(ctx, "d-1")
+		require.NoError(t, err)
+		assert.Equal(t, domain.DeliverySucceeded, d.Status)
+		assert.Equal(t, 200, d.ResponseCode)
+		assert.JSONEq(t, `{"id":"evt-1"}`, string(d.Payload))
+
+		listed, err := webhooks.ListDeliveries(ctx, "sub-1")
+		require.NoError(t, err)
+		if assert.Len(t, listed, 1) {
+			assert.Equal(t, "d-1", listed[0].ID)
+		}
+	})
+
+	t.Run("ClaimDueDeliveries", func(t *testing.T) {
+		webhooks := open(t)
+		require.NoError(t, webhooks.CreateSubscription(ctx, subscription("sub-1", true)))
+
+		now := time.Now().UTC().Truncate(time.Millisecond)
+		delivery := func(id, status string, next time.Time) *domain.WebhookDelivery {
+			return &domain.WebhookDelivery{ID: id, SubscriptionID: "sub-1", EventID: "evt-1", EventType: domain.EventTransactionSucceeded, Payload: []byte(`{}`), Status: status, NextAttemptAt: next}
+		}
+		require.NoError(t, webhooks.CreateDelivery(ctx, delivery("d-due", domain.DeliveryPending, now.Add(-time.Minute))))
+		require.NoError(t, webhooks.CreateDelivery(ctx, delivery("d-later", domain.DeliveryPending, now.Add(time.Hour))))
+		require.NoError(t, webhooks.CreateDelivery(ctx, delivery("d-done", domain.DeliverySucceeded, now.Add(-time.Minute))))
+
+		until := now.Add(time.Minute)
+		claimed, err := webhooks.ClaimDueDeliveries(ctx, now, until, 10)
+		require.NoError(t, err)
+		if assert.Len(t, claimed, 1, "only pending deliveries that are due") {
+			assert.Equal(t, "d-due", claimed[0].ID)
+			assert.JSONEq(t, `{}`, string(claimed[0].Payload))
+			assert.WithinDuration(t, until, claimed[0].NextAttemptAt, time.Millisecond)
+		}
+
+		claimed, err = webhooks.ClaimDueDeliveries(ctx, now, until, 10)
+		require.NoError(t, err)
+		assert.Empty(t, claimed, "a claimed delivery is not due again until its claim runs out")
+
+		claimed, err = webhooks.ClaimDueDeliveries(ctx, now.Add(2*time.Hour), now.Add(3*time.Hour), 1)
+		require.NoError(t, err)
+		if assert.Len(t, claimed, 1, "claims stop at the limit") {
+			assert.Equal(t, "d-due", claimed[0].ID, "the delivery due longest is claimed first")
+		}
+	})
+
+	t.Run("DeleteSubscription", func(t *testing.T) {
+		webhooks := open(t)
+		require.NoError(t, webhooks.CreateSubscription(ctx, subscription("sub-1", true)))
+		require.NoError(t, webhooks.CreateDelivery(ctx, &domain.WebhookDelivery{ID: "d-1", SubscriptionID: "sub-1", EventID: "evt-1", EventType: domain.EventTransactionSucceeded, Payload: []byte(`{}`), Status: domain.DeliveryPending}))
+
+		require.NoError(t, webhooks.DeleteSubscription(ctx, "sub-1"))
+		_, err := webhooks.GetSubscription(ctx, "sub-1")
+		assert.ErrorIs(t, err, domain.ErrSubscriptionNotFound)
+		_, err = webhooks.GetDelivery(ctx, "d-1")
+		assert.ErrorIs(t, err, domain.ErrDeliveryNotFound, "deliveries go with their subscription")
+		assert.ErrorIs(t, webhooks.DeleteSubscription(ctx, "sub-1"), domain.ErrSubscriptionNotFound)
+	})
+}
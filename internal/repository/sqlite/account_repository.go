@@ -0,0 +1,244 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"ledger/internal/domain"
+)
+
+type AccountRepository struct {
+	db *sql.DB
+}
+
+func NewAccountRepository(db *sql.DB) *AccountRepository {
+	return &AccountRepository{db: db}
+}
+
+func (r *AccountRepository) Create(ctx context.Context, account *domain.Account) error {
+	if account.ID == "" {
+		var id int64
+		err := conn(ctx, r.db).QueryRowContext(ctx, `INSERT INTO account_ids DEFAULT VALUES RETURNING id`).Scan(&id)
+		if err != nil {
+			return err
+		}
+		account.ID = strconv.FormatInt(id, 10)
+	}
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
+		INSERT INTO accounts (id, owner_name, balance, currency, customer_id, status)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, account.ID, account.OwnerName, account.Balance, account.Currency, nullString(account.CustomerID), account.Status)
+	return mapError(err)
+}
+
+func (r *AccountRepository) GetByID(ctx context.Context, id string) (*domain.Account, error) {
+	row := conn(ctx, r.db).QueryRowContext(ctx, `
+		SELECT id, owner_name, balance, currency, customer_id, status
+		FROM accounts
+		WHERE id = ?
+	`, id)
+
+	account, err := scanAccount(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return &domain.Account{}, domain.ErrAccountNotFound
+		}
+		return &domain.Account{}, err
+	}
+	return account, nil
+}
+
+func (r *AccountRepository) GetAll(ctx context.Context) ([]*domain.Account, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT id, owner_name, balance, currency, customer_id, status
+		FROM accounts
+		ORDER BY created_at, rowid
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanAccounts(rows)
+}
+
+func (r *AccountRepository) GetByCustomerID(ctx context.Context, customerID string) ([]*domain.Account, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT id, owner_name, balance, currency, customer_id, status
+		FROM accounts
+		WHERE customer_id = ?
+		ORDER BY created_at, rowid
+	`, customerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanAccounts(rows)
+}
+
+// accountSortColumns whitelists the columns accounts may be ordered by. IDs
+// order as numbers, like the Postgres SERIAL column.
+var accountSortColumns = map[string]string{
+	domain.SortByID:        idOrder,
+	domain.SortByOwnerName: "owner_name",
+	domain.SortByBalance:   "balance",
+	domain.SortByCreatedAt: "created_at",
+}
+
+// idOrder orders account IDs numerically, with the text breaking ties between
+// IDs that are not numbers
+const idOrder = "CAST(id AS INTEGER)"
+
+// List returns one page of accounts using keyset pagination on (sort column, id)
+func (r *AccountRepository) List(ctx context.Context, opts domain.AccountListOptions) (*domain.AccountPage, error) {
+	sortBy := opts.SortBy
+	if sortBy == "" {
+		sortBy = domain.SortByID
+	}
+	col, ok := accountSortColumns[sortBy]
+	if !ok {
+		return nil, fmt.Errorf("unsupported sort field %q", opts.SortBy)
+	}
+	cursor, err := domain.DecodeCursor(opts.Cursor)
+	if err != nil {
+		return nil, err
+	}
+	if cursor != nil && cursor.Sort != sortBy {
+		return nil, domain.ErrInvalidCursor
+	}
+	limit := domain.ClampPageSize(opts.Limit)
+
+	dir, op := "ASC", ">"
+	if opts.Desc {
+		dir, op = "DESC", "<"
+	}
+
+	query := fmt.Sprintf(`
+		SELECT id, owner_name, balance, currency, customer_id, status, CAST(%s AS TEXT)
+		FROM accounts`, col)
+	var args []any
+	var conds []string
+	if opts.CustomerID != "" {
+		args = append(args, opts.CustomerID)
+		conds = append(conds, "customer_id = ?")
+	}
+	if cursor != nil {
+		// The key keeps the column's type so balances compare as numbers
+		args = append(args, cursor.Key, cursor.ID, cursor.ID)
+		conds = append(conds, fmt.Sprintf("(%s, %s, id) %s (CAST(? AS %s), CAST(? AS INTEGER), ?)", col, idOrder, op, accountColumnTypes[col]))
+	}
+	if len(conds) > 0 {
+		query += `
+		WHERE ` + strings.Join(conds, " AND ")
+	}
+	query += fmt.Sprintf(`
+		ORDER BY %s %s, %s %s, id %s
+		LIMIT ?`, col, dir, idOrder, dir, dir)
+	// Fetch one extra row to find out whether another page exists
+	args = append(args, limit+1)
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	page := &domain.AccountPage{Accounts: []*domain.Account{}}
+	var lastKey string
+	for rows.Next() {
+		var account domain.Account
+		var customerID sql.NullString
+		var key string
+		if err := rows.Scan(&account.ID, &account.OwnerName, &account.Balance, &account.Currency, &customerID, &account.Status, &key); err != nil {
+			return nil, err
+		}
+		if len(page.Accounts) == limit {
+			page.NextCursor = domain.EncodeCursor(domain.Cursor{Sort: sortBy, Key: lastKey, ID: page.Accounts[limit-1].ID})
+			break
+		}
+		account.CustomerID = customerID.String
+		page.Accounts = append(page.Accounts, &account)
+		lastKey = key
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return page, nil
+}
+
+// accountColumnTypes are the storage types of the sortable columns
+var accountColumnTypes = map[string]string{
+	idOrder:      "INTEGER",
+	"owner_name": "TEXT",
+	"balance":    "REAL",
+	"created_at": "TEXT",
+}
+
+func (r *AccountRepository) UpdateBalance(ctx context.Context, id string, amount float64) error {
+	res, err := conn(ctx, r.db).ExecContext(ctx, `
+		UPDATE accounts
+		SET balance = balance + ?
+		WHERE id = ?
+	`, amount, id)
+	if err != nil {
+		return mapError(err)
+	}
+	return expectAffected(res, domain.ErrAccountNotFound)
+}
+
+func (r *AccountRepository) UpdateStatus(ctx context.Context, id string, status string) error {
+	res, err := conn(ctx, r.db).ExecContext(ctx, `
+		UPDATE accounts
+		SET status = ?
+		WHERE id = ?
+	`, status, id)
+	if err != nil {
+		return err
+	}
+	return expectAffected(res, domain.ErrAccountNotFound)
+}
+
+func (r *AccountRepository) Delete(ctx context.Context, id string) error {
+	res, err := conn(ctx, r.db).ExecContext(ctx, `
+		DELETE FROM accounts
+		WHERE id = ?
+	`, id)
+	if err != nil {
+		return mapError(err)
+	}
+	return expectAffected(res, domain.ErrAccountNotFound)
+}
+
+func scanAccount(s scanner) (*domain.Account, error) {
+	var account domain.Account
+	var customerID sql.NullString
+	if err := s.Scan(&account.ID, &account.OwnerName, &account.Balance, &account.Currency, &customerID, &account.Status); err != nil {
+		return nil, err
+	}
+	account.CustomerID = customerID.String
+	return &account, nil
+}
+
+func scanAccounts(rows *sql.Rows) ([]*domain.Account, error) {
+	var accounts []*domain.Account
+	for rows.Next() {
+		account, err := scanAccount(rows)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, account)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return accounts, nil
+}
@@ -0,0 +1,92 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"ledger/internal/domain"
+)
+
+type APIKeyRepository struct {
+	db *sql.DB
+}
+
+func NewAPIKeyRepository(db *sql.DB) *APIKeyRepository {
+	return &APIKeyRepository{db: db}
+}
+
+func (r *APIKeyRepository) Create(ctx context.Context, key *domain.APIKey) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
+		INSERT INTO api_keys (id, name, prefix, key_hash, role, customer_id)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, key.ID, key.Name, key.Prefix, key.KeyHash, key.Role, nullString(key.CustomerID))
+	return mapError(err)
+}
+
+func (r *APIKeyRepository) GetByHash(ctx context.Context, hash string) (*domain.APIKey, error) {
+	row := conn(ctx, r.db).QueryRowContext(ctx, `
+		SELECT id, name, prefix, key_hash, role, customer_id, revoked_at IS NOT NULL, created_at
+		FROM api_keys
+		WHERE key_hash = ? AND revoked_at IS NULL
+	`, hash)
+
+	key, err := scanAPIKey(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrAPIKeyNotFound
+		}
+		return nil, err
+	}
+	return key, nil
+}
+
+func (r *APIKeyRepository) List(ctx context.Context) ([]*domain.APIKey, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT id, name, prefix, key_hash, role, customer_id, revoked_at IS NOT NULL, created_at
+		FROM api_keys
+		ORDER BY created_at, rowid
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []*domain.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+func (r *APIKeyRepository) Revoke(ctx context.Context, id string) error {
+	res, err := conn(ctx, r.db).ExecContext(ctx, `
+		UPDATE api_keys
+		SET revoked_at = strftime('%Y-%m-%d %H:%M:%f', 'now')
+		WHERE id = ? AND revoked_at IS NULL
+	`, id)
+	if err != nil {
+		return err
+	}
+	return expectAffected(res, domain.ErrAPIKeyNotFound)
+}
+
+func scanAPIKey(s scanner) (*domain.APIKey, error) {
+	var key domain.APIKey
+	var customerID sql.NullString
+	err := s.Scan(&key.ID, &key.Name, &key.Prefix, &key.KeyHash, &key.Role, &customerID, &key.Revoked, &key.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	key.CustomerID = customerID.String
+	return &key, nil
+}
@@ -0,0 +1,182 @@
+// Package sqlite stores accounts, customers, API keys, webhooks and the ledger
+// in a single SQLite database file, for single-node deployments without
+// Postgres or MongoDB. The schema mirrors the Postgres tables and the MongoDB
+// ledger collection, constraints included.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+
+	_ "modernc.org/sqlite" // SQLite driver
+)
+
+// Open opens the database file at path, creating it and its schema if needed.
+// The database runs in WAL mode so reads proceed while a transfer is written,
+// and transactions take the write lock up front so concurrent transfers queue
+// up instead of failing to upgrade their lock.
+func Open(ctx context.Context, path string) (*sql.DB, error) {
+	q := url.Values{}
+	q.Add("_pragma", "journal_mode(WAL)")
+	q.Add("_pragma", "foreign_keys(1)")
+	q.Add("_pragma", "busy_timeout(5000)")
+	q.Add("_pragma", "synchronous(NORMAL)")
+	q.Set("_txlock", "immediate")
+	db, err := sql.Open("sqlite", "file:"+path+"?"+q.Encode())
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.ExecContext(ctx, schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("create sqlite schema: %w", err)
+	}
+	if err := upgrade(ctx, db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("upgrade sqlite schema: %w", err)
+	}
+	return db, nil
+}
+
+// timestampLayout matches strftime('%Y-%m-%d %H:%M:%f') so stored times
+// compare as text
+const timestampLayout = "2006-01-02 15:04:05.000"
+
+// upgrade adds the columns schema gained since a database file was created.
+// Deliveries recorded before next_attempt_at existed keep it empty, which
+// sorts before any timestamp, so a sweeper picks them up straight away.
+func upgrade(ctx context.Context, db *sql.DB) error {
+	var found int
+	err := db.QueryRowContext(ctx, `
+		SELECT count(*) FROM pragma_table_info('webhook_deliveries') WHERE name = 'next_attempt_at'
+	`).Scan(&found)
+	if err != nil {
+		return err
+	}
+	if found == 0 {
+		if _, err := db.ExecContext(ctx, `ALTER TABLE webhook_deliveries ADD COLUMN next_attempt_at TEXT NOT NULL DEFAULT ''`); err != nil {
+			return err
+		}
+	}
+	_, err = db.ExecContext(ctx, `CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries(status, next_attempt_at)`)
+	return err
+}
+
+// schema is applied on every Open; each statement is idempotent
+const schema = `
+CREATE TABLE IF NOT EXISTS customers (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    email TEXT NOT NULL DEFAULT '',
+    created_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+
+CREATE TABLE IF NOT EXISTS accounts (
+    id TEXT PRIMARY KEY,
+    owner_name TEXT NOT NULL,
+    balance REAL NOT NULL DEFAULT 0,
+    currency TEXT NOT NULL,
+    customer_id TEXT REFERENCES customers(id),
+    status TEXT NOT NULL DEFAULT 'ACTIVE',
+    created_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+CREATE INDEX IF NOT EXISTS idx_accounts_customer_id ON accounts(customer_id);
+-- Hands out account numbers like the Postgres SERIAL column, never reusing one
+CREATE TABLE IF NOT EXISTS account_ids (
+    id INTEGER PRIMARY KEY AUTOINCREMENT
+);
+
+CREATE TABLE IF NOT EXISTS ledger_entries (
+    id TEXT NOT NULL,
+    transaction_id TEXT NOT NULL UNIQUE CHECK (transaction_id <> ''),
+    from_account_id INTEGER NOT NULL,
+    to_account_id INTEGER NOT NULL,
+    amount REAL NOT NULL CHECK (amount >= 0),
+    currency TEXT NOT NULL,
+    status TEXT NOT NULL,
+    timestamp TEXT NOT NULL,
+    correlation_id TEXT NOT NULL DEFAULT ''
+);
+CREATE INDEX IF NOT EXISTS idx_ledger_from_account ON ledger_entries(from_account_id, timestamp DESC, transaction_id DESC);
+CREATE INDEX IF NOT EXISTS idx_ledger_to_account ON ledger_entries(to_account_id, timestamp DESC, transaction_id DESC);
+
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+    id TEXT PRIMARY KEY,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    event_types TEXT NOT NULL,
+    active INTEGER NOT NULL DEFAULT 1,
+    created_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id TEXT PRIMARY KEY,
+    subscription_id TEXT NOT NULL REFERENCES webhook_subscriptions(id) ON DELETE CASCADE,
+    event_id TEXT NOT NULL,
+    event_type TEXT NOT NULL,
+    payload TEXT NOT NULL,
+    status TEXT NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    response_code INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT '',
+    created_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    updated_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    next_attempt_at TEXT NOT NULL DEFAULT ''
+);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_subscription_id ON webhook_deliveries(subscription_id, created_at);
+
+CREATE TABLE IF NOT EXISTS api_keys (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    prefix TEXT NOT NULL,
+    key_hash TEXT NOT NULL UNIQUE,
+    role TEXT NOT NULL CHECK (role IN ('viewer', 'operator', 'admin')),
+    customer_id TEXT REFERENCES customers(id) ON DELETE CASCADE,
+    revoked_at TEXT,
+    created_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+`
+
+// querier is satisfied by both *sql.DB and *sql.Tx
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+type txKey struct{}
+
+// conn returns the transaction ctx runs in, or db outside of one
+func conn(ctx context.Context, db *sql.DB) querier {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return tx
+	}
+	return db
+}
+
+// Transactor runs repository calls in one SQLite transaction
+type Transactor struct {
+	db *sql.DB
+}
+
+func NewTransactor(db *sql.DB) *Transactor {
+	return &Transactor{db: db}
+}
+
+// WithinTransaction runs fn in a transaction, committing it when fn returns
+// nil. Calls nested in fn join the outer transaction.
+func (t *Transactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return fn(ctx)
+	}
+	tx, err := t.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
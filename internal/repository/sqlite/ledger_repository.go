@@ -0,0 +1,186 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+
+	"ledger/internal/domain"
+)
+
+// LedgerRepository keeps ledger entries in the same database as the accounts,
+// so a transfer's balance updates and its entry can commit together
+type LedgerRepository struct {
+	db *sql.DB
+}
+
+func NewLedgerRepository(db *sql.DB) *LedgerRepository {
+	return &LedgerRepository{db: db}
+}
+
+func (r *LedgerRepository) SaveEntry(ctx context.Context, entry *domain.LedgerEntry) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
+		INSERT INTO ledger_entries (id, transaction_id, from_account_id, to_account_id, amount, currency, status, timestamp, correlation_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, entry.ID, entry.TransactionID, entry.FromAccountID, entry.ToAccountID, entry.Amount,
+		entry.Currency, entry.Status, entry.Timestamp, entry.CorrelationID)
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE {
+		return fmt.Errorf("%w: transaction %s is already recorded", domain.ErrConflict, entry.TransactionID)
+	}
+	return mapError(err)
+}
+
+func (r *LedgerRepository) GetEntryByTransactionID(ctx context.Context, transactionID string) (*domain.LedgerEntry, error) {
+	row := conn(ctx, r.db).QueryRowContext(ctx, `
+		SELECT id, transaction_id, from_account_id, to_account_id, amount, currency, status, timestamp, correlation_id
+		FROM ledger_entries
+		WHERE transaction_id = ?
+	`, transactionID)
+
+	entry, err := scanEntry(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrTransactionNotFound
+	}
+	return entry, err
+}
+
+func (r *LedgerRepository) GetEntriesByAccountID(ctx context.Context, accountID int64) ([]*domain.LedgerEntry, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT id, transaction_id, from_account_id, to_account_id, amount, currency, status, timestamp, correlation_id
+		FROM ledger_entries
+		WHERE from_account_id = ? OR to_account_id = ?
+		ORDER BY timestamp DESC, transaction_id DESC
+	`, accountID, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*domain.LedgerEntry
+	for rows.Next() {
+		entry, err := scanEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+// ListEntriesByAccountID returns one page of an account's ledger entries, newest first,
+// using keyset pagination on (timestamp, transaction_id)
+func (r *LedgerRepository) ListEntriesByAccountID(ctx context.Context, accountID int64, filter domain.HistoryFilter) (*domain.LedgerPage, error) {
+	cursor, err := domain.DecodeCursor(filter.Cursor)
+	if err != nil {
+		return nil, err
+	}
+	limit := domain.ClampPageSize(filter.Limit)
+
+	where, args := historyQuery(accountID, filter, cursor)
+	// Fetch one extra row to find out whether another page exists
+	args = append(args, limit+1)
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT id, transaction_id, from_account_id, to_account_id, amount, currency, status, timestamp, correlation_id
+		FROM ledger_entries
+		WHERE `+where+`
+		ORDER BY timestamp DESC, transaction_id DESC
+		LIMIT ?`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	page := &domain.LedgerPage{Entries: []*domain.LedgerEntry{}}
+	for rows.Next() {
+		if len(page.Entries) == limit {
+			last := page.Entries[limit-1]
+			page.NextCursor = domain.EncodeCursor(domain.Cursor{Key: last.Timestamp, ID: last.TransactionID})
+			break
+		}
+		entry, err := scanEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		page.Entries = append(page.Entries, entry)
+	}
+
+	return page, rows.Err()
+}
+
+// historyQuery builds the WHERE clause for an account's history page, with the
+// same conditions as the MongoDB history query
+func historyQuery(accountID int64, filter domain.HistoryFilter, cursor *domain.Cursor) (string, []any) {
+	var clauses []string
+	var args []any
+
+	switch {
+	case filter.CounterpartyID != 0 && filter.Direction == domain.DirectionIncoming:
+		clauses = append(clauses, "from_account_id = ? AND to_account_id = ?")
+		args = append(args, filter.CounterpartyID, accountID)
+	case filter.CounterpartyID != 0 && filter.Direction == domain.DirectionOutgoing:
+		clauses = append(clauses, "from_account_id = ? AND to_account_id = ?")
+		args = append(args, accountID, filter.CounterpartyID)
+	case filter.CounterpartyID != 0:
+		clauses = append(clauses, "((from_account_id = ? AND to_account_id = ?) OR (from_account_id = ? AND to_account_id = ?))")
+		args = append(args, accountID, filter.CounterpartyID, filter.CounterpartyID, accountID)
+	case filter.Direction == domain.DirectionIncoming:
+		clauses = append(clauses, "to_account_id = ?")
+		args = append(args, accountID)
+	case filter.Direction == domain.DirectionOutgoing:
+		clauses = append(clauses, "from_account_id = ?")
+		args = append(args, accountID)
+	default:
+		clauses = append(clauses, "(from_account_id = ? OR to_account_id = ?)")
+		args = append(args, accountID, accountID)
+	}
+
+	if !filter.From.IsZero() {
+		clauses = append(clauses, "timestamp >= ?")
+		args = append(args, filter.From.UTC().Format(time.RFC3339))
+	}
+	if !filter.To.IsZero() {
+		clauses = append(clauses, "timestamp <= ?")
+		args = append(args, filter.To.UTC().Format(time.RFC3339))
+	}
+	if filter.MinAmount > 0 {
+		clauses = append(clauses, "amount >= ?")
+		args = append(args, filter.MinAmount)
+	}
+	if filter.MaxAmount > 0 {
+		clauses = append(clauses, "amount <= ?")
+		args = append(args, filter.MaxAmount)
+	}
+	if filter.Currency != "" {
+		clauses = append(clauses, "currency = ?")
+		args = append(args, filter.Currency)
+	}
+	if filter.Status != "" {
+		clauses = append(clauses, "status = ?")
+		args = append(args, filter.Status)
+	}
+
+	if cursor != nil {
+		clauses = append(clauses, "(timestamp, transaction_id) < (?, ?)")
+		args = append(args, cursor.Key, cursor.ID)
+	}
+
+	return strings.Join(clauses, " AND "), args
+}
+
+func scanEntry(s scanner) (*domain.LedgerEntry, error) {
+	var entry domain.LedgerEntry
+	err := s.Scan(&entry.ID, &entry.TransactionID, &entry.FromAccountID, &entry.ToAccountID, &entry.Amount,
+		&entry.Currency, &entry.Status, &entry.Timestamp, &entry.CorrelationID)
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
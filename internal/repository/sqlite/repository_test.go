@@ -0,0 +1,155 @@
+package sqlite_test
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"ledger/internal/domain"
+	"ledger/internal/repository/repotest"
+	"ledger/internal/repository/sqlite"
+	"ledger/internal/service"
+)
+
+func openDB(t *testing.T) *sql.DB {
+	db, err := sqlite.Open(context.Background(), filepath.Join(t.TempDir(), "ledger.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestAccountRepository(t *testing.T) {
+	repotest.TestAccountRepository(t, func(t *testing.T) repotest.AccountStore {
+		db := openDB(t)
+		customers := sqlite.NewCustomerRepository(db)
+		return repotest.AccountStore{
+			Accounts: sqlite.NewAccountRepository(db),
+			AddCustomer: func(ctx context.Context, id string) error {
+				return customers.Create(ctx, &domain.Customer{ID: id, Name: id})
+			},
+		}
+	})
+}
+
+func TestLedgerRepository(t *testing.T) {
+	repotest.TestLedgerRepository(t, func(t *testing.T) domain.LedgerRepository {
+		return sqlite.NewLedgerRepository(openDB(t))
+	})
+}
+
+func TestCustomerRepository(t *testing.T) {
+	repotest.TestCustomerRepository(t, func(t *testing.T) repotest.CustomerStore {
+		db := openDB(t)
+		return repotest.CustomerStore{
+			Customers: sqlite.NewCustomerRepository(db),
+			Accounts:  sqlite.NewAccountRepository(db),
+			APIKeys:   sqlite.NewAPIKeyRepository(db),
+		}
+	})
+}
+
+func TestAPIKeyRepository(t *testing.T) {
+	repotest.TestAPIKeyRepository(t, func(t *testing.T) domain.APIKeyRepository {
+		return sqlite.NewAPIKeyRepository(openDB(t))
+	})
+}
+
+func TestWebhookRepository(t *testing.T) {
+	repotest.TestWebhookRepository(t, func(t *testing.T) domain.WebhookRepository {
+		return sqlite.NewWebhookRepository(openDB(t))
+	})
+}
+
+func TestOpenKeepsData(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "ledger.db")
+	db, err := sqlite.Open(ctx, path)
+	require.NoError(t, err)
+	require.NoError(t, sqlite.NewAccountRepository(db).Create(ctx, &domain.Account{ID: "acc-1", Balance: 10, Currency: "USD", Status: domain.AccountStatusActive}))
+	require.NoError(t, db.Close())
+
+	db, err = sqlite.Open(ctx, path)
+	require.NoError(t, err)
+	defer db.Close()
+	var mode string
+	require.NoError(t, db.QueryRowContext(ctx, "PRAGMA journal_mode").Scan(&mode))
+	assert.Equal(t, "wal", mode)
+	account, err := sqlite.NewAccountRepository(db).GetByID(ctx, "acc-1")
+	require.NoError(t, err)
+	assert.Equal(t, 10.0, account.Balance)
+}
+
+func TestOpenUpgradesDeliveries(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "ledger.db")
+	old, err := sql.Open("sqlite", path)
+	require.NoError(t, err)
+	// webhook_deliveries as it was before deliveries were scheduled
+	_, err = old.ExecContext(ctx, `
+		CREATE TABLE webhook_deliveries (
+			id TEXT PRIMARY KEY,
+			subscription_id TEXT NOT NULL,
+			event_id TEXT NOT NULL,
+			event_type TEXT NOT NULL,
+			payload TEXT NOT NULL,
+			status TEXT NOT NULL,
+			attempts INTEGER NOT NULL DEFAULT 0,
+			response_code INTEGER NOT NULL DEFAULT 0,
+			last_error TEXT NOT NULL DEFAULT '',
+			created_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+			updated_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+		);
+		INSERT INTO webhook_deliveries (id, subscription_id, event_id, event_type, payload, status)
+		VALUES ('d-1', 'sub-1', 'evt-1', 'balance.low', '{}', 'PENDING');
+	`)
+	require.NoError(t, err)
+	require.NoError(t, old.Close())
+
+	db, err := sqlite.Open(ctx, path)
+	require.NoError(t, err)
+	defer db.Close()
+	now := time.Now()
+	claimed, err := sqlite.NewWebhookRepository(db).ClaimDueDeliveries(ctx, now, now.Add(time.Minute), 10)
+	require.NoError(t, err)
+	if assert.Len(t, claimed, 1, "deliveries from before the upgrade are due") {
+		assert.Equal(t, "d-1", claimed[0].ID)
+	}
+}
+
+func TestTransferIsAtomic(t *testing.T) {
+	ctx := context.Background()
+	db := openDB(t)
+	accounts := sqlite.NewAccountRepository(db)
+	ledger := sqlite.NewLedgerRepository(db)
+	require.NoError(t, accounts.Create(ctx, &domain.Account{ID: "1", Balance: 100, Currency: "USD", Status: domain.AccountStatusActive}))
+	require.NoError(t, accounts.Create(ctx, &domain.Account{ID: "2", Balance: 0, Currency: "USD", Status: domain.AccountStatusActive}))
+
+	svc := service.NewTransactionService(accounts, ledger, nil, nil)
+	svc.Transactor = sqlite.NewTransactor(db)
+
+	tx := &domain.Transaction{ID: "tx-1", FromAccountID: 1, ToAccountID: 2, Amount: 30, Currency: "USD"}
+	require.NoError(t, svc.ProcessTransaction(ctx, tx))
+	// A redelivered transfer is not applied again
+	retry := *tx
+	require.NoError(t, svc.ProcessTransaction(ctx, &retry))
+	// A ledger entry the schema rejects rolls back the balance updates before it
+	bad := &domain.Transaction{ID: "tx-2", FromAccountID: 1, ToAccountID: 2, Amount: -5, Currency: "USD"}
+	assert.ErrorIs(t, svc.ProcessTransaction(ctx, bad), domain.ErrValidation)
+	assert.NotErrorIs(t, svc.ProcessTransaction(ctx, bad), domain.ErrTransferIncomplete)
+
+	from, err := accounts.GetByID(ctx, "1")
+	require.NoError(t, err)
+	to, err := accounts.GetByID(ctx, "2")
+	require.NoError(t, err)
+	assert.Equal(t, 70.0, from.Balance)
+	assert.Equal(t, 30.0, to.Balance)
+
+	entries, err := ledger.GetEntriesByAccountID(ctx, 1)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
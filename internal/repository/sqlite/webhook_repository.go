@@ -0,0 +1,239 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"ledger/internal/domain"
+)
+
+type WebhookRepository struct {
+	db *sql.DB
+}
+
+func NewWebhookRepository(db *sql.DB) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+func (r *WebhookRepository) CreateSubscription(ctx context.Context, sub *domain.WebhookSubscription) error {
+	// Event types are kept as a JSON array, queried with json_each
+	eventTypes, err := json.Marshal(sub.EventTypes)
+	if err != nil {
+		return err
+	}
+	_, err = conn(ctx, r.db).ExecContext(ctx, `
+		INSERT INTO webhook_subscriptions (id, url, secret, event_types, active)
+		VALUES (?, ?, ?, ?, ?)
+	`, sub.ID, sub.URL, sub.Secret, string(eventTypes), sub.Active)
+	return mapError(err)
+}
+
+func (r *WebhookRepository) GetSubscription(ctx context.Context, id string) (*domain.WebhookSubscription, error) {
+	row := conn(ctx, r.db).QueryRowContext(ctx, `
+		SELECT id, url, secret, event_types, active, created_at
+		FROM webhook_subscriptions
+		WHERE id = ?
+	`, id)
+
+	sub, err := scanSubscription(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrSubscriptionNotFound
+		}
+		return nil, err
+	}
+	return sub, nil
+}
+
+func (r *WebhookRepository) ListSubscriptions(ctx context.Context) ([]*domain.WebhookSubscription, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT id, url, secret, event_types, active, created_at
+		FROM webhook_subscriptions
+		ORDER BY created_at, rowid
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanSubscriptions(rows)
+}
+
+func (r *WebhookRepository) ListSubscriptionsForEvent(ctx context.Context, eventType string) ([]*domain.WebhookSubscription, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT id, url, secret, event_types, active, created_at
+		FROM webhook_subscriptions
+		WHERE active AND EXISTS (SELECT 1 FROM json_each(event_types) WHERE value = ?)
+	`, eventType)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanSubscriptions(rows)
+}
+
+func (r *WebhookRepository) DeleteSubscription(ctx context.Context, id string) error {
+	res, err := conn(ctx, r.db).ExecContext(ctx, `
+		DELETE FROM webhook_subscriptions
+		WHERE id = ?
+	`, id)
+	if err != nil {
+		return err
+	}
+	return expectAffected(res, domain.ErrSubscriptionNotFound)
+}
+
+func (r *WebhookRepository) CreateDelivery(ctx context.Context, d *domain.WebhookDelivery) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
+		INSERT INTO webhook_deliveries (id, subscription_id, event_id, event_type, payload, status, attempts, next_attempt_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, d.ID, d.SubscriptionID, d.EventID, d.EventType, string(d.Payload), d.Status, d.Attempts, formatTime(d.NextAttemptAt))
+	return mapError(err)
+}
+
+func (r *WebhookRepository) UpdateDelivery(ctx context.Context, d *domain.WebhookDelivery) error {
+	res, err := conn(ctx, r.db).ExecContext(ctx, `
+		UPDATE webhook_deliveries
+		SET status = ?, attempts = ?, response_code = ?, last_error = ?, next_attempt_at = ?,
+		    updated_at = strftime('%Y-%m-%d %H:%M:%f', 'now')
+		WHERE id = ?
+	`, d.Status, d.Attempts, d.ResponseCode, d.LastError, formatTime(d.NextAttemptAt), d.ID)
+	if err != nil {
+		return err
+	}
+	return expectAffected(res, domain.ErrDeliveryNotFound)
+}
+
+func (r *WebhookRepository) GetDelivery(ctx context.Context, id string) (*domain.WebhookDelivery, error) {
+	row := conn(ctx, r.db).QueryRowContext(ctx, `
+		SELECT id, subscription_id, event_id, event_type, payload, status, attempts, response_code, last_error, created_at, updated_at, next_attempt_at
+		FROM webhook_deliveries
+		WHERE id = ?
+	`, id)
+
+	d, err := scanDelivery(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrDeliveryNotFound
+		}
+		return nil, err
+	}
+	return d, nil
+}
+
+func (r *WebhookRepository) ListDeliveries(ctx context.Context, subscriptionID string) ([]*domain.WebhookDelivery, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT id, subscription_id, event_id, event_type, payload, status, attempts, response_code, last_error, created_at, updated_at, next_attempt_at
+		FROM webhook_deliveries
+		WHERE subscription_id = ?
+		ORDER BY created_at DESC, rowid DESC
+		LIMIT ?
+	`, subscriptionID, domain.MaxPageSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []*domain.WebhookDelivery{}
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+// ClaimDueDeliveries claims the pending deliveries that have been due longest
+func (r *WebhookRepository) ClaimDueDeliveries(ctx context.Context, now, until time.Time, limit int) ([]*domain.WebhookDelivery, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		UPDATE webhook_deliveries
+		SET next_attempt_at = ?
+		WHERE id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE status = ? AND next_attempt_at <= ?
+			ORDER BY next_attempt_at
+			LIMIT ?
+		)
+		RETURNING id, subscription_id, event_id, event_type, payload, status, attempts, response_code, last_error, created_at, updated_at, next_attempt_at
+	`, formatTime(until), domain.DeliveryPending, formatTime(now), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []*domain.WebhookDelivery{}
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+func scanSubscription(s scanner) (*domain.WebhookSubscription, error) {
+	var sub domain.WebhookSubscription
+	var eventTypes string
+	if err := s.Scan(&sub.ID, &sub.URL, &sub.Secret, &eventTypes, &sub.Active, &sub.CreatedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(eventTypes), &sub.EventTypes); err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+func scanSubscriptions(rows *sql.Rows) ([]*domain.WebhookSubscription, error) {
+	subs := []*domain.WebhookSubscription{}
+	for rows.Next() {
+		sub, err := scanSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		subs = append(subs, sub)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return subs, nil
+}
+
+func scanDelivery(s scanner) (*domain.WebhookDelivery, error) {
+	var d domain.WebhookDelivery
+	var payload, next string
+	err := s.Scan(&d.ID, &d.SubscriptionID, &d.EventID, &d.EventType, &payload, &d.Status,
+		&d.Attempts, &d.ResponseCode, &d.LastError, &d.CreatedAt, &d.UpdatedAt, &next)
+	if err != nil {
+		return nil, err
+	}
+	d.Payload = json.RawMessage(payload)
+	if next != "" {
+		if d.NextAttemptAt, err = time.Parse(timestampLayout, next); err != nil {
+			return nil, err
+		}
+	}
+	return &d, nil
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(timestampLayout)
+}
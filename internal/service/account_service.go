@@ -3,23 +3,26 @@ package service
 import (
 	"context"
 
-	"github.com/google/uuid"
 	"ledger/internal/domain"
 )
 
 type AccountService struct {
 	accountRepo domain.AccountRepository
+	events      domain.EventPublisher
 }
 
-func NewAccountService(accountRepo domain.AccountRepository) *AccountService {
-	return &AccountService{accountRepo: accountRepo}
+func NewAccountService(accountRepo domain.AccountRepository, events domain.EventPublisher) *AccountService {
+	if events == nil {
+		events = domain.NopEventPublisher{}
+	}
+	return &AccountService{accountRepo: accountRepo, events: events}
 }
 
 func (s *AccountService) CreateAccount(ctx context.Context, ownerName string, initialBalance float64) error {
 	account := domain.Account{
-		ID:        uuid.New().String(),
 		OwnerName: ownerName,
 		Balance:   initialBalance,
+		Status:    domain.AccountStatusActive,
 	}
 
 	err := s.accountRepo.Create(ctx, &account)
@@ -45,6 +48,14 @@ func (s *AccountService) GetAllAccounts(ctx context.Context) ([]*domain.Account,
 	return accounts, nil
 }
 
+func (s *AccountService) ListAccounts(ctx context.Context, opts domain.AccountListOptions) (*domain.AccountPage, error) {
+	page, err := s.accountRepo.List(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
 func (s *AccountService) UpdateAccountBalance(ctx context.Context, id string, newBalance float64) error {
 	err := s.accountRepo.UpdateBalance(ctx, id, newBalance)
 	if err != nil {
@@ -52,6 +63,21 @@ func (s *AccountService) UpdateAccountBalance(ctx context.Context, id string, ne
 	}
 	return nil
 }
+
+// FreezeAccount blocks an account from sending or receiving transfers
+func (s *AccountService) FreezeAccount(ctx context.Context, id string) error {
+	err := s.accountRepo.UpdateStatus(ctx, id, domain.AccountStatusFrozen)
+	if err != nil {
+		return err
+	}
+	s.events.Publish(ctx, newEvent(domain.EventAccountFrozen, map[string]string{"account_id": id}, id))
+	return nil
+}
+
+func (s *AccountService) UnfreezeAccount(ctx context.Context, id string) error {
+	return s.accountRepo.UpdateStatus(ctx, id, domain.AccountStatusActive)
+}
+
 func (s *AccountService) DeleteAccount(ctx context.Context, id string) error {
 	err := s.accountRepo.Delete(ctx, id)
 	if err != nil {
@@ -30,11 +30,27 @@ func (m *MockAccountRepo) GetAll(ctx context.Context) ([]*domain.Account, error)
 	return args.Get(0).([]*domain.Account), args.Error(1)
 }
 
+func (m *MockAccountRepo) List(ctx context.Context, opts domain.AccountListOptions) (*domain.AccountPage, error) {
+	args := m.Called(ctx, opts)
+	page, _ := args.Get(0).(*domain.AccountPage)
+	return page, args.Error(1)
+}
+
+func (m *MockAccountRepo) GetByCustomerID(ctx context.Context, customerID string) ([]*domain.Account, error) {
+	args := m.Called(ctx, customerID)
+	return args.Get(0).([]*domain.Account), args.Error(1)
+}
+
 func (m *MockAccountRepo) UpdateBalance(ctx context.Context, id string, newBalance float64) error {
 	args := m.Called(ctx, id, newBalance)
 	return args.Error(0)
 }
 
+func (m *MockAccountRepo) UpdateStatus(ctx context.Context, id, status string) error {
+	args := m.Called(ctx, id, status)
+	return args.Error(0)
+}
+
 func (m *MockAccountRepo) Delete(ctx context.Context, id string) error {
 	args := m.Called(ctx, id)
 	return args.Error(0)
@@ -42,7 +58,7 @@ func (m *MockAccountRepo) Delete(ctx context.Context, id string) error {
 
 func TestCreateAccount(t *testing.T) {
 	mockRepo := new(MockAccountRepo)
-	svc := service.NewAccountService(mockRepo)
+	svc := service.NewAccountService(mockRepo, nil)
 
 	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Account")).Return(nil)
 
@@ -54,7 +70,7 @@ func TestCreateAccount(t *testing.T) {
 
 func TestGetAccount(t *testing.T) {
 	mockRepo := new(MockAccountRepo)
-	svc := service.NewAccountService(mockRepo)
+	svc := service.NewAccountService(mockRepo, nil)
 
 	expected := &domain.Account{ID: "acc1", OwnerName: "John", Balance: 100.0}
 	mockRepo.On("GetByID", mock.Anything, "acc1").Return(expected, nil)
@@ -68,7 +84,7 @@ func TestGetAccount(t *testing.T) {
 
 func TestGetAllAccounts(t *testing.T) {
 	mockRepo := new(MockAccountRepo)
-	svc := service.NewAccountService(mockRepo)
+	svc := service.NewAccountService(mockRepo, nil)
 
 	expected := []*domain.Account{
 		{ID: "acc1", OwnerName: "John", Balance: 100},
@@ -86,7 +102,7 @@ func TestGetAllAccounts(t *testing.T) {
 
 func TestUpdateAccountBalance(t *testing.T) {
 	mockRepo := new(MockAccountRepo)
-	svc := service.NewAccountService(mockRepo)
+	svc := service.NewAccountService(mockRepo, nil)
 
 	mockRepo.On("UpdateBalance", mock.Anything, "acc1", 150.0).Return(nil)
 
@@ -98,7 +114,7 @@ func TestUpdateAccountBalance(t *testing.T) {
 
 func TestDeleteAccount(t *testing.T) {
 	mockRepo := new(MockAccountRepo)
-	svc := service.NewAccountService(mockRepo)
+	svc := service.NewAccountService(mockRepo, nil)
 
 	mockRepo.On("Delete", mock.Anything, "acc1").Return(nil)
 
@@ -110,7 +126,7 @@ func TestDeleteAccount(t *testing.T) {
 
 func TestCreateAccount_Failure(t *testing.T) {
 	mockRepo := new(MockAccountRepo)
-	svc := service.NewAccountService(mockRepo)
+	svc := service.NewAccountService(mockRepo, nil)
 
 	mockRepo.On("Create", mock.Anything, mock.Anything).Return(errors.New("insert failed"))
 
@@ -120,3 +136,56 @@ func TestCreateAccount_Failure(t *testing.T) {
 	assert.EqualError(t, err, "insert failed")
 	mockRepo.AssertExpectations(t)
 }
+
+func TestListAccounts(t *testing.T) {
+	mockRepo := new(MockAccountRepo)
+	svc := service.NewAccountService(mockRepo, nil)
+
+	opts := domain.AccountListOptions{Limit: 1, SortBy: domain.SortByBalance}
+	expected := &domain.AccountPage{Accounts: []*domain.Account{{ID: "acc1"}}, NextCursor: "next"}
+	mockRepo.On("List", mock.Anything, opts).Return(expected, nil)
+
+	page, err := svc.ListAccounts(context.Background(), opts)
+
+	assert.NoError(t, err)
+	assert.Equal(t, expected, page)
+	mockRepo.AssertExpectations(t)
+}
+
+type recordingPublisher struct {
+	events []domain.Event
+}
+
+func (p *recordingPublisher) Publish(ctx context.Context, evt domain.Event) {
+	p.events = append(p.events, evt)
+}
+
+func TestFreezeAccount_PublishesEvent(t *testing.T) {
+	mockRepo := new(MockAccountRepo)
+	pub := &recordingPublisher{}
+	svc := service.NewAccountService(mockRepo, pub)
+
+	mockRepo.On("UpdateStatus", mock.Anything, "acc1", domain.AccountStatusFrozen).Return(nil)
+
+	err := svc.FreezeAccount(context.Background(), "acc1")
+
+	assert.NoError(t, err)
+	if assert.Len(t, pub.events, 1) {
+		assert.Equal(t, domain.EventAccountFrozen, pub.events[0].Type)
+		assert.Equal(t, []string{"acc1"}, pub.events[0].AccountIDs)
+	}
+	mockRepo.AssertExpectations(t)
+}
+
+func TestFreezeAccount_NotFound(t *testing.T) {
+	mockRepo := new(MockAccountRepo)
+	pub := &recordingPublisher{}
+	svc := service.NewAccountService(mockRepo, pub)
+
+	mockRepo.On("UpdateStatus", mock.Anything, "missing", domain.AccountStatusFrozen).Return(domain.ErrAccountNotFound)
+
+	err := svc.FreezeAccount(context.Background(), "missing")
+
+	assert.Error(t, err)
+	assert.Empty(t, pub.events)
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"ledger/internal/auth"
+	"ledger/internal/domain"
+)
+
+type APIKeyService struct {
+	keyRepo      domain.APIKeyRepository
+	customerRepo domain.CustomerRepository
+}
+
+func NewAPIKeyService(keyRepo domain.APIKeyRepository, customerRepo domain.CustomerRepository) *APIKeyService {
+	return &APIKeyService{
+		keyRepo:      keyRepo,
+		customerRepo: customerRepo,
+	}
+}
+
+// CreateKey issues a key for role, optionally limited to one customer's data
+func (s *APIKeyService) CreateKey(ctx context.Context, name, role, customerID string) (*domain.APIKey, string, error) {
+	if name == "" {
+		return nil, "", fmt.Errorf("%w: name is required", domain.ErrInvalidAPIKey)
+	}
+	if domain.RoleRank(role) < 0 {
+		return nil, "", fmt.Errorf("%w: unknown role %s", domain.ErrInvalidAPIKey, role)
+	}
+	if role == domain.RoleAdmin && customerID != "" {
+		// Admins manage keys and webhooks for every customer
+		return nil, "", fmt.Errorf("%w: admin keys cannot be limited to a customer", domain.ErrInvalidAPIKey)
+	}
+	if customerID != "" {
+		if _, err := s.customerRepo.GetByID(ctx, customerID); err != nil {
+			return nil, "", err
+		}
+	}
+
+	plaintext, prefix, hash, err := auth.GenerateAPIKey()
+	if err != nil {
+		return nil, "", err
+	}
+
+	key := &domain.APIKey{
+		ID:         uuid.New().String(),
+		Name:       name,
+		Prefix:     prefix,
+		KeyHash:    hash,
+		Role:       role,
+		CustomerID: customerID,
+	}
+	if err := s.keyRepo.Create(ctx, key); err != nil {
+		return nil, "", err
+	}
+	return key, plaintext, nil
+}
+
+func (s *APIKeyService) ListKeys(ctx context.Context) ([]*domain.APIKey, error) {
+	return s.keyRepo.List(ctx)
+}
+
+func (s *APIKeyService) RevokeKey(ctx context.Context, id string) error {
+	return s.keyRepo.Revoke(ctx, id)
+}
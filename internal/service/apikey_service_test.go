@@ -0,0 +1,85 @@
+package service_test
+
+import (
+	"context"
+	"ledger/internal/auth"
+	"ledger/internal/domain"
+	"ledger/internal/service"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockAPIKeyRepo struct {
+	mock.Mock
+}
+
+func (m *MockAPIKeyRepo) Create(ctx context.Context, key *domain.APIKey) error {
+	args := m.Called(ctx, key)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyRepo) GetByHash(ctx context.Context, hash string) (*domain.APIKey, error) {
+	args := m.Called(ctx, hash)
+	key, _ := args.Get(0).(*domain.APIKey)
+	return key, args.Error(1)
+}
+
+func (m *MockAPIKeyRepo) List(ctx context.Context) ([]*domain.APIKey, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]*domain.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepo) Revoke(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func TestCreateKey(t *testing.T) {
+	keyRepo := new(MockAPIKeyRepo)
+	customerRepo := new(MockCustomerRepo)
+	svc := service.NewAPIKeyService(keyRepo, customerRepo)
+
+	customerRepo.On("GetByID", mock.Anything, "cust1").Return(&domain.Customer{ID: "cust1"}, nil)
+	keyRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.APIKey")).Return(nil)
+
+	key, plaintext, err := svc.CreateKey(context.Background(), "portal", domain.RoleViewer, "cust1")
+
+	assert.NoError(t, err)
+	assert.True(t, auth.IsAPIKey(plaintext))
+	assert.Equal(t, auth.HashAPIKey(plaintext), key.KeyHash)
+	assert.Equal(t, "cust1", key.CustomerID)
+	keyRepo.AssertExpectations(t)
+}
+
+func TestCreateKey_UnknownRole(t *testing.T) {
+	svc := service.NewAPIKeyService(new(MockAPIKeyRepo), new(MockCustomerRepo))
+
+	_, _, err := svc.CreateKey(context.Background(), "portal", "root", "")
+
+	assert.ErrorIs(t, err, domain.ErrInvalidAPIKey)
+}
+
+func TestCreateKey_ScopedAdmin(t *testing.T) {
+	keyRepo := new(MockAPIKeyRepo)
+	svc := service.NewAPIKeyService(keyRepo, new(MockCustomerRepo))
+
+	_, _, err := svc.CreateKey(context.Background(), "portal", domain.RoleAdmin, "cust1")
+
+	assert.ErrorIs(t, err, domain.ErrInvalidAPIKey)
+	keyRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestCreateKey_UnknownCustomer(t *testing.T) {
+	keyRepo := new(MockAPIKeyRepo)
+	customerRepo := new(MockCustomerRepo)
+	svc := service.NewAPIKeyService(keyRepo, customerRepo)
+
+	customerRepo.On("GetByID", mock.Anything, "missing").Return(nil, domain.ErrCustomerNotFound)
+
+	_, _, err := svc.CreateKey(context.Background(), "portal", domain.RoleViewer, "missing")
+
+	assert.ErrorIs(t, err, domain.ErrCustomerNotFound)
+	keyRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
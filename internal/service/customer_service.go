@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"sort"
+
+	"github.com/google/uuid"
+	"ledger/internal/domain"
+)
+
+type CustomerService struct {
+	customerRepo domain.CustomerRepository
+	accountRepo  domain.AccountRepository
+}
+
+func NewCustomerService(customerRepo domain.CustomerRepository, accountRepo domain.AccountRepository) *CustomerService {
+	return &CustomerService{
+		customerRepo: customerRepo,
+		accountRepo:  accountRepo,
+	}
+}
+
+func (s *CustomerService) CreateCustomer(ctx context.Context, name, email string) (*domain.Customer, error) {
+	customer := &domain.Customer{
+		ID:    uuid.New().String(),
+		Name:  name,
+		Email: email,
+	}
+
+	if err := s.customerRepo.Create(ctx, customer); err != nil {
+		return nil, err
+	}
+	return customer, nil
+}
+
+func (s *CustomerService) GetCustomer(ctx context.Context, id string) (*domain.Customer, error) {
+	customer, err := s.customerRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return customer, nil
+}
+
+func (s *CustomerService) GetAllCustomers(ctx context.Context) ([]*domain.Customer, error) {
+	customers, err := s.customerRepo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return customers, nil
+}
+
+func (s *CustomerService) UpdateCustomer(ctx context.Context, id, name, email string) (*domain.Customer, error) {
+	customer, err := s.customerRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	customer.Name = name
+	customer.Email = email
+	if err := s.customerRepo.Update(ctx, customer); err != nil {
+		return nil, err
+	}
+	return customer, nil
+}
+
+func (s *CustomerService) DeleteCustomer(ctx context.Context, id string) error {
+	accounts, err := s.accountRepo.GetByCustomerID(ctx, id)
+	if err != nil {
+		return err
+	}
+	// Accounts must be closed or moved before their owner can be removed
+	if len(accounts) > 0 {
+		return domain.ErrCustomerHasAccounts
+	}
+	return s.customerRepo.Delete(ctx, id)
+}
+
+// OpenAccount creates a new account owned by the given customer
+func (s *CustomerService) OpenAccount(ctx context.Context, customerID, currency string, initialBalance float64) (*domain.Account, error) {
+	customer, err := s.customerRepo.GetByID(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	account := &domain.Account{
+		CustomerID: customer.ID,
+		OwnerName:  customer.Name,
+		Balance:    initialBalance,
+		Currency:   currency,
+		Status:     domain.AccountStatusActive,
+	}
+	if err := s.accountRepo.Create(ctx, account); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+func (s *CustomerService) GetCustomerAccounts(ctx context.Context, customerID string) ([]*domain.Account, error) {
+	if _, err := s.customerRepo.GetByID(ctx, customerID); err != nil {
+		return nil, err
+	}
+	return s.accountRepo.GetByCustomerID(ctx, customerID)
+}
+
+// GetCustomerBalances sums the balances of a customer's accounts per currency
+func (s *CustomerService) GetCustomerBalances(ctx context.Context, customerID string) (*domain.CustomerBalances, error) {
+	accounts, err := s.GetCustomerAccounts(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	totals := make(map[string]*domain.CurrencyBalance)
+	for _, acc := range accounts {
+		b, ok := totals[acc.Currency]
+		if !ok {
+			b = &domain.CurrencyBalance{Currency: acc.Currency}
+			totals[acc.Currency] = b
+		}
+		b.Total += acc.Balance
+		b.Accounts++
+	}
+
+	result := &domain.CustomerBalances{
+		CustomerID: customerID,
+		Accounts:   len(accounts),
+		Balances:   make([]domain.CurrencyBalance, 0, len(totals)),
+	}
+	for _, b := range totals {
+		result.Balances = append(result.Balances, *b)
+	}
+	sort.Slice(result.Balances, func(i, j int) bool {
+		return result.Balances[i].Currency < result.Balances[j].Currency
+	})
+	return result, nil
+}
@@ -0,0 +1,94 @@
+package service_test
+
+import (
+	"context"
+	"ledger/internal/domain"
+	"ledger/internal/service"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockCustomerRepo struct {
+	mock.Mock
+}
+
+func (m *MockCustomerRepo) Create(ctx context.Context, c *domain.Customer) error {
+	args := m.Called(ctx, c)
+	return args.Error(0)
+}
+
+func (m *MockCustomerRepo) GetByID(ctx context.Context, id string) (*domain.Customer, error) {
+	args := m.Called(ctx, id)
+	c, _ := args.Get(0).(*domain.Customer)
+	return c, args.Error(1)
+}
+
+func (m *MockCustomerRepo) GetAll(ctx context.Context) ([]*domain.Customer, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]*domain.Customer), args.Error(1)
+}
+
+func (m *MockCustomerRepo) Update(ctx context.Context, c *domain.Customer) error {
+	args := m.Called(ctx, c)
+	return args.Error(0)
+}
+
+func (m *MockCustomerRepo) Delete(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func TestOpenAccount(t *testing.T) {
+	customerRepo := new(MockCustomerRepo)
+	accountRepo := new(MockAccountRepo)
+	svc := service.NewCustomerService(customerRepo, accountRepo)
+
+	customerRepo.On("GetByID", mock.Anything, "cust1").Return(&domain.Customer{ID: "cust1", Name: "John"}, nil)
+	accountRepo.On("Create", mock.Anything, mock.MatchedBy(func(acc *domain.Account) bool {
+		return acc.CustomerID == "cust1" && acc.OwnerName == "John" && acc.Currency == "EUR"
+	})).Return(nil)
+
+	account, err := svc.OpenAccount(context.Background(), "cust1", "EUR", 50)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 50.0, account.Balance)
+	customerRepo.AssertExpectations(t)
+	accountRepo.AssertExpectations(t)
+}
+
+func TestGetCustomerBalances(t *testing.T) {
+	customerRepo := new(MockCustomerRepo)
+	accountRepo := new(MockAccountRepo)
+	svc := service.NewCustomerService(customerRepo, accountRepo)
+
+	customerRepo.On("GetByID", mock.Anything, "cust1").Return(&domain.Customer{ID: "cust1"}, nil)
+	accountRepo.On("GetByCustomerID", mock.Anything, "cust1").Return([]*domain.Account{
+		{ID: "a1", CustomerID: "cust1", Balance: 100, Currency: "USD"},
+		{ID: "a2", CustomerID: "cust1", Balance: 25, Currency: "EUR"},
+		{ID: "a3", CustomerID: "cust1", Balance: 50, Currency: "USD"},
+	}, nil)
+
+	balances, err := svc.GetCustomerBalances(context.Background(), "cust1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, balances.Accounts)
+	assert.Equal(t, []domain.CurrencyBalance{
+		{Currency: "EUR", Total: 25, Accounts: 1},
+		{Currency: "USD", Total: 150, Accounts: 2},
+	}, balances.Balances)
+}
+
+func TestDeleteCustomer_WithAccounts(t *testing.T) {
+	customerRepo := new(MockCustomerRepo)
+	accountRepo := new(MockAccountRepo)
+	svc := service.NewCustomerService(customerRepo, accountRepo)
+
+	accountRepo.On("GetByCustomerID", mock.Anything, "cust1").Return([]*domain.Account{{ID: "a1"}}, nil)
+
+	err := svc.DeleteCustomer(context.Background(), "cust1")
+
+	assert.Error(t, err)
+	customerRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+}
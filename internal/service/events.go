@@ -0,0 +1,18 @@
+package service
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"ledger/internal/domain"
+)
+
+func newEvent(eventType string, data any, accountIDs ...string) domain.Event {
+	return domain.Event{
+		ID:         uuid.New().String(),
+		Type:       eventType,
+		AccountIDs: accountIDs,
+		OccurredAt: time.Now().UTC().Format(time.RFC3339),
+		Data:       data,
+	}
+}
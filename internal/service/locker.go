@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+	"sync"
+)
+
+// localLocker is a domain.Locker for one process
+type localLocker struct {
+	mu   sync.Mutex
+	held map[string]*localLock
+}
+
+type localLock struct {
+	// token is taken by the holder
+	token chan struct{}
+	// users counts the holder and the waiters, so the last one removes the lock
+	users int
+}
+
+func newLocalLocker() *localLocker {
+	return &localLocker{held: make(map[string]*localLock)}
+}
+
+func (l *localLocker) Lock(ctx context.Context, key string) (func(), error) {
+	l.mu.Lock()
+	lock, ok := l.held[key]
+	if !ok {
+		lock = &localLock{token: make(chan struct{}, 1)}
+		l.held[key] = lock
+	}
+	lock.users++
+	l.mu.Unlock()
+
+	select {
+	case lock.token <- struct{}{}:
+		return func() {
+			<-lock.token
+			l.release(key, lock)
+		}, nil
+	case <-ctx.Done():
+		l.release(key, lock)
+		return nil, ctx.Err()
+	}
+}
+
+func (l *localLocker) release(key string, lock *localLock) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	lock.users--
+	if lock.users == 0 {
+		delete(l.held, key)
+	}
+}
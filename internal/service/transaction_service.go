@@ -3,47 +3,176 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
 	"ledger/internal/domain"
+	"ledger/internal/logging"
 	"ledger/internal/queue"
 	"strconv"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 type TransactionService struct {
 	accountRepo  domain.AccountRepository
 	ledgerRepo   domain.LedgerRepository
-	transactionQ queue.TransactionPublisher
+	transactionQ queue.Publisher
+	events       domain.EventPublisher
+
+	// LowBalanceThreshold emits a balance.low event when a transfer leaves the
+	// source account below it. Zero disables the event.
+	LowBalanceThreshold float64
+	// Transactor, when set, applies the debit, the credit and the ledger entry
+	// of a transfer atomically
+	Transactor domain.Transactor
+	// Locker serializes transfers carrying the same ID, so two deliveries of
+	// one transfer cannot both apply it. It defaults to a lock within this
+	// process; processes sharing the accounts need a shared one.
+	Locker domain.Locker
 }
 
-func NewTransactionService(accountRepo domain.AccountRepository, ledgerRepo domain.LedgerRepository, transactionQ queue.TransactionPublisher) *TransactionService {
+func NewTransactionService(accountRepo domain.AccountRepository, ledgerRepo domain.LedgerRepository, transactionQ queue.Publisher, events domain.EventPublisher) *TransactionService {
+	if events == nil {
+		events = domain.NopEventPublisher{}
+	}
 	return &TransactionService{
 		accountRepo:  accountRepo,
 		ledgerRepo:   ledgerRepo,
 		transactionQ: transactionQ,
+		events:       events,
+		Locker:       newLocalLocker(),
 	}
 }
 
+// ProcessTransaction applies tx. A transfer that arrives with an ID is applied
+// at most once: when the ledger already records it, it succeeds without being
+// applied again, so redelivered queue messages and retried requests are safe.
+// Reusing the ID for a different transfer fails with ErrTransactionIDReused.
 func (s *TransactionService) ProcessTransaction(ctx context.Context, tx *domain.Transaction) error {
+	known := tx.ID != ""
+	if known {
+		unlock, err := s.Locker.Lock(ctx, tx.ID)
+		if err != nil {
+			return fmt.Errorf("lock transaction: %w", err)
+		}
+		defer unlock()
+	} else {
+		tx.ID = uuid.New().String()
+	}
+
+	fromID := strconv.FormatInt(tx.FromAccountID, 10)
+	toID := strconv.FormatInt(tx.ToAccountID, 10)
+
+	var fromAccount, toAccount *domain.Account
+	var applied bool
+	err := s.atomically(ctx, func(ctx context.Context) error {
+		if known {
+			var err error
+			if applied, err = s.recorded(ctx, tx); err != nil || applied {
+				return err
+			}
+		}
+		var err error
+		fromAccount, toAccount, err = s.transfer(ctx, tx, fromID, toID)
+		return err
+	})
+	if err != nil {
+		tx.Status = "FAILED"
+		// A failure the consumer retries is reported once it gives up, and a
+		// reused ID must not report on the transfer that owns it
+		if !queue.WillRetry(ctx, err) && !errors.Is(err, domain.ErrTransactionIDReused) {
+			s.events.Publish(ctx, newEvent(domain.EventTransactionFailed, map[string]any{
+				"transaction": tx,
+				"reason":      err.Error(),
+			}, fromID, toID))
+		}
+		return err
+	}
+	if applied {
+		// Its events were published when it was applied
+		tx.Status = "SUCCESS"
+		return nil
+	}
+
+	tx.Status = "SUCCESS"
+	s.events.Publish(ctx, newEvent(domain.EventTransactionSucceeded, tx, fromID, toID))
+
+	remaining := fromAccount.Balance - tx.Amount
+	s.events.Publish(ctx, newEvent(domain.EventBalanceUpdated, map[string]any{
+		"account_id": fromID,
+		"balance":    remaining,
+	}, fromID))
+	s.events.Publish(ctx, newEvent(domain.EventBalanceUpdated, map[string]any{
+		"account_id": toID,
+		"balance":    toAccount.Balance + tx.Amount,
+	}, toID))
+	if s.LowBalanceThreshold > 0 && remaining < s.LowBalanceThreshold {
+		s.events.Publish(ctx, newEvent(domain.EventBalanceLow, map[string]any{
+			"account_id": fromID,
+			"balance":    remaining,
+			"threshold":  s.LowBalanceThreshold,
+		}, fromID))
+	}
+	return nil
+}
+
+// atomically runs fn within the Transactor, if there is one
+func (s *TransactionService) atomically(ctx context.Context, fn func(ctx context.Context) error) error {
+	if s.Transactor == nil {
+		return fn(ctx)
+	}
+	return s.Transactor.WithinTransaction(ctx, fn)
+}
 
-	fromAccount, err := s.accountRepo.GetByID(ctx, strconv.FormatInt(tx.FromAccountID, 10))
+// recorded reports whether the ledger already holds tx, failing when its ID
+// was recorded for a different transfer
+func (s *TransactionService) recorded(ctx context.Context, tx *domain.Transaction) (bool, error) {
+	entry, err := s.ledgerRepo.GetEntryByTransactionID(ctx, tx.ID)
+	if errors.Is(err, domain.ErrTransactionNotFound) {
+		return false, nil
+	}
 	if err != nil {
-		return errors.New("failed to fetch source account: " + err.Error())
+		return false, fmt.Errorf("look up transaction: %w", err)
+	}
+	if entry.FromAccountID != tx.FromAccountID || entry.ToAccountID != tx.ToAccountID ||
+		entry.Amount != tx.Amount || entry.Currency != tx.Currency {
+		return false, fmt.Errorf("%w: %s", domain.ErrTransactionIDReused, tx.ID)
+	}
+	return true, nil
+}
+
+// transfer moves the funds and writes the ledger entry, returning both
+// accounts as they were before the transfer
+func (s *TransactionService) transfer(ctx context.Context, tx *domain.Transaction, fromID, toID string) (*domain.Account, *domain.Account, error) {
+	fromAccount, err := s.accountRepo.GetByID(ctx, fromID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("fetch source account: %w", err)
+	}
+	toAccount, err := s.accountRepo.GetByID(ctx, toID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("fetch destination account: %w", err)
+	}
+	if fromAccount.Status == domain.AccountStatusFrozen || toAccount.Status == domain.AccountStatusFrozen {
+		return nil, nil, domain.ErrAccountFrozen
+	}
+	if !acceptsCurrency(fromAccount, tx.Currency) || !acceptsCurrency(toAccount, tx.Currency) {
+		return nil, nil, domain.ErrCurrencyMismatch
 	}
 
 	// Check for sufficient balance
 	if fromAccount.Balance < tx.Amount {
-		return errors.New("insufficient funds")
+		return nil, nil, domain.ErrInsufficientFunds
 	}
 
 	// Deduct from source and credit to destination
-	err = s.accountRepo.UpdateBalance(ctx, strconv.FormatInt(tx.FromAccountID, 10), -tx.Amount)
+	err = s.accountRepo.UpdateBalance(ctx, fromID, -tx.Amount)
 	if err != nil {
-		return errors.New("failed to debit source account: " + err.Error())
+		return nil, nil, fmt.Errorf("debit source account: %w", err)
 	}
 
-	err = s.accountRepo.UpdateBalance(ctx, strconv.FormatInt(tx.ToAccountID, 10), tx.Amount)
+	err = s.accountRepo.UpdateBalance(ctx, toID, tx.Amount)
 	if err != nil {
-		return errors.New("failed to credit destination account: " + err.Error())
+		return nil, nil, s.afterDebit(fmt.Errorf("credit destination account: %w", err))
 	}
 
 	// Prepare ledger entry
@@ -55,16 +184,33 @@ func (s *TransactionService) ProcessTransaction(ctx context.Context, tx *domain.
 		Amount:        tx.Amount,
 		Currency:      tx.Currency,
 		Status:        "SUCCESS",
-		Timestamp:     time.Now().Format(time.RFC3339),
+		Timestamp:     time.Now().UTC().Format(time.RFC3339),
+		CorrelationID: logging.CorrelationID(ctx),
 	}
 
 	// Store in MongoDB
 	err = s.ledgerRepo.SaveEntry(ctx, ledger)
 	if err != nil {
-		return errors.New("failed to log transaction: " + err.Error())
+		return nil, nil, s.afterDebit(fmt.Errorf("log transaction: %w", err))
 	}
 
-	return nil
+	return fromAccount, toAccount, nil
+}
+
+// afterDebit marks err, which happened after the source account was debited,
+// as ErrTransferIncomplete unless the Transactor rolls the debit back. The
+// ledger has no entry for the transfer yet, so a retry would debit it again.
+func (s *TransactionService) afterDebit(err error) error {
+	if s.Transactor != nil {
+		return err
+	}
+	return fmt.Errorf("%w: %w", domain.ErrTransferIncomplete, err)
+}
+
+// acceptsCurrency reports whether a transfer in currency may touch acc. Accounts
+// opened through POST /accounts carry no currency and accept any.
+func acceptsCurrency(acc *domain.Account, currency string) bool {
+	return acc.Currency == "" || acc.Currency == currency
 }
 
 func (s *TransactionService) GetTransactionHistory(ctx context.Context, accountID int64) ([]*domain.Transaction, error) {
@@ -76,24 +222,51 @@ func (s *TransactionService) GetTransactionHistory(ctx context.Context, accountI
 	// Convert ledger entries to transactions
 	var txs []*domain.Transaction
 	for _, entry := range transactions {
-		txs = append(txs, &domain.Transaction{
-			ID:            entry.TransactionID,
-			FromAccountID: entry.FromAccountID,
-			ToAccountID:   entry.ToAccountID,
-			Amount:        entry.Amount,
-			Currency:      entry.Currency,
-			Status:        entry.Status,
-			CreatedAt:     entry.Timestamp, // Assuming CreatedAt is the same as Timestamp
-		})
+		txs = append(txs, toTransaction(entry))
 	}
 	if len(txs) == 0 {
-
-		return nil, errors.New("no transactions found for account")
+		return nil, domain.ErrNoTransactions
 	}
 	return txs, nil
 }
 
+// ListTransactionHistory returns one filtered page of an account's history. Unlike
+// GetTransactionHistory an empty page is not an error.
+func (s *TransactionService) ListTransactionHistory(ctx context.Context, accountID int64, filter domain.HistoryFilter) (*domain.TransactionPage, error) {
+	entries, err := s.ledgerRepo.ListEntriesByAccountID(ctx, accountID, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &domain.TransactionPage{
+		Transactions: make([]*domain.Transaction, 0, len(entries.Entries)),
+		NextCursor:   entries.NextCursor,
+	}
+	for _, entry := range entries.Entries {
+		page.Transactions = append(page.Transactions, toTransaction(entry))
+	}
+	return page, nil
+}
+
+func toTransaction(entry *domain.LedgerEntry) *domain.Transaction {
+	return &domain.Transaction{
+		ID:            entry.TransactionID,
+		FromAccountID: entry.FromAccountID,
+		ToAccountID:   entry.ToAccountID,
+		Amount:        entry.Amount,
+		Currency:      entry.Currency,
+		Status:        entry.Status,
+		CreatedAt:     entry.Timestamp, // Assuming CreatedAt is the same as Timestamp
+	}
+}
+
 func (s *TransactionService) QueueTransaction(ctx context.Context, tx domain.Transaction) error {
-	// Publish to RabbitMQ (or Kafka)
+	if s.transactionQ == nil {
+		return fmt.Errorf("%w: this process does not queue transfers", queue.ErrUnavailable)
+	}
+	// Every delivery of the message carries the same ID, so a redelivery is not applied twice
+	if tx.ID == "" {
+		tx.ID = uuid.New().String()
+	}
 	return s.transactionQ.Publish(ctx, tx)
 }
@@ -0,0 +1,328 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"ledger/internal/domain"
+	"ledger/internal/logging"
+	"ledger/internal/queue"
+	"ledger/internal/repository/memory"
+	"ledger/internal/service"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockLedgerRepo struct {
+	mock.Mock
+}
+
+func (m *MockLedgerRepo) SaveEntry(ctx context.Context, entry *domain.LedgerEntry) error {
+	args := m.Called(ctx, entry)
+	return args.Error(0)
+}
+
+func (m *MockLedgerRepo) GetEntryByTransactionID(ctx context.Context, transactionID string) (*domain.LedgerEntry, error) {
+	args := m.Called(ctx, transactionID)
+	entry, _ := args.Get(0).(*domain.LedgerEntry)
+	return entry, args.Error(1)
+}
+
+func (m *MockLedgerRepo) GetEntriesByAccountID(ctx context.Context, accountID int64) ([]*domain.LedgerEntry, error) {
+	args := m.Called(ctx, accountID)
+	return args.Get(0).([]*domain.LedgerEntry), args.Error(1)
+}
+
+func (m *MockLedgerRepo) ListEntriesByAccountID(ctx context.Context, accountID int64, filter domain.HistoryFilter) (*domain.LedgerPage, error) {
+	args := m.Called(ctx, accountID, filter)
+	page, _ := args.Get(0).(*domain.LedgerPage)
+	return page, args.Error(1)
+}
+
+func eventTypes(events []domain.Event) []string {
+	var types []string
+	for _, e := range events {
+		types = append(types, e.Type)
+	}
+	return types
+}
+
+func TestProcessTransaction_PublishesSucceededAndBalances(t *testing.T) {
+	accounts := new(MockAccountRepo)
+	ledger := new(MockLedgerRepo)
+	pub := &recordingPublisher{}
+	svc := service.NewTransactionService(accounts, ledger, nil, pub)
+	svc.LowBalanceThreshold = 50
+
+	accounts.On("GetByID", mock.Anything, "1").Return(&domain.Account{ID: "1", Balance: 100, Currency: "USD", Status: domain.AccountStatusActive}, nil)
+	accounts.On("GetByID", mock.Anything, "2").Return(&domain.Account{ID: "2", Balance: 0, Currency: "USD", Status: domain.AccountStatusActive}, nil)
+	accounts.On("UpdateBalance", mock.Anything, "1", -80.0).Return(nil)
+	accounts.On("UpdateBalance", mock.Anything, "2", 80.0).Return(nil)
+	ledger.On("SaveEntry", mock.Anything, mock.MatchedBy(func(e *domain.LedgerEntry) bool {
+		return e.CorrelationID == "req-1"
+	})).Return(nil)
+
+	ctx := logging.WithCorrelationID(context.Background(), "req-1")
+	err := svc.ProcessTransaction(ctx, &domain.Transaction{FromAccountID: 1, ToAccountID: 2, Amount: 80, Currency: "USD"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		domain.EventTransactionSucceeded,
+		domain.EventBalanceUpdated,
+		domain.EventBalanceUpdated,
+		domain.EventBalanceLow,
+	}, eventTypes(pub.events))
+	assert.Equal(t, map[string]any{"account_id": "2", "balance": 80.0}, pub.events[2].Data)
+	assert.Equal(t, []string{"1"}, pub.events[3].AccountIDs)
+	accounts.AssertExpectations(t)
+	ledger.AssertExpectations(t)
+}
+
+func TestProcessTransaction_FrozenAccount(t *testing.T) {
+	accounts := new(MockAccountRepo)
+	pub := &recordingPublisher{}
+	svc := service.NewTransactionService(accounts, new(MockLedgerRepo), nil, pub)
+
+	accounts.On("GetByID", mock.Anything, "1").Return(&domain.Account{ID: "1", Balance: 100, Status: domain.AccountStatusActive}, nil)
+	accounts.On("GetByID", mock.Anything, "2").Return(&domain.Account{ID: "2", Status: domain.AccountStatusFrozen}, nil)
+
+	err := svc.ProcessTransaction(context.Background(), &domain.Transaction{FromAccountID: 1, ToAccountID: 2, Amount: 10, Currency: "USD"})
+
+	assert.ErrorIs(t, err, domain.ErrAccountFrozen)
+	assert.Equal(t, []string{domain.EventTransactionFailed}, eventTypes(pub.events))
+	accounts.AssertNotCalled(t, "UpdateBalance", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestProcessTransaction_CurrencyMismatch(t *testing.T) {
+	accounts := new(MockAccountRepo)
+	svc := service.NewTransactionService(accounts, new(MockLedgerRepo), nil, nil)
+
+	accounts.On("GetByID", mock.Anything, "1").Return(&domain.Account{ID: "1", Balance: 100, Currency: "USD", Status: domain.AccountStatusActive}, nil)
+	accounts.On("GetByID", mock.Anything, "2").Return(&domain.Account{ID: "2", Currency: "EUR", Status: domain.AccountStatusActive}, nil)
+
+	err := svc.ProcessTransaction(context.Background(), &domain.Transaction{FromAccountID: 1, ToAccountID: 2, Amount: 10, Currency: "USD"})
+
+	assert.ErrorIs(t, err, domain.ErrCurrencyMismatch)
+	assert.ErrorIs(t, err, domain.ErrPrecondition)
+	accounts.AssertNotCalled(t, "UpdateBalance", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestProcessTransaction_MissingAccount(t *testing.T) {
+	accounts := new(MockAccountRepo)
+	svc := service.NewTransactionService(accounts, new(MockLedgerRepo), nil, nil)
+
+	accounts.On("GetByID", mock.Anything, "1").Return(&domain.Account{}, domain.ErrAccountNotFound)
+
+	err := svc.ProcessTransaction(context.Background(), &domain.Transaction{FromAccountID: 1, ToAccountID: 2, Amount: 10, Currency: "USD"})
+
+	assert.ErrorIs(t, err, domain.ErrAccountNotFound)
+	assert.EqualError(t, err, "fetch source account: account not found")
+}
+
+func TestProcessTransaction_InsufficientFunds(t *testing.T) {
+	accounts := new(MockAccountRepo)
+	pub := &recordingPublisher{}
+	svc := service.NewTransactionService(accounts, new(MockLedgerRepo), nil, pub)
+
+	accounts.On("GetByID", mock.Anything, "1").Return(&domain.Account{ID: "1", Balance: 5, Status: domain.AccountStatusActive}, nil)
+	accounts.On("GetByID", mock.Anything, "2").Return(&domain.Account{ID: "2", Status: domain.AccountStatusActive}, nil)
+
+	err := svc.ProcessTransaction(context.Background(), &domain.Transaction{FromAccountID: 1, ToAccountID: 2, Amount: 10, Currency: "USD"})
+
+	assert.ErrorIs(t, err, domain.ErrInsufficientFunds)
+	if assert.Len(t, pub.events, 1) {
+		assert.Equal(t, domain.EventTransactionFailed, pub.events[0].Type)
+	}
+}
+
+func TestQueueTransaction_AppliedByConsumer(t *testing.T) {
+	accounts := new(MockAccountRepo)
+	ledger := new(MockLedgerRepo)
+	broker := queue.NewMemoryBroker()
+	svc := service.NewTransactionService(accounts, ledger, broker.Publisher("transactions", nil), nil)
+
+	accounts.On("GetByID", mock.Anything, "1").Return(&domain.Account{ID: "1", Balance: 100, Status: domain.AccountStatusActive}, nil)
+	accounts.On("GetByID", mock.Anything, "2").Return(&domain.Account{ID: "2", Status: domain.AccountStatusActive}, nil)
+	accounts.On("UpdateBalance", mock.Anything, "1", -30.0).Return(nil)
+	accounts.On("UpdateBalance", mock.Anything, "2", 30.0).Return(nil)
+	ledger.On("GetEntryByTransactionID", mock.Anything, mock.Anything).Return(nil, domain.ErrTransactionNotFound)
+	saved := make(chan *domain.LedgerEntry, 1)
+	ledger.On("SaveEntry", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		saved <- args.Get(1).(*domain.LedgerEntry)
+	})
+
+	consumer := broker.StartConsumer("transactions", svc, nil, queue.ConsumerOptions{Retry: queue.RetryPolicy{MaxAttempts: 1}})
+	defer consumer.Shutdown(context.Background())
+
+	ctx := logging.WithCorrelationID(context.Background(), "req-7")
+	err := svc.QueueTransaction(ctx, domain.Transaction{FromAccountID: 1, ToAccountID: 2, Amount: 30, Currency: "USD"})
+	assert.NoError(t, err)
+
+	select {
+	case entry := <-saved:
+		assert.Equal(t, 30.0, entry.Amount)
+		assert.Equal(t, "req-7", entry.CorrelationID, "the correlation ID should travel with the message")
+	case <-time.After(2 * time.Second):
+		t.Fatal("queued transfer was never applied")
+	}
+}
+
+func TestQueueTransaction_WithoutPublisher(t *testing.T) {
+	svc := service.NewTransactionService(new(MockAccountRepo), new(MockLedgerRepo), nil, nil)
+
+	err := svc.QueueTransaction(context.Background(), domain.Transaction{FromAccountID: 1, ToAccountID: 2, Amount: 1})
+
+	assert.ErrorIs(t, err, queue.ErrUnavailable)
+}
+
+func TestProcessTransaction_AlreadyRecorded(t *testing.T) {
+	accounts := new(MockAccountRepo)
+	ledger := new(MockLedgerRepo)
+	pub := &recordingPublisher{}
+	svc := service.NewTransactionService(accounts, ledger, nil, pub)
+
+	ledger.On("GetEntryByTransactionID", mock.Anything, "tx-1").Return(&domain.LedgerEntry{TransactionID: "tx-1", FromAccountID: 1, ToAccountID: 2, Amount: 10, Currency: "USD"}, nil)
+
+	tx := &domain.Transaction{ID: "tx-1", FromAccountID: 1, ToAccountID: 2, Amount: 10, Currency: "USD"}
+	err := svc.ProcessTransaction(context.Background(), tx)
+
+	assert.NoError(t, err, "a redelivered transfer succeeds")
+	assert.Equal(t, "SUCCESS", tx.Status)
+	assert.Empty(t, pub.events, "its events were published when it was applied")
+	accounts.AssertNotCalled(t, "UpdateBalance", mock.Anything, mock.Anything, mock.Anything)
+	ledger.AssertNotCalled(t, "SaveEntry", mock.Anything, mock.Anything)
+}
+
+func TestProcessTransaction_ReusedID(t *testing.T) {
+	accounts := new(MockAccountRepo)
+	ledger := new(MockLedgerRepo)
+	pub := &recordingPublisher{}
+	svc := service.NewTransactionService(accounts, ledger, nil, pub)
+
+	ledger.On("GetEntryByTransactionID", mock.Anything, "tx-1").Return(&domain.LedgerEntry{TransactionID: "tx-1", FromAccountID: 1, ToAccountID: 2, Amount: 10, Currency: "USD"}, nil)
+
+	tx := &domain.Transaction{ID: "tx-1", FromAccountID: 1, ToAccountID: 2, Amount: 500, Currency: "USD"}
+	err := svc.ProcessTransaction(context.Background(), tx)
+
+	assert.ErrorIs(t, err, domain.ErrTransactionIDReused)
+	assert.ErrorIs(t, err, domain.ErrConflict)
+	assert.Empty(t, pub.events, "the transfer that owns the ID is not reported as failed")
+	accounts.AssertNotCalled(t, "UpdateBalance", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// slowLookups widens the gap between checking the ledger for a transfer and applying it
+type slowLookups struct {
+	domain.LedgerRepository
+}
+
+func (l slowLookups) GetEntryByTransactionID(ctx context.Context, transactionID string) (*domain.LedgerEntry, error) {
+	entry, err := l.LedgerRepository.GetEntryByTransactionID(ctx, transactionID)
+	time.Sleep(5 * time.Millisecond)
+	return entry, err
+}
+
+func TestProcessTransaction_ConcurrentDeliveriesApplyOnce(t *testing.T) {
+	ctx := context.Background()
+	db := memory.NewDB()
+	accounts := memory.NewAccountRepository(db)
+	require.NoError(t, accounts.Create(ctx, &domain.Account{ID: "1", Balance: 100, Currency: "USD", Status: domain.AccountStatusActive}))
+	require.NoError(t, accounts.Create(ctx, &domain.Account{ID: "2", Currency: "USD", Status: domain.AccountStatusActive}))
+	// No Transactor, like Postgres accounts with a MongoDB ledger
+	svc := service.NewTransactionService(accounts, slowLookups{memory.NewLedgerRepository()}, nil, nil)
+
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tx := &domain.Transaction{ID: "tx-1", FromAccountID: 1, ToAccountID: 2, Amount: 10, Currency: "USD"}
+			assert.NoError(t, svc.ProcessTransaction(ctx, tx))
+		}()
+	}
+	wg.Wait()
+
+	from, err := accounts.GetByID(ctx, "1")
+	require.NoError(t, err)
+	assert.Equal(t, 90.0, from.Balance)
+}
+
+func TestProcessTransaction_FailureAfterDebit(t *testing.T) {
+	for name, tc := range map[string]struct {
+		transactor domain.Transactor
+		incomplete bool
+	}{
+		"without transactor": {incomplete: true},
+		"with transactor":    {transactor: passthroughTransactor{}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			accounts := new(MockAccountRepo)
+			ledger := new(MockLedgerRepo)
+			pub := &recordingPublisher{}
+			svc := service.NewTransactionService(accounts, ledger, nil, pub)
+			svc.Transactor = tc.transactor
+
+			accounts.On("GetByID", mock.Anything, "1").Return(&domain.Account{ID: "1", Balance: 100, Status: domain.AccountStatusActive}, nil)
+			accounts.On("GetByID", mock.Anything, "2").Return(&domain.Account{ID: "2", Status: domain.AccountStatusActive}, nil)
+			accounts.On("UpdateBalance", mock.Anything, "1", -10.0).Return(nil)
+			accounts.On("UpdateBalance", mock.Anything, "2", 10.0).Return(errors.New("connection reset"))
+
+			err := svc.ProcessTransaction(context.Background(), &domain.Transaction{FromAccountID: 1, ToAccountID: 2, Amount: 10, Currency: "USD"})
+
+			assert.Error(t, err)
+			assert.Equal(t, tc.incomplete, errors.Is(err, domain.ErrTransferIncomplete),
+				"only a debit nothing rolls back makes the failure final")
+			assert.Equal(t, []string{domain.EventTransactionFailed}, eventTypes(pub.events))
+		})
+	}
+}
+
+func TestQueueTransaction_RetriedFailureIsNotReported(t *testing.T) {
+	accounts := new(MockAccountRepo)
+	ledger := new(MockLedgerRepo)
+	broker := queue.NewMemoryBroker()
+	pub := &channelPublisher{events: make(chan domain.Event, 10)}
+	svc := service.NewTransactionService(accounts, ledger, broker.Publisher("transactions", nil), pub)
+
+	// The first attempt cannot reach the database
+	accounts.On("GetByID", mock.Anything, "1").Return(&domain.Account{}, errors.New("connection refused")).Once()
+	accounts.On("GetByID", mock.Anything, "1").Return(&domain.Account{ID: "1", Balance: 100, Status: domain.AccountStatusActive}, nil)
+	accounts.On("GetByID", mock.Anything, "2").Return(&domain.Account{ID: "2", Status: domain.AccountStatusActive}, nil)
+	accounts.On("UpdateBalance", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	ledger.On("GetEntryByTransactionID", mock.Anything, mock.Anything).Return(nil, domain.ErrTransactionNotFound)
+	ledger.On("SaveEntry", mock.Anything, mock.Anything).Return(nil)
+
+	consumer := broker.StartConsumer("transactions", svc, nil, queue.ConsumerOptions{
+		Retry: queue.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond},
+	})
+	defer consumer.Shutdown(context.Background())
+
+	err := svc.QueueTransaction(context.Background(), domain.Transaction{FromAccountID: 1, ToAccountID: 2, Amount: 30, Currency: "USD"})
+	assert.NoError(t, err)
+
+	select {
+	case evt := <-pub.events:
+		assert.Equal(t, domain.EventTransactionSucceeded, evt.Type, "the failed first attempt is not reported")
+	case <-time.After(2 * time.Second):
+		t.Fatal("queued transfer was never applied")
+	}
+}
+
+// passthroughTransactor stands in for a database transaction in tests
+type passthroughTransactor struct{}
+
+func (passthroughTransactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// channelPublisher hands events to the test goroutine
+type channelPublisher struct {
+	events chan domain.Event
+}
+
+func (p *channelPublisher) Publish(ctx context.Context, evt domain.Event) {
+	p.events <- evt
+}
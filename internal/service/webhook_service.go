@@ -0,0 +1,357 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"ledger/internal/domain"
+	"ledger/internal/webhook"
+)
+
+// deliveryLease is how long a delivery stays with the process working on it
+// before a sweeper may take it over. It outlasts an attempt, which the sender's
+// client timeout bounds.
+const deliveryLease = time.Minute
+
+// sweepBatch caps how many deliveries a sweep claims at a time
+const sweepBatch = 100
+
+// WebhookService manages subscriptions and delivers events to them. It implements
+// domain.EventPublisher by recording a delivery per interested subscription;
+// they are sent by background workers started by Start.
+type WebhookService struct {
+	repo   domain.WebhookRepository
+	sender *webhook.Sender
+
+	// SweepInterval is how often Start reloads due pending deliveries from the
+	// repository, picking up retries a restart or a full queue left behind
+	SweepInterval time.Duration
+
+	deliveries chan *domain.WebhookDelivery
+
+	mu      sync.Mutex
+	retries map[string]*time.Timer
+	queued  map[string]struct{}
+}
+
+func NewWebhookService(repo domain.WebhookRepository, sender *webhook.Sender) *WebhookService {
+	return &WebhookService{
+		repo:       repo,
+		sender:     sender,
+		deliveries: make(chan *domain.WebhookDelivery, 1024),
+		retries:    make(map[string]*time.Timer),
+		queued:     make(map[string]struct{}),
+
+		SweepInterval: 30 * time.Second,
+	}
+}
+
+// Start runs the delivery workers and the sweeper until ctx is cancelled
+func (s *WebhookService) Start(ctx context.Context, workers int) {
+	for i := 0; i < workers; i++ {
+		go s.work(ctx)
+	}
+	go func() {
+		ticker := time.NewTicker(s.SweepInterval)
+		defer ticker.Stop()
+		for {
+			s.sweep(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	go func() {
+		<-ctx.Done()
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		for id, t := range s.retries {
+			t.Stop()
+			delete(s.retries, id)
+		}
+	}()
+}
+
+// Publish records one delivery per subscription interested in evt and queues
+// them for the workers. Only the records are written before it returns, so an
+// event is not lost when the queue is full or the process stops; the sweeper
+// sends whatever the workers did not.
+func (s *WebhookService) Publish(ctx context.Context, evt domain.Event) {
+	// The event has happened, so record it even if the caller gives up
+	ctx = context.WithoutCancel(ctx)
+	for _, d := range s.recordDeliveries(ctx, evt) {
+		s.enqueue(d)
+	}
+}
+
+func (s *WebhookService) Subscribe(ctx context.Context, rawURL string, eventTypes []string) (*domain.WebhookSubscription, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("%w: url must be an absolute http(s) URL", domain.ErrInvalidSubscription)
+	}
+	if len(eventTypes) == 0 {
+		return nil, fmt.Errorf("%w: at least one event type is required", domain.ErrInvalidSubscription)
+	}
+	for _, t := range eventTypes {
+		if !domain.IsEventType(t) {
+			return nil, fmt.Errorf("%w: unknown event type %q", domain.ErrInvalidSubscription, t)
+		}
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+
+	sub := &domain.WebhookSubscription{
+		ID:         uuid.New().String(),
+		URL:        rawURL,
+		Secret:     hex.EncodeToString(secret),
+		EventTypes: eventTypes,
+		Active:     true,
+	}
+	if err := s.repo.CreateSubscription(ctx, sub); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+func (s *WebhookService) GetSubscription(ctx context.Context, id string) (*domain.WebhookSubscription, error) {
+	sub, err := s.repo.GetSubscription(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	sub.Secret = ""
+	return sub, nil
+}
+
+func (s *WebhookService) ListSubscriptions(ctx context.Context) ([]*domain.WebhookSubscription, error) {
+	subs, err := s.repo.ListSubscriptions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, sub := range subs {
+		sub.Secret = ""
+	}
+	return subs, nil
+}
+
+func (s *WebhookService) Unsubscribe(ctx context.Context, id string) error {
+	return s.repo.DeleteSubscription(ctx, id)
+}
+
+func (s *WebhookService) ListDeliveries(ctx context.Context, subscriptionID string) ([]*domain.WebhookDelivery, error) {
+	if _, err := s.repo.GetSubscription(ctx, subscriptionID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListDeliveries(ctx, subscriptionID)
+}
+
+// ReplayDelivery sends a recorded delivery again with a fresh retry budget
+func (s *WebhookService) ReplayDelivery(ctx context.Context, deliveryID string) (*domain.WebhookDelivery, error) {
+	d, err := s.repo.GetDelivery(ctx, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.cancelRetry(d.ID)
+	d.Status = domain.DeliveryPending
+	d.Attempts = 0
+	d.ResponseCode = 0
+	d.LastError = ""
+	d.NextAttemptAt = time.Now().Add(deliveryLease)
+	if err := s.repo.UpdateDelivery(ctx, d); err != nil {
+		return nil, err
+	}
+
+	s.enqueue(d)
+	return d, nil
+}
+
+func (s *WebhookService) work(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case d := <-s.deliveries:
+			s.mu.Lock()
+			delete(s.queued, d.ID)
+			s.mu.Unlock()
+			s.attempt(ctx, d)
+		}
+	}
+}
+
+// recordDeliveries records one pending delivery per subscription interested in evt
+func (s *WebhookService) recordDeliveries(ctx context.Context, evt domain.Event) []*domain.WebhookDelivery {
+	subs, err := s.repo.ListSubscriptionsForEvent(ctx, evt.Type)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to load webhook subscriptions", "event_id", evt.ID, "event_type", evt.Type, "error", err)
+		return nil
+	}
+	if len(subs) == 0 {
+		return nil
+	}
+
+	payload, err := json.Marshal(evt)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to encode event", "event_id", evt.ID, "error", err)
+		return nil
+	}
+
+	var ds []*domain.WebhookDelivery
+
+	for _, sub := range subs {
+		d := &domain.WebhookDelivery{
+			ID:             uuid.New().String(),
+			SubscriptionID: sub.ID,
+			EventID:        evt.ID,
+			EventType:      evt.Type,
+			Payload:        payload,
+			Status:         domain.DeliveryPending,
+			NextAttemptAt:  time.Now().Add(deliveryLease),
+		}
+		if err := s.repo.CreateDelivery(ctx, d); err != nil {
+			slog.ErrorContext(ctx, "failed to record webhook delivery", "event_id", evt.ID, "subscription_id", sub.ID, "error", err)
+			continue
+		}
+		ds = append(ds, d)
+	}
+	return ds
+}
+
+// attempt sends d once, records the outcome and schedules a retry on failure
+func (s *WebhookService) attempt(ctx context.Context, d *domain.WebhookDelivery) {
+	sub, err := s.repo.GetSubscription(ctx, d.SubscriptionID)
+	if errors.Is(err, domain.ErrSubscriptionNotFound) {
+		// Nobody is left to send it to. Repositories that delete deliveries
+		// along with their subscription have no row left to update.
+		d.Status = domain.DeliveryFailed
+		d.LastError = err.Error()
+		if err := s.repo.UpdateDelivery(ctx, d); err != nil && !errors.Is(err, domain.ErrDeliveryNotFound) {
+			slog.ErrorContext(ctx, "failed to record webhook delivery", "delivery_id", d.ID, "error", err)
+		}
+		return
+	}
+	if err != nil {
+		// Try again later without spending an attempt, since nothing was sent
+		if ctx.Err() != nil {
+			return
+		}
+		slog.WarnContext(ctx, "failed to load webhook subscription, retrying delivery", "delivery_id", d.ID, "error", err)
+		retry := s.sender.Backoff(d.Attempts + 1)
+		d.NextAttemptAt = time.Now().Add(retry + deliveryLease)
+		if err := s.repo.UpdateDelivery(ctx, d); err != nil {
+			slog.ErrorContext(ctx, "failed to record webhook delivery", "delivery_id", d.ID, "error", err)
+		}
+		s.scheduleRetry(d, retry)
+		return
+	}
+
+	d.Attempts++
+	code, err := s.sender.Send(ctx, webhook.Request{
+		URL:        sub.URL,
+		Secret:     sub.Secret,
+		EventID:    d.EventID,
+		EventType:  d.EventType,
+		DeliveryID: d.ID,
+		Payload:    d.Payload,
+	})
+	d.ResponseCode = code
+
+	var retry time.Duration
+	switch {
+	case err == nil:
+		d.Status = domain.DeliverySucceeded
+		d.LastError = ""
+	case d.Attempts >= s.sender.MaxAttempts:
+		d.Status = domain.DeliveryFailed
+		d.LastError = err.Error()
+	default:
+		d.Status = domain.DeliveryPending
+		d.LastError = err.Error()
+		retry = s.sender.Backoff(d.Attempts)
+		// Should this process stop before the retry, a sweeper picks it up
+		d.NextAttemptAt = time.Now().Add(retry + deliveryLease)
+	}
+
+	if err := s.repo.UpdateDelivery(ctx, d); err != nil {
+		slog.ErrorContext(ctx, "failed to record webhook delivery", "delivery_id", d.ID, "error", err)
+	}
+	if retry > 0 {
+		s.scheduleRetry(d, retry)
+	}
+}
+
+func (s *WebhookService) scheduleRetry(d *domain.WebhookDelivery, delay time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.retries[d.ID] = time.AfterFunc(delay, func() {
+		s.mu.Lock()
+		delete(s.retries, d.ID)
+		s.mu.Unlock()
+		s.enqueue(d)
+	})
+}
+
+func (s *WebhookService) cancelRetry(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if t, ok := s.retries[id]; ok {
+		t.Stop()
+		delete(s.retries, id)
+	}
+}
+
+// enqueue hands d to a worker unless it is already waiting for one. When the
+// queue is full d stays pending in the repository until a sweep claims it.
+func (s *WebhookService) enqueue(d *domain.WebhookDelivery) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.queued[d.ID]; ok {
+		return
+	}
+	select {
+	case s.deliveries <- d:
+		s.queued[d.ID] = struct{}{}
+	default:
+		slog.Warn("webhook queue full, delivery stays pending", "delivery_id", d.ID)
+	}
+}
+
+// sweep claims pending deliveries that are due and queues them, as long as
+// the queue has room. They are due when the process that held them stopped,
+// or its queue was full, without sending them.
+func (s *WebhookService) sweep(ctx context.Context) {
+	for {
+		limit := min(cap(s.deliveries)-len(s.deliveries), sweepBatch)
+		if limit <= 0 {
+			return
+		}
+		now := time.Now()
+		ds, err := s.repo.ClaimDueDeliveries(ctx, now, now.Add(deliveryLease), limit)
+		if err != nil {
+			if ctx.Err() == nil {
+				slog.ErrorContext(ctx, "failed to claim due webhook deliveries", "error", err)
+			}
+			return
+		}
+		for _, d := range ds {
+			s.enqueue(d)
+		}
+		if len(ds) < limit {
+			return
+		}
+	}
+}
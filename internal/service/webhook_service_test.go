@@ -0,0 +1,388 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"ledger/internal/domain"
+	"ledger/internal/service"
+	"ledger/internal/webhook"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memWebhookRepo is a minimal in-memory domain.WebhookRepository
+type memWebhookRepo struct {
+	mu         sync.Mutex
+	subs       map[string]domain.WebhookSubscription
+	deliveries map[string]domain.WebhookDelivery
+}
+
+func newMemWebhookRepo() *memWebhookRepo {
+	return &memWebhookRepo{
+		subs:       map[string]domain.WebhookSubscription{},
+		deliveries: map[string]domain.WebhookDelivery{},
+	}
+}
+
+func (r *memWebhookRepo) CreateSubscription(ctx context.Context, sub *domain.WebhookSubscription) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.subs[sub.ID] = *sub
+	return nil
+}
+
+func (r *memWebhookRepo) GetSubscription(ctx context.Context, id string) (*domain.WebhookSubscription, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	sub, ok := r.subs[id]
+	if !ok {
+		return nil, domain.ErrSubscriptionNotFound
+	}
+	return &sub, nil
+}
+
+func (r *memWebhookRepo) ListSubscriptions(ctx context.Context) ([]*domain.WebhookSubscription, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []*domain.WebhookSubscription
+	for _, sub := range r.subs {
+		sub := sub
+		out = append(out, &sub)
+	}
+	return out, nil
+}
+
+func (r *memWebhookRepo) ListSubscriptionsForEvent(ctx context.Context, eventType string) ([]*domain.WebhookSubscription, error) {
+	subs, _ := r.ListSubscriptions(ctx)
+	var out []*domain.WebhookSubscription
+	for _, sub := range subs {
+		for _, t := range sub.EventTypes {
+			if t == eventType && sub.Active {
+				out = append(out, sub)
+			}
+		}
+	}
+	return out, nil
+}
+
+func (r *memWebhookRepo) DeleteSubscription(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.subs[id]; !ok {
+		return domain.ErrSubscriptionNotFound
+	}
+	delete(r.subs, id)
+	return nil
+}
+
+func (r *memWebhookRepo) CreateDelivery(ctx context.Context, d *domain.WebhookDelivery) error {
+	return r.UpdateDelivery(ctx, d)
+}
+
+func (r *memWebhookRepo) UpdateDelivery(ctx context.Context, d *domain.WebhookDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.deliveries[d.ID] = *d
+	return nil
+}
+
+func (r *memWebhookRepo) GetDelivery(ctx context.Context, id string) (*domain.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	d, ok := r.deliveries[id]
+	if !ok {
+		return nil, domain.ErrDeliveryNotFound
+	}
+	return &d, nil
+}
+
+func (r *memWebhookRepo) ListDeliveries(ctx context.Context, subscriptionID string) ([]*domain.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []*domain.WebhookDelivery
+	for _, d := range r.deliveries {
+		if d.SubscriptionID == subscriptionID {
+			d := d
+			out = append(out, &d)
+		}
+	}
+	return out, nil
+}
+
+func (r *memWebhookRepo) ClaimDueDeliveries(ctx context.Context, now, until time.Time, limit int) ([]*domain.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []*domain.WebhookDelivery
+	for id, d := range r.deliveries {
+		if len(out) < limit && d.Status == domain.DeliveryPending && !d.NextAttemptAt.After(now) {
+			d.NextAttemptAt = until
+			r.deliveries[id] = d
+			out = append(out, &d)
+		}
+	}
+	return out, nil
+}
+
+func newTestWebhookService(t *testing.T, repo domain.WebhookRepository, maxAttempts int) *service.WebhookService {
+	t.Helper()
+	sender := webhook.NewSender(maxAttempts)
+	sender.BaseDelay = 5 * time.Millisecond
+	sender.MaxDelay = 20 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	svc := service.NewWebhookService(repo, sender)
+	svc.Start(ctx, 2)
+	return svc
+}
+
+// waitForDelivery polls until the subscription has a delivery matching done
+func waitForDelivery(t *testing.T, repo *memWebhookRepo, subID string, done func(*domain.WebhookDelivery) bool) *domain.WebhookDelivery {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		ds, _ := repo.ListDeliveries(context.Background(), subID)
+		if len(ds) == 1 && done(ds[0]) {
+			return ds[0]
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("timed out waiting for webhook delivery")
+	return nil
+}
+
+func TestWebhookService_Subscribe_Validation(t *testing.T) {
+	svc := service.NewWebhookService(newMemWebhookRepo(), webhook.NewSender(1))
+
+	_, err := svc.Subscribe(context.Background(), "ftp://example.com", []string{domain.EventTransactionSucceeded})
+	assert.ErrorIs(t, err, domain.ErrInvalidSubscription)
+
+	_, err = svc.Subscribe(context.Background(), "https://example.com/hook", nil)
+	assert.ErrorIs(t, err, domain.ErrInvalidSubscription)
+
+	_, err = svc.Subscribe(context.Background(), "https://example.com/hook", []string{"account.created"})
+	assert.ErrorIs(t, err, domain.ErrInvalidSubscription)
+}
+
+func TestWebhookService_SecretOnlyReturnedOnSubscribe(t *testing.T) {
+	svc := service.NewWebhookService(newMemWebhookRepo(), webhook.NewSender(1))
+
+	sub, err := svc.Subscribe(context.Background(), "https://example.com/hook", []string{domain.EventBalanceLow})
+	require.NoError(t, err)
+	assert.Len(t, sub.Secret, 64)
+
+	got, err := svc.GetSubscription(context.Background(), sub.ID)
+	require.NoError(t, err)
+	assert.Empty(t, got.Secret)
+}
+
+func TestWebhookService_DeliversSignedEvent(t *testing.T) {
+	var secret atomic.Value
+	received := make(chan error, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- webhook.Verify(secret.Load().(string), r.Header.Get(webhook.SignatureHeader), body, time.Minute)
+	}))
+	defer srv.Close()
+
+	repo := newMemWebhookRepo()
+	svc := newTestWebhookService(t, repo, 3)
+	sub, err := svc.Subscribe(context.Background(), srv.URL, []string{domain.EventTransactionSucceeded})
+	require.NoError(t, err)
+	secret.Store(sub.Secret)
+
+	svc.Publish(context.Background(), domain.Event{ID: "evt-1", Type: domain.EventAccountFrozen})
+	svc.Publish(context.Background(), domain.Event{ID: "evt-2", Type: domain.EventTransactionSucceeded})
+
+	select {
+	case err := <-received:
+		assert.NoError(t, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("receiver was never called")
+	}
+
+	d := waitForDelivery(t, repo, sub.ID, func(d *domain.WebhookDelivery) bool {
+		return d.Status == domain.DeliverySucceeded
+	})
+	assert.Equal(t, "evt-2", d.EventID)
+	assert.Equal(t, 1, d.Attempts)
+	assert.Equal(t, http.StatusOK, d.ResponseCode)
+}
+
+func TestWebhookService_RetriesUntilSuccess(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	repo := newMemWebhookRepo()
+	svc := newTestWebhookService(t, repo, 5)
+	sub, err := svc.Subscribe(context.Background(), srv.URL, []string{domain.EventTransactionFailed})
+	require.NoError(t, err)
+
+	svc.Publish(context.Background(), domain.Event{ID: "evt-1", Type: domain.EventTransactionFailed})
+
+	d := waitForDelivery(t, repo, sub.ID, func(d *domain.WebhookDelivery) bool {
+		return d.Status == domain.DeliverySucceeded
+	})
+	assert.Equal(t, 3, d.Attempts)
+	assert.Equal(t, http.StatusAccepted, d.ResponseCode)
+	assert.Empty(t, d.LastError)
+}
+
+func TestWebhookService_FailsAfterMaxAttemptsAndReplays(t *testing.T) {
+	var healthy atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+
+	repo := newMemWebhookRepo()
+	svc := newTestWebhookService(t, repo, 2)
+	sub, err := svc.Subscribe(context.Background(), srv.URL, []string{domain.EventBalanceLow})
+	require.NoError(t, err)
+
+	svc.Publish(context.Background(), domain.Event{ID: "evt-1", Type: domain.EventBalanceLow})
+
+	d := waitForDelivery(t, repo, sub.ID, func(d *domain.WebhookDelivery) bool {
+		return d.Status == domain.DeliveryFailed
+	})
+	assert.Equal(t, 2, d.Attempts)
+	assert.Equal(t, http.StatusInternalServerError, d.ResponseCode)
+	assert.NotEmpty(t, d.LastError)
+
+	healthy.Store(true)
+	_, err = svc.ReplayDelivery(context.Background(), d.ID)
+	require.NoError(t, err)
+
+	d = waitForDelivery(t, repo, sub.ID, func(d *domain.WebhookDelivery) bool {
+		return d.Status == domain.DeliverySucceeded
+	})
+	assert.Equal(t, 1, d.Attempts)
+	assert.Equal(t, http.StatusOK, d.ResponseCode)
+}
+
+func TestWebhookService_SweepsDeliveriesLeftPending(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+	}))
+	defer srv.Close()
+
+	// A previous process recorded a failed attempt and stopped before retrying
+	repo := newMemWebhookRepo()
+	ctx := context.Background()
+	require.NoError(t, repo.CreateSubscription(ctx, &domain.WebhookSubscription{ID: "sub-1", URL: srv.URL, Secret: "secret", EventTypes: []string{domain.EventBalanceLow}, Active: true}))
+	require.NoError(t, repo.CreateSubscription(ctx, &domain.WebhookSubscription{ID: "sub-2", URL: srv.URL, Secret: "secret", EventTypes: []string{domain.EventBalanceLow}, Active: true}))
+	require.NoError(t, repo.CreateDelivery(ctx, &domain.WebhookDelivery{
+		ID: "d-1", SubscriptionID: "sub-1", EventID: "evt-1", EventType: domain.EventBalanceLow, Payload: []byte(`{}`),
+		Status: domain.DeliveryPending, Attempts: 1, NextAttemptAt: time.Now().Add(-time.Second),
+	}))
+	// Another process still holds this one
+	require.NoError(t, repo.CreateDelivery(ctx, &domain.WebhookDelivery{
+		ID: "d-2", SubscriptionID: "sub-2", EventID: "evt-1", EventType: domain.EventBalanceLow, Payload: []byte(`{}`),
+		Status: domain.DeliveryPending, Attempts: 1, NextAttemptAt: time.Now().Add(time.Hour),
+	}))
+
+	newTestWebhookService(t, repo, 3)
+
+	d := waitForDelivery(t, repo, "sub-1", func(d *domain.WebhookDelivery) bool {
+		return d.Status == domain.DeliverySucceeded
+	})
+	assert.Equal(t, 2, d.Attempts)
+	held, err := repo.GetDelivery(ctx, "d-2")
+	require.NoError(t, err)
+	assert.Equal(t, domain.DeliveryPending, held.Status)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestWebhookService_PublishRecordsDeliveriesPastAFullQueue(t *testing.T) {
+	repo := newMemWebhookRepo()
+	ctx := context.Background()
+	require.NoError(t, repo.CreateSubscription(ctx, &domain.WebhookSubscription{ID: "sub-1", URL: "http://example.invalid", Secret: "secret", EventTypes: []string{domain.EventBalanceUpdated}, Active: true}))
+
+	// Without workers the queue fills up after 1024 deliveries
+	svc := service.NewWebhookService(repo, webhook.NewSender(1))
+	for i := range 1100 {
+		svc.Publish(ctx, domain.Event{ID: fmt.Sprintf("evt-%d", i), Type: domain.EventBalanceUpdated})
+	}
+
+	ds, err := repo.ListDeliveries(ctx, "sub-1")
+	require.NoError(t, err)
+	require.Len(t, ds, 1100)
+	for _, d := range ds {
+		assert.Equal(t, domain.DeliveryPending, d.Status)
+	}
+}
+
+func TestWebhookService_FailsDeliveriesOfDeletedSubscriptions(t *testing.T) {
+	repo := newMemWebhookRepo()
+	ctx := context.Background()
+	require.NoError(t, repo.CreateDelivery(ctx, &domain.WebhookDelivery{
+		ID: "d-1", SubscriptionID: "gone", EventID: "evt-1", EventType: domain.EventBalanceLow, Payload: []byte(`{}`),
+		Status: domain.DeliveryPending, NextAttemptAt: time.Now().Add(-time.Second),
+	}))
+
+	newTestWebhookService(t, repo, 3)
+
+	d := waitForDelivery(t, repo, "gone", func(d *domain.WebhookDelivery) bool {
+		return d.Status == domain.DeliveryFailed
+	})
+	assert.Equal(t, 0, d.Attempts)
+	assert.Contains(t, d.LastError, "subscription")
+}
+
+// flakySubscriptions fails the first lookups of a subscription
+type flakySubscriptions struct {
+	*memWebhookRepo
+	failures atomic.Int32
+}
+
+func (r *flakySubscriptions) GetSubscription(ctx context.Context, id string) (*domain.WebhookSubscription, error) {
+	if r.failures.Add(-1) >= 0 {
+		return nil, errors.New("connection reset")
+	}
+	return r.memWebhookRepo.GetSubscription(ctx, id)
+}
+
+func TestWebhookService_RetriesWhenTheSubscriptionCannotBeLoaded(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	repo := &flakySubscriptions{memWebhookRepo: newMemWebhookRepo()}
+	ctx := context.Background()
+	require.NoError(t, repo.CreateSubscription(ctx, &domain.WebhookSubscription{ID: "sub-1", URL: srv.URL, Secret: "secret", EventTypes: []string{domain.EventBalanceLow}, Active: true}))
+	repo.failures.Store(2)
+
+	svc := newTestWebhookService(t, repo, 3)
+	svc.Publish(ctx, domain.Event{ID: "evt-1", Type: domain.EventBalanceLow})
+
+	d := waitForDelivery(t, repo.memWebhookRepo, "sub-1", func(d *domain.WebhookDelivery) bool {
+		return d.Status == domain.DeliverySucceeded
+	})
+	assert.Equal(t, 1, d.Attempts, "failed lookups should not use up attempts")
+}
+
+func TestWebhookService_ReplayUnknownDelivery(t *testing.T) {
+	svc := service.NewWebhookService(newMemWebhookRepo(), webhook.NewSender(1))
+
+	_, err := svc.ReplayDelivery(context.Background(), "missing")
+	assert.ErrorIs(t, err, domain.ErrDeliveryNotFound)
+}
@@ -0,0 +1,25 @@
+package tracing
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// Middleware opens a server span for every request, continuing the trace from
+// an incoming traceparent header. Spans are named after the matched route
+// template, e.g. "GET /api/v1/accounts/{id}", so they group per handler.
+func Middleware(next http.Handler) http.Handler {
+	return otelhttp.NewHandler(next, "http", otelhttp.WithSpanNameFormatter(spanName))
+}
+
+func spanName(_ string, r *http.Request) string {
+	route := "unmatched"
+	if current := mux.CurrentRoute(r); current != nil {
+		if tmpl, err := current.GetPathTemplate(); err == nil {
+			route = tmpl
+		}
+	}
+	return r.Method + " " + route
+}
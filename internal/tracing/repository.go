@@ -0,0 +1,174 @@
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"ledger/internal/domain"
+)
+
+// Database systems reported in the db.system attribute of repository spans
+const (
+	SystemPostgres = "postgresql"
+	SystemMongo    = "mongodb"
+	SystemMemory   = "memory"
+	SystemSQLite   = "sqlite"
+)
+
+// startRepository opens a client span for a call into a repository backed by system
+func startRepository(ctx context.Context, name, system string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	attrs = append(attrs, attribute.String("db.system", system))
+	return otel.Tracer(instrumentationName).Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+}
+
+// AccountRepository opens a span around every domain.AccountRepository call
+type AccountRepository struct {
+	next   domain.AccountRepository
+	system string
+}
+
+func NewAccountRepository(next domain.AccountRepository, system string) *AccountRepository {
+	return &AccountRepository{next: next, system: system}
+}
+
+func (r *AccountRepository) Create(ctx context.Context, acc *domain.Account) error {
+	ctx, span := startRepository(ctx, "AccountRepository.Create", r.system)
+	err := r.next.Create(ctx, acc)
+	End(span, err)
+	return err
+}
+
+func (r *AccountRepository) GetByID(ctx context.Context, id string) (*domain.Account, error) {
+	ctx, span := startRepository(ctx, "AccountRepository.GetByID", r.system, attribute.String("account.id", id))
+	acc, err := r.next.GetByID(ctx, id)
+	End(span, err)
+	return acc, err
+}
+
+func (r *AccountRepository) GetAll(ctx context.Context) ([]*domain.Account, error) {
+	ctx, span := startRepository(ctx, "AccountRepository.GetAll", r.system)
+	accounts, err := r.next.GetAll(ctx)
+	End(span, err)
+	return accounts, err
+}
+
+func (r *AccountRepository) List(ctx context.Context, opts domain.AccountListOptions) (*domain.AccountPage, error) {
+	ctx, span := startRepository(ctx, "AccountRepository.List", r.system)
+	page, err := r.next.List(ctx, opts)
+	End(span, err)
+	return page, err
+}
+
+func (r *AccountRepository) GetByCustomerID(ctx context.Context, customerID string) ([]*domain.Account, error) {
+	ctx, span := startRepository(ctx, "AccountRepository.GetByCustomerID", r.system, attribute.String("customer.id", customerID))
+	accounts, err := r.next.GetByCustomerID(ctx, customerID)
+	End(span, err)
+	return accounts, err
+}
+
+func (r *AccountRepository) UpdateBalance(ctx context.Context, id string, amount float64) error {
+	ctx, span := startRepository(ctx, "AccountRepository.UpdateBalance", r.system, attribute.String("account.id", id))
+	err := r.next.UpdateBalance(ctx, id, amount)
+	End(span, err)
+	return err
+}
+
+func (r *AccountRepository) UpdateStatus(ctx context.Context, id string, status string) error {
+	ctx, span := startRepository(ctx, "AccountRepository.UpdateStatus", r.system, attribute.String("account.id", id))
+	err := r.next.UpdateStatus(ctx, id, status)
+	End(span, err)
+	return err
+}
+
+func (r *AccountRepository) Delete(ctx context.Context, id string) error {
+	ctx, span := startRepository(ctx, "AccountRepository.Delete", r.system, attribute.String("account.id", id))
+	err := r.next.Delete(ctx, id)
+	End(span, err)
+	return err
+}
+
+// LedgerRepository opens a span around every domain.LedgerRepository call
+type LedgerRepository struct {
+	next   domain.LedgerRepository
+	system string
+}
+
+func NewLedgerRepository(next domain.LedgerRepository, system string) *LedgerRepository {
+	return &LedgerRepository{next: next, system: system}
+}
+
+func (r *LedgerRepository) SaveEntry(ctx context.Context, entry *domain.LedgerEntry) error {
+	ctx, span := startRepository(ctx, "LedgerRepository.SaveEntry", r.system, attribute.String("transaction.id", entry.TransactionID))
+	err := r.next.SaveEntry(ctx, entry)
+	End(span, err)
+	return err
+}
+
+func (r *LedgerRepository) GetEntryByTransactionID(ctx context.Context, transactionID string) (*domain.LedgerEntry, error) {
+	ctx, span := startRepository(ctx, "LedgerRepository.GetEntryByTransactionID", r.system, attribute.String("transaction.id", transactionID))
+	entry, err := r.next.GetEntryByTransactionID(ctx, transactionID)
+	End(span, err)
+	return entry, err
+}
+
+func (r *LedgerRepository) GetEntriesByAccountID(ctx context.Context, accountID int64) ([]*domain.LedgerEntry, error) {
+	ctx, span := startRepository(ctx, "LedgerRepository.GetEntriesByAccountID", r.system, attribute.Int64("account.id", accountID))
+	entries, err := r.next.GetEntriesByAccountID(ctx, accountID)
+	End(span, err)
+	return entries, err
+}
+
+func (r *LedgerRepository) ListEntriesByAccountID(ctx context.Context, accountID int64, filter domain.HistoryFilter) (*domain.LedgerPage, error) {
+	ctx, span := startRepository(ctx, "LedgerRepository.ListEntriesByAccountID", r.system, attribute.Int64("account.id", accountID))
+	page, err := r.next.ListEntriesByAccountID(ctx, accountID, filter)
+	End(span, err)
+	return page, err
+}
+
+// CustomerRepository opens a span around every domain.CustomerRepository call
+type CustomerRepository struct {
+	next   domain.CustomerRepository
+	system string
+}
+
+func NewCustomerRepository(next domain.CustomerRepository, system string) *CustomerRepository {
+	return &CustomerRepository{next: next, system: system}
+}
+
+func (r *CustomerRepository) Create(ctx context.Context, c *domain.Customer) error {
+	ctx, span := startRepository(ctx, "CustomerRepository.Create", r.system)
+	err := r.next.Create(ctx, c)
+	End(span, err)
+	return err
+}
+
+func (r *CustomerRepository) GetByID(ctx context.Context, id string) (*domain.Customer, error) {
+	ctx, span := startRepository(ctx, "CustomerRepository.GetByID", r.system, attribute.String("customer.id", id))
+	c, err := r.next.GetByID(ctx, id)
+	End(span, err)
+	return c, err
+}
+
+func (r *CustomerRepository) GetAll(ctx context.Context) ([]*domain.Customer, error) {
+	ctx, span := startRepository(ctx, "CustomerRepository.GetAll", r.system)
+	customers, err := r.next.GetAll(ctx)
+	End(span, err)
+	return customers, err
+}
+
+func (r *CustomerRepository) Update(ctx context.Context, c *domain.Customer) error {
+	ctx, span := startRepository(ctx, "CustomerRepository.Update", r.system, attribute.String("customer.id", c.ID))
+	err := r.next.Update(ctx, c)
+	End(span, err)
+	return err
+}
+
+func (r *CustomerRepository) Delete(ctx context.Context, id string) error {
+	ctx, span := startRepository(ctx, "CustomerRepository.Delete", r.system, attribute.String("customer.id", id))
+	err := r.next.Delete(ctx, id)
+	End(span, err)
+	return err
+}
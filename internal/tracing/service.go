@@ -0,0 +1,175 @@
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"ledger/internal/domain"
+)
+
+// AccountService opens a span around every domain.AccountService call
+type AccountService struct {
+	next domain.AccountService
+}
+
+func NewAccountService(next domain.AccountService) *AccountService {
+	return &AccountService{next: next}
+}
+
+func (s *AccountService) CreateAccount(ctx context.Context, ownerName string, initialBalance float64) error {
+	ctx, span := Start(ctx, "AccountService.CreateAccount")
+	err := s.next.CreateAccount(ctx, ownerName, initialBalance)
+	End(span, err)
+	return err
+}
+
+func (s *AccountService) GetAccount(ctx context.Context, id string) (*domain.Account, error) {
+	ctx, span := Start(ctx, "AccountService.GetAccount", attribute.String("account.id", id))
+	acc, err := s.next.GetAccount(ctx, id)
+	End(span, err)
+	return acc, err
+}
+
+func (s *AccountService) GetAllAccounts(ctx context.Context) ([]*domain.Account, error) {
+	ctx, span := Start(ctx, "AccountService.GetAllAccounts")
+	accounts, err := s.next.GetAllAccounts(ctx)
+	End(span, err)
+	return accounts, err
+}
+
+func (s *AccountService) ListAccounts(ctx context.Context, opts domain.AccountListOptions) (*domain.AccountPage, error) {
+	ctx, span := Start(ctx, "AccountService.ListAccounts")
+	page, err := s.next.ListAccounts(ctx, opts)
+	End(span, err)
+	return page, err
+}
+
+func (s *AccountService) UpdateAccountBalance(ctx context.Context, id string, newBalance float64) error {
+	ctx, span := Start(ctx, "AccountService.UpdateAccountBalance", attribute.String("account.id", id))
+	err := s.next.UpdateAccountBalance(ctx, id, newBalance)
+	End(span, err)
+	return err
+}
+
+func (s *AccountService) FreezeAccount(ctx context.Context, id string) error {
+	ctx, span := Start(ctx, "AccountService.FreezeAccount", attribute.String("account.id", id))
+	err := s.next.FreezeAccount(ctx, id)
+	End(span, err)
+	return err
+}
+
+func (s *AccountService) UnfreezeAccount(ctx context.Context, id string) error {
+	ctx, span := Start(ctx, "AccountService.UnfreezeAccount", attribute.String("account.id", id))
+	err := s.next.UnfreezeAccount(ctx, id)
+	End(span, err)
+	return err
+}
+
+func (s *AccountService) DeleteAccount(ctx context.Context, id string) error {
+	ctx, span := Start(ctx, "AccountService.DeleteAccount", attribute.String("account.id", id))
+	err := s.next.DeleteAccount(ctx, id)
+	End(span, err)
+	return err
+}
+
+// TransactionService opens a span around every domain.TransactionService call
+type TransactionService struct {
+	next domain.TransactionService
+}
+
+func NewTransactionService(next domain.TransactionService) *TransactionService {
+	return &TransactionService{next: next}
+}
+
+func (s *TransactionService) ProcessTransaction(ctx context.Context, tx *domain.Transaction) error {
+	ctx, span := Start(ctx, "TransactionService.ProcessTransaction",
+		attribute.Int64("transaction.from_account_id", tx.FromAccountID),
+		attribute.Int64("transaction.to_account_id", tx.ToAccountID),
+		attribute.String("transaction.currency", tx.Currency),
+	)
+	err := s.next.ProcessTransaction(ctx, tx)
+	// The ID is assigned by the service when the caller did not set one
+	span.SetAttributes(attribute.String("transaction.id", tx.ID))
+	End(span, err)
+	return err
+}
+
+func (s *TransactionService) GetTransactionHistory(ctx context.Context, accountID int64) ([]*domain.Transaction, error) {
+	ctx, span := Start(ctx, "TransactionService.GetTransactionHistory", attribute.Int64("account.id", accountID))
+	txs, err := s.next.GetTransactionHistory(ctx, accountID)
+	End(span, err)
+	return txs, err
+}
+
+func (s *TransactionService) ListTransactionHistory(ctx context.Context, accountID int64, filter domain.HistoryFilter) (*domain.TransactionPage, error) {
+	ctx, span := Start(ctx, "TransactionService.ListTransactionHistory", attribute.Int64("account.id", accountID))
+	page, err := s.next.ListTransactionHistory(ctx, accountID, filter)
+	End(span, err)
+	return page, err
+}
+
+// CustomerService opens a span around every domain.CustomerService call
+type CustomerService struct {
+	next domain.CustomerService
+}
+
+func NewCustomerService(next domain.CustomerService) *CustomerService {
+	return &CustomerService{next: next}
+}
+
+func (s *CustomerService) CreateCustomer(ctx context.Context, name, email string) (*domain.Customer, error) {
+	ctx, span := Start(ctx, "CustomerService.CreateCustomer")
+	c, err := s.next.CreateCustomer(ctx, name, email)
+	End(span, err)
+	return c, err
+}
+
+func (s *CustomerService) GetCustomer(ctx context.Context, id string) (*domain.Customer, error) {
+	ctx, span := Start(ctx, "CustomerService.GetCustomer", attribute.String("customer.id", id))
+	c, err := s.next.GetCustomer(ctx, id)
+	End(span, err)
+	return c, err
+}
+
+func (s *CustomerService) GetAllCustomers(ctx context.Context) ([]*domain.Customer, error) {
+	ctx, span := Start(ctx, "CustomerService.GetAllCustomers")
+	customers, err := s.next.GetAllCustomers(ctx)
+	End(span, err)
+	return customers, err
+}
+
+func (s *CustomerService) UpdateCustomer(ctx context.Context, id, name, email string) (*domain.Customer, error) {
+	ctx, span := Start(ctx, "CustomerService.UpdateCustomer", attribute.String("customer.id", id))
+	c, err := s.next.UpdateCustomer(ctx, id, name, email)
+	End(span, err)
+	return c, err
+}
+
+func (s *CustomerService) DeleteCustomer(ctx context.Context, id string) error {
+	ctx, span := Start(ctx, "CustomerService.DeleteCustomer", attribute.String("customer.id", id))
+	err := s.next.DeleteCustomer(ctx, id)
+	End(span, err)
+	return err
+}
+
+func (s *CustomerService) OpenAccount(ctx context.Context, customerID, currency string, initialBalance float64) (*domain.Account, error) {
+	ctx, span := Start(ctx, "CustomerService.OpenAccount", attribute.String("customer.id", customerID))
+	acc, err := s.next.OpenAccount(ctx, customerID, currency, initialBalance)
+	End(span, err)
+	return acc, err
+}
+
+func (s *CustomerService) GetCustomerAccounts(ctx context.Context, customerID string) ([]*domain.Account, error) {
+	ctx, span := Start(ctx, "CustomerService.GetCustomerAccounts", attribute.String("customer.id", customerID))
+	accounts, err := s.next.GetCustomerAccounts(ctx, customerID)
+	End(span, err)
+	return accounts, err
+}
+
+func (s *CustomerService) GetCustomerBalances(ctx context.Context, customerID string) (*domain.CustomerBalances, error) {
+	ctx, span := Start(ctx, "CustomerService.GetCustomerBalances", attribute.String("customer.id", customerID))
+	balances, err := s.next.GetCustomerBalances(ctx, customerID)
+	End(span, err)
+	return balances, err
+}
@@ -0,0 +1,114 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"ledger/internal/domain"
+)
+
+// record installs a tracer provider that keeps finished spans in memory
+func record(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	rec := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+	return rec
+}
+
+func attr(span sdktrace.ReadOnlySpan, key string) (attribute.Value, bool) {
+	for _, kv := range span.Attributes() {
+		if string(kv.Key) == key {
+			return kv.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+func TestMiddleware_NamesSpansByRoute(t *testing.T) {
+	rec := record(t)
+
+	router := mux.NewRouter()
+	router.Use(Middleware)
+	router.HandleFunc("/api/v1/accounts/{id}", func(w http.ResponseWriter, r *http.Request) {}).Methods("GET")
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/v1/accounts/42", nil))
+
+	spans := rec.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("expected one span, got %d", len(spans))
+	}
+	if got := spans[0].Name(); got != "GET /api/v1/accounts/{id}" {
+		t.Errorf("unexpected span name %q", got)
+	}
+}
+
+type stubAccounts struct {
+	domain.AccountRepository
+	err error
+}
+
+func (s stubAccounts) GetByID(ctx context.Context, id string) (*domain.Account, error) {
+	return &domain.Account{ID: id}, s.err
+}
+
+func TestRepository_SpansNestUnderService(t *testing.T) {
+	rec := record(t)
+
+	repo := NewAccountRepository(stubAccounts{}, SystemPostgres)
+	ctx, parent := Start(context.Background(), "TransactionService.ProcessTransaction")
+	repo.GetByID(ctx, "7")
+	parent.End()
+
+	spans := rec.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("expected two spans, got %d", len(spans))
+	}
+	child := spans[0]
+	if child.Name() != "AccountRepository.GetByID" {
+		t.Errorf("unexpected span name %q", child.Name())
+	}
+	if child.Parent().SpanID() != spans[1].SpanContext().SpanID() {
+		t.Error("repository span should be a child of the service span")
+	}
+	if v, _ := attr(child, "db.system"); v.AsString() != SystemPostgres {
+		t.Errorf("expected db.system %q, got %q", SystemPostgres, v.AsString())
+	}
+}
+
+func TestEnd_OnlyInternalErrorsFailTheSpan(t *testing.T) {
+	rec := record(t)
+
+	NewAccountRepository(stubAccounts{err: domain.ErrAccountNotFound}, SystemPostgres).GetByID(context.Background(), "1")
+	NewAccountRepository(stubAccounts{err: errors.New("connection reset")}, SystemPostgres).GetByID(context.Background(), "1")
+
+	spans := rec.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("expected two spans, got %d", len(spans))
+	}
+	if spans[0].Status().Code == codes.Error {
+		t.Error("a not found result should not mark the span as failed")
+	}
+	if v, _ := attr(spans[0], "error.code"); v.AsString() != "account_not_found" {
+		t.Errorf("expected error.code account_not_found, got %q", v.AsString())
+	}
+	if spans[1].Status().Code != codes.Error {
+		t.Error("an unexpected error should mark the span as failed")
+	}
+}
+
+func TestSetup_RejectsUnknownExporter(t *testing.T) {
+	if _, err := Setup(context.Background(), "zipkin", "test", nil); err == nil {
+		t.Error("expected an error for an unknown exporter")
+	}
+}
@@ -0,0 +1,84 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Headers sent with every delivery besides the signature
+const (
+	EventIDHeader   = "X-Ledger-Event-Id"
+	EventTypeHeader = "X-Ledger-Event-Type"
+	DeliveryHeader  = "X-Ledger-Delivery-Id"
+)
+
+// Sender posts signed payloads and computes the retry schedule
+type Sender struct {
+	Client      *http.Client
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// NewSender creates a Sender with sensible defaults for production use
+func NewSender(maxAttempts int) *Sender {
+	return &Sender{
+		Client:      &http.Client{Timeout: 10 * time.Second},
+		MaxAttempts: maxAttempts,
+		BaseDelay:   time.Second,
+		MaxDelay:    10 * time.Minute,
+	}
+}
+
+// Request describes a single delivery attempt
+type Request struct {
+	URL        string
+	Secret     string
+	EventID    string
+	EventType  string
+	DeliveryID string
+	Payload    []byte
+}
+
+// Send makes one delivery attempt. It returns the response status code (0 if no
+// response was received) and an error unless the receiver answered with a 2xx.
+func (s *Sender) Send(ctx context.Context, req Request) (int, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, req.URL, bytes.NewReader(req.Payload))
+	if err != nil {
+		return 0, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set(SignatureHeader, Sign(req.Secret, time.Now(), req.Payload))
+	httpReq.Header.Set(EventIDHeader, req.EventID)
+	httpReq.Header.Set(EventTypeHeader, req.EventType)
+	httpReq.Header.Set(DeliveryHeader, req.DeliveryID)
+
+	resp, err := s.Client.Do(httpReq)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("receiver responded with %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// Backoff returns how long to wait after the given failed attempt (1-based),
+// doubling from BaseDelay and capped at MaxDelay
+func (s *Sender) Backoff(attempt int) time.Duration {
+	d := s.BaseDelay
+	for i := 1; i < attempt; i++ {
+		d *= 2
+		if d >= s.MaxDelay {
+			return s.MaxDelay
+		}
+	}
+	return d
+}
@@ -0,0 +1,63 @@
+// Package webhook signs and sends event notifications to subscriber URLs.
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SignatureHeader carries "t=<unix seconds>,v1=<hex HMAC-SHA256>" on every delivery.
+// The MAC covers "<t>.<body>" so receivers can reject replayed requests.
+const SignatureHeader = "X-Ledger-Signature"
+
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// Sign computes the signature header value for body sent at ts
+func Sign(secret string, ts time.Time, body []byte) string {
+	t := strconv.FormatInt(ts.Unix(), 10)
+	return fmt.Sprintf("t=%s,v1=%s", t, mac(secret, t, body))
+}
+
+// Verify checks a signature header produced by Sign. Signatures older than
+// tolerance are rejected; a zero tolerance disables the age check.
+func Verify(secret, header string, body []byte, tolerance time.Duration) error {
+	var t, v1 string
+	for _, part := range strings.Split(header, ",") {
+		k, v, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch k {
+		case "t":
+			t = v
+		case "v1":
+			v1 = v
+		}
+	}
+	if t == "" || v1 == "" {
+		return ErrInvalidSignature
+	}
+
+	if tolerance > 0 {
+		unix, err := strconv.ParseInt(t, 10, 64)
+		if err != nil || time.Since(time.Unix(unix, 0)) > tolerance {
+			return ErrInvalidSignature
+		}
+	}
+
+	if !hmac.Equal([]byte(v1), []byte(mac(secret, t, body))) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+func mac(secret, t string, body []byte) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(t))
+	h.Write([]byte("."))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,95 @@
+package webhook_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"ledger/internal/webhook"
+)
+
+func TestSignVerify(t *testing.T) {
+	body := []byte(`{"id":"evt-1"}`)
+	header := webhook.Sign("secret", time.Now(), body)
+
+	if err := webhook.Verify("secret", header, body, time.Minute); err != nil {
+		t.Fatalf("expected valid signature, got %v", err)
+	}
+	if err := webhook.Verify("other", header, body, time.Minute); err != webhook.ErrInvalidSignature {
+		t.Errorf("expected wrong secret to fail, got %v", err)
+	}
+	if err := webhook.Verify("secret", header, []byte(`{"id":"evt-2"}`), time.Minute); err != webhook.ErrInvalidSignature {
+		t.Errorf("expected tampered body to fail, got %v", err)
+	}
+	if err := webhook.Verify("secret", "garbage", body, 0); err != webhook.ErrInvalidSignature {
+		t.Errorf("expected malformed header to fail, got %v", err)
+	}
+}
+
+func TestVerify_Stale(t *testing.T) {
+	body := []byte(`{}`)
+	header := webhook.Sign("secret", time.Now().Add(-time.Hour), body)
+
+	if err := webhook.Verify("secret", header, body, 5*time.Minute); err != webhook.ErrInvalidSignature {
+		t.Errorf("expected stale signature to fail, got %v", err)
+	}
+	if err := webhook.Verify("secret", header, body, 0); err != nil {
+		t.Errorf("expected zero tolerance to skip the age check, got %v", err)
+	}
+}
+
+func TestSend(t *testing.T) {
+	var got *http.Request
+	var gotBody []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		gotBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	s := webhook.NewSender(3)
+	code, err := s.Send(context.Background(), webhook.Request{
+		URL:        srv.URL,
+		Secret:     "secret",
+		EventID:    "evt-1",
+		EventType:  "transaction.succeeded",
+		DeliveryID: "del-1",
+		Payload:    []byte(`{"ok":true}`),
+	})
+	if err != nil || code != http.StatusNoContent {
+		t.Fatalf("expected 204 without error, got %d %v", code, err)
+	}
+	if got.Header.Get(webhook.EventTypeHeader) != "transaction.succeeded" || got.Header.Get(webhook.DeliveryHeader) != "del-1" {
+		t.Errorf("unexpected headers: %v", got.Header)
+	}
+	if err := webhook.Verify("secret", got.Header.Get(webhook.SignatureHeader), gotBody, time.Minute); err != nil {
+		t.Errorf("receiver could not verify signature: %v", err)
+	}
+}
+
+func TestSend_ErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	code, err := webhook.NewSender(3).Send(context.Background(), webhook.Request{URL: srv.URL, Payload: []byte(`{}`)})
+	if err == nil || code != http.StatusBadGateway {
+		t.Errorf("expected 502 with error, got %d %v", code, err)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	s := &webhook.Sender{BaseDelay: time.Second, MaxDelay: 10 * time.Second}
+
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second}
+	for i, w := range want {
+		if got := s.Backoff(i + 1); got != w {
+			t.Errorf("attempt %d: expected %v, got %v", i+1, w, got)
+		}
+	}
+}